@@ -1,3 +1,10 @@
 module github.com/jeronimobarea/transaction_parser
 
 go 1.24.2
+
+require (
+	github.com/lib/pq v1.12.3
+	go.etcd.io/bbolt v1.4.3
+)
+
+require golang.org/x/sys v0.29.0 // indirect
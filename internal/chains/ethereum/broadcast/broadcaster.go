@@ -0,0 +1,257 @@
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+// retention is how long transactions are kept once they reach a final status.
+const retention = 24 * time.Hour
+
+var rawTransactionRegex = regexp.MustCompile(`^0x([0-9a-fA-F]{2})+$`)
+
+type broadcaster struct {
+	ethClient     client.Client
+	repo          ethereum.Repository
+	confirmations uint64
+	// resolveGrace is how long a transaction whose sender is unresolved stays pending while
+	// the node doesn't know it, the node indexing it some time after accepting it.
+	resolveGrace time.Duration
+	logger       *log.Logger
+
+	mu  sync.RWMutex
+	txs map[string]*parser.BroadcastTransaction
+	// unsubscribed holds the transactions whose sender is still to be subscribed, either
+	// unresolved yet or failed to subscribe.
+	unsubscribed map[string]struct{}
+}
+
+// NewBroadcaster creates a broadcaster where transactions are confirmed once they are
+// buried under the given number of confirmations, their senders being subscribed to repo.
+// A transaction the node can't resolve is dropped once resolveGrace has passed.
+func NewBroadcaster(ethClient client.Client, repo ethereum.Repository, confirmations uint64, resolveGrace time.Duration, logger *log.Logger) ethereum.Broadcaster {
+	return &broadcaster{
+		ethClient:     ethClient,
+		repo:          repo,
+		confirmations: confirmations,
+		resolveGrace:  resolveGrace,
+		logger:        logger,
+		txs:           make(map[string]*parser.BroadcastTransaction),
+		unsubscribed:  make(map[string]struct{}),
+	}
+}
+
+func (b *broadcaster) Broadcast(ctx context.Context, rawTx string) (parser.BroadcastTransaction, error) {
+	if !rawTransactionRegex.MatchString(rawTx) {
+		return parser.BroadcastTransaction{}, ethereum.ErrInvalidRawTransaction
+	}
+
+	hash, err := b.ethClient.SendRawTransaction(ctx, rawTx)
+	if err != nil {
+		b.logger.Printf("error sending raw transaction: %v\n", err)
+
+		// the node refusing the transaction (bad nonce, underpriced...) is on the caller
+		var rpcErr *client.RPCError
+		if errors.As(err, &rpcErr) && !client.IsRetryable(err) {
+			return parser.BroadcastTransaction{}, fmt.Errorf("%w: %s", ethereum.ErrTransactionRejected, rpcErr.Message)
+		}
+		return parser.BroadcastTransaction{}, err
+	}
+
+	now := time.Now().UTC()
+	broadcastTx := &parser.BroadcastTransaction{
+		Hash:        normalizeHash(hash),
+		Status:      parser.BroadcastStatusPending,
+		SubmittedAt: now,
+		UpdatedAt:   now,
+	}
+
+	// the node resolves the sender and nonce, saving us from decoding and recovering the signature.
+	// The transaction is on the network already, so a failed lookup leaves them to the tracking.
+	tx, err := b.ethClient.GetTransactionByHash(ctx, hash)
+	if err != nil {
+		b.logger.Printf("error retrieving broadcast transaction: %s: %v\n", hash, err)
+	} else {
+		broadcastTx.From, broadcastTx.Nonce = evm.Address(tx.From), tx.Nonce
+	}
+
+	subscribed := broadcastTx.From != "" && b.subscribe(ctx, broadcastTx.From)
+
+	b.mu.Lock()
+	b.txs[broadcastTx.Hash] = broadcastTx
+	if !subscribed {
+		b.unsubscribed[broadcastTx.Hash] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	b.logger.Printf("[INFO] transaction broadcast: %s from %s nonce %d\n", hash, broadcastTx.From, broadcastTx.Nonce)
+	return *broadcastTx, nil
+}
+
+// subscribe watches the sender so the transaction shows up in its transactions once mined,
+// and reports whether it is subscribed. The transaction is out already, so a failure is only
+// logged and retried by the tracking.
+func (b *broadcaster) subscribe(ctx context.Context, from evm.Address) bool {
+	subscribed, err := b.repo.HasAddress(ctx, from)
+	if err != nil {
+		b.logger.Printf("error checking subscription: %s: %v\n", from, err)
+		return false
+	}
+	if subscribed {
+		return true
+	}
+
+	err = b.repo.AddAddress(ctx, from)
+	if err != nil && !errors.Is(err, ethereum.ErrAddressConflict) {
+		b.logger.Printf("error subscribing broadcast sender: %s: %v\n", from, err)
+		return false
+	}
+	return true
+}
+
+func (b *broadcaster) GetBroadcast(_ context.Context, hash string) (parser.BroadcastTransaction, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	tx, ok := b.txs[normalizeHash(hash)]
+	if !ok {
+		return parser.BroadcastTransaction{}, fmt.Errorf("%w: %s", ethereum.ErrBroadcastNotFound, hash)
+	}
+	return *tx, nil
+}
+
+func (b *broadcaster) Track(ctx context.Context) error {
+	b.mu.RLock()
+	var tracked []parser.BroadcastTransaction
+	for _, tx := range b.txs {
+		tracked = append(tracked, *tx)
+	}
+	b.mu.RUnlock()
+
+	now := time.Now().UTC()
+
+	var errs []error
+	for _, tx := range tracked {
+		if tx.Status.IsFinal() {
+			if now.Sub(tx.UpdatedAt) > retention {
+				b.mu.Lock()
+				delete(b.txs, tx.Hash)
+				delete(b.unsubscribed, tx.Hash)
+				b.mu.Unlock()
+			}
+			continue
+		}
+
+		updated, err := b.refresh(ctx, tx)
+		if err != nil {
+			b.logger.Printf("error tracking broadcast transaction: %s: %v\n", tx.Hash, err)
+			errs = append(errs, err)
+			continue
+		}
+
+		b.mu.RLock()
+		_, unsubscribed := b.unsubscribed[tx.Hash]
+		b.mu.RUnlock()
+		if unsubscribed && updated.From != "" && b.subscribe(ctx, updated.From) {
+			b.mu.Lock()
+			delete(b.unsubscribed, tx.Hash)
+			b.mu.Unlock()
+		}
+		if updated.Status == tx.Status && updated.Confirmations == tx.Confirmations && updated.From == tx.From {
+			continue
+		}
+
+		updated.UpdatedAt = now
+		b.logger.Printf("[INFO] broadcast transaction %s: %s\n", tx.Hash, updated.Status)
+
+		b.mu.Lock()
+		b.txs[tx.Hash] = &updated
+		b.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// refresh derives the status of tx from the chain: its receipt when included, otherwise
+// whether its nonce was taken by another transaction or it left the node mempool. The sender
+// and nonce of a transaction that couldn't be looked up once sent are resolved first.
+func (b *broadcaster) refresh(ctx context.Context, tx parser.BroadcastTransaction) (parser.BroadcastTransaction, error) {
+	if tx.From == "" {
+		sent, err := b.ethClient.GetTransactionByHash(ctx, tx.Hash)
+		switch {
+		case err == nil:
+			tx.From, tx.Nonce = evm.Address(sent.From), sent.Nonce
+		case !errors.Is(err, client.ErrTransactionNotFound):
+			return tx, err
+		}
+	}
+
+	receipt, err := b.ethClient.GetTransactionReceipt(ctx, tx.Hash)
+	switch {
+	case err == nil:
+		head, err := b.ethClient.BlockNumber(ctx)
+		if err != nil {
+			return tx, err
+		}
+
+		tx.BlockNumber = receipt.BlockNumber
+		tx.Confirmations = 0
+		if head >= receipt.BlockNumber {
+			tx.Confirmations = uint64(head-receipt.BlockNumber) + 1
+		}
+
+		tx.Status = parser.BroadcastStatusIncluded
+		if tx.Confirmations >= b.confirmations {
+			tx.Status = parser.BroadcastStatusConfirmed
+		}
+		return tx, nil
+	case !errors.Is(err, client.ErrReceiptNotFound):
+		return tx, err
+	}
+
+	// not included, or reorged out
+	tx.BlockNumber, tx.Confirmations = 0, 0
+
+	// the node doesn't know the transaction it never resolved, which may just not be indexed yet
+	if tx.From == "" {
+		tx.Status = parser.BroadcastStatusPending
+		if time.Since(tx.SubmittedAt) >= b.resolveGrace {
+			tx.Status = parser.BroadcastStatusDropped
+		}
+		return tx, nil
+	}
+
+	confirmedNonce, err := b.ethClient.GetTransactionCount(ctx, tx.From, client.LatestBlock)
+	if err != nil {
+		return tx, err
+	}
+	if confirmedNonce > tx.Nonce {
+		tx.Status = parser.BroadcastStatusReplaced
+		return tx, nil
+	}
+
+	_, err = b.ethClient.GetTransactionByHash(ctx, tx.Hash)
+	switch {
+	case err == nil:
+		tx.Status = parser.BroadcastStatusPending
+	case errors.Is(err, client.ErrTransactionNotFound):
+		tx.Status = parser.BroadcastStatusDropped
+	default:
+		return tx, err
+	}
+	return tx, nil
+}
+
+func normalizeHash(hash string) string {
+	return strings.ToLower(hash)
+}
@@ -0,0 +1,301 @@
+package broadcast_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/broadcast"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/test"
+	"github.com/jeronimobarea/transaction_parser/internal/test/ethereumtest"
+)
+
+const (
+	sender = evm.Address("0x1111111111111111111111111111111111111111")
+	hash   = "0xabc1"
+	rawTx  = "0x02f8b00184"
+)
+
+// failingSubscriptions fails to subscribe addresses while err is set.
+type failingSubscriptions struct {
+	ethereum.Repository
+	err error
+}
+
+func (r *failingSubscriptions) AddAddress(ctx context.Context, address evm.Address) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.Repository.AddAddress(ctx, address)
+}
+
+func newBroadcastClient() *ethereumtest.FakeClient {
+	return &ethereumtest.FakeClient{
+		SendRawTxResp: hash,
+		GetTxByHashResp: map[string]*client.TransactionResponse{
+			hash: {Hash: hash, From: string(sender), Nonce: 7},
+		},
+		GetTxCountResp:     map[string]evm.Nonce{client.LatestBlock: 7},
+		GetReceiptNotFound: true,
+		GetReceiptResp:     map[string]*client.ReceiptResponse{},
+	}
+}
+
+func TestBroadcaster_Broadcast(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("happy path", func(t *testing.T) {
+		b := broadcast.NewBroadcaster(newBroadcastClient(), ethereumtest.FakeRepo{}, 3, 0, log.Default())
+
+		tx, err := b.Broadcast(ctx, rawTx)
+		if err != nil {
+			t.Fatalf("Broadcast: unexpected error: %v", err)
+		}
+		if tx.Hash != hash || tx.From != sender || tx.Nonce != 7 || tx.Status != parser.BroadcastStatusPending {
+			t.Errorf("Broadcast: unexpected transaction %+v", tx)
+		}
+
+		got, err := b.GetBroadcast(ctx, "0xABC1")
+		if err != nil {
+			t.Fatalf("GetBroadcast: unexpected error: %v", err)
+		}
+		if got.Hash != hash {
+			t.Errorf("GetBroadcast: want %s, got %+v", hash, got)
+		}
+	})
+
+	t.Run("invalid raw transaction", func(t *testing.T) {
+		b := broadcast.NewBroadcaster(newBroadcastClient(), ethereumtest.FakeRepo{}, 3, 0, log.Default())
+
+		for _, raw := range []string{"", "0x", "02f8", "0x0", "0xzz"} {
+			if _, err := b.Broadcast(ctx, raw); !errors.Is(err, ethereum.ErrInvalidRawTransaction) {
+				t.Errorf("Broadcast(%q): expected %v, got %v", raw, ethereum.ErrInvalidRawTransaction, err)
+			}
+		}
+	})
+
+	t.Run("node rejects", func(t *testing.T) {
+		fc := newBroadcastClient()
+		fc.SendRawTxErr = test.DummyErr
+		b := broadcast.NewBroadcaster(fc, ethereumtest.FakeRepo{}, 3, 0, log.Default())
+
+		if _, err := b.Broadcast(ctx, rawTx); !errors.Is(err, test.DummyErr) {
+			t.Errorf("Broadcast: expected %v, got %v", test.DummyErr, err)
+		}
+	})
+
+	t.Run("node refuses the transaction", func(t *testing.T) {
+		fc := newBroadcastClient()
+		fc.SendRawTxErr = &client.RPCError{Code: -32000, Message: "nonce too low"}
+		b := broadcast.NewBroadcaster(fc, ethereumtest.FakeRepo{}, 3, 0, log.Default())
+
+		if _, err := b.Broadcast(ctx, rawTx); !errors.Is(err, ethereum.ErrTransactionRejected) {
+			t.Errorf("Broadcast: expected %v, got %v", ethereum.ErrTransactionRejected, err)
+		}
+	})
+
+	t.Run("subscribes the sender", func(t *testing.T) {
+		repo := repository.NewMemoryStorage()
+		b := broadcast.NewBroadcaster(newBroadcastClient(), repo, 3, 0, log.Default())
+
+		if _, err := b.Broadcast(ctx, rawTx); err != nil {
+			t.Fatalf("Broadcast: unexpected error: %v", err)
+		}
+		if subscribed, _ := repo.HasAddress(ctx, sender); !subscribed {
+			t.Errorf("Broadcast: expected %s to be subscribed", sender)
+		}
+
+		// broadcasting again from a subscribed sender is fine
+		if _, err := b.Broadcast(ctx, rawTx); err != nil {
+			t.Errorf("Broadcast: unexpected error: %v", err)
+		}
+	})
+
+	t.Run("subscription fails once sent", func(t *testing.T) {
+		repo := &failingSubscriptions{Repository: repository.NewMemoryStorage(), err: test.DummyErr}
+		b := broadcast.NewBroadcaster(newBroadcastClient(), repo, 3, 0, log.Default())
+
+		// the transaction is out, so its hash is returned anyway
+		tx, err := b.Broadcast(ctx, rawTx)
+		if err != nil {
+			t.Fatalf("Broadcast: unexpected error: %v", err)
+		}
+		if tx.Hash != hash {
+			t.Errorf("Broadcast: want %s, got %+v", hash, tx)
+		}
+
+		// the tracking subscribes the sender once the repository is back
+		repo.err = nil
+		if err := b.Track(ctx); err != nil {
+			t.Fatalf("Track: unexpected error: %v", err)
+		}
+		if subscribed, _ := repo.HasAddress(ctx, sender); !subscribed {
+			t.Errorf("Track: expected %s to be subscribed", sender)
+		}
+	})
+
+	t.Run("lookup fails once sent", func(t *testing.T) {
+		fc := newBroadcastClient()
+		fc.GetTxByHashErr = test.DummyErr
+		repo := repository.NewMemoryStorage()
+		b := broadcast.NewBroadcaster(fc, repo, 3, 0, log.Default())
+
+		// the transaction is on the network, so it is tracked rather than failed
+		tx, err := b.Broadcast(ctx, rawTx)
+		if err != nil {
+			t.Fatalf("Broadcast: unexpected error: %v", err)
+		}
+		if tx.Hash != hash || tx.From != "" || tx.Status != parser.BroadcastStatusPending {
+			t.Errorf("Broadcast: expected the pending hash without sender, got %+v", tx)
+		}
+
+		// the tracking resolves the sender
+		fc.GetTxByHashErr = nil
+		if err := b.Track(ctx); err != nil {
+			t.Fatalf("Track: unexpected error: %v", err)
+		}
+		got, _ := b.GetBroadcast(ctx, hash)
+		if got.From != sender || got.Nonce != 7 || got.Status != parser.BroadcastStatusPending {
+			t.Errorf("Track: expected the sender and nonce to be resolved, got %+v", got)
+		}
+		if subscribed, _ := repo.HasAddress(ctx, sender); !subscribed {
+			t.Errorf("Track: expected the resolved sender %s to be subscribed", sender)
+		}
+	})
+
+	t.Run("unresolved sender", func(t *testing.T) {
+		fc := newBroadcastClient()
+		delete(fc.GetTxByHashResp, hash)
+
+		for _, tc := range []struct {
+			grace time.Duration
+			want  parser.BroadcastStatus
+		}{
+			{grace: time.Hour, want: parser.BroadcastStatusPending},
+			{grace: 0, want: parser.BroadcastStatusDropped},
+		} {
+			b := broadcast.NewBroadcaster(fc, ethereumtest.FakeRepo{}, 3, tc.grace, log.Default())
+			if _, err := b.Broadcast(ctx, rawTx); err != nil {
+				t.Fatalf("Broadcast: unexpected error: %v", err)
+			}
+
+			// the node hasn't indexed the transaction yet
+			if err := b.Track(ctx); err != nil {
+				t.Fatalf("Track: unexpected error: %v", err)
+			}
+			if got, _ := b.GetBroadcast(ctx, hash); got.Status != tc.want {
+				t.Errorf("Track with a %s grace: want %s, got %s", tc.grace, tc.want, got.Status)
+			}
+		}
+	})
+
+	t.Run("unknown hash", func(t *testing.T) {
+		b := broadcast.NewBroadcaster(newBroadcastClient(), ethereumtest.FakeRepo{}, 3, 0, log.Default())
+
+		if _, err := b.GetBroadcast(ctx, hash); !errors.Is(err, ethereum.ErrBroadcastNotFound) {
+			t.Errorf("GetBroadcast: expected %v, got %v", ethereum.ErrBroadcastNotFound, err)
+		}
+	})
+}
+
+func TestBroadcaster_Track(t *testing.T) {
+	ctx := context.Background()
+
+	fc := newBroadcastClient()
+	b := broadcast.NewBroadcaster(fc, ethereumtest.FakeRepo{}, 3, 0, log.Default())
+	tracking := broadcast.NewTrackingPoller(b)
+
+	if _, err := b.Broadcast(ctx, rawTx); err != nil {
+		t.Fatalf("Broadcast: unexpected error: %v", err)
+	}
+
+	steps := []struct {
+		name          string
+		update        func()
+		status        parser.BroadcastStatus
+		confirmations uint64
+	}{
+		{
+			name:   "still in the mempool",
+			update: func() {},
+			status: parser.BroadcastStatusPending,
+		},
+		{
+			name: "included",
+			update: func() {
+				fc.GetReceiptResp[hash] = &client.ReceiptResponse{TransactionHash: hash, BlockNumber: 100, Status: "0x1"}
+				fc.BlockNumberResp = 100
+			},
+			status:        parser.BroadcastStatusIncluded,
+			confirmations: 1,
+		},
+		{
+			name: "reorged out and dropped",
+			update: func() {
+				delete(fc.GetReceiptResp, hash)
+				delete(fc.GetTxByHashResp, hash)
+			},
+			status: parser.BroadcastStatusDropped,
+		},
+		{
+			name: "mined again and confirmed",
+			update: func() {
+				fc.GetReceiptResp[hash] = &client.ReceiptResponse{TransactionHash: hash, BlockNumber: 101, Status: "0x1"}
+				fc.BlockNumberResp = 103
+			},
+			status:        parser.BroadcastStatusConfirmed,
+			confirmations: 3,
+		},
+	}
+
+	for _, step := range steps {
+		step.update()
+		if err := tracking.Poll(ctx); err != nil {
+			t.Fatalf("%s: Track: unexpected error: %v", step.name, err)
+		}
+
+		got, _ := b.GetBroadcast(ctx, hash)
+		if got.Status != step.status || got.Confirmations != step.confirmations {
+			t.Errorf("%s: want %s with %d confirmations, got %s with %d", step.name, step.status, step.confirmations, got.Status, got.Confirmations)
+		}
+	}
+
+	t.Run("replaced", func(t *testing.T) {
+		fc := newBroadcastClient()
+		b := broadcast.NewBroadcaster(fc, ethereumtest.FakeRepo{}, 3, 0, log.Default())
+		if _, err := b.Broadcast(ctx, rawTx); err != nil {
+			t.Fatalf("Broadcast: unexpected error: %v", err)
+		}
+
+		// another transaction used nonce 7
+		fc.GetTxCountResp[client.LatestBlock] = 8
+		if err := b.Track(ctx); err != nil {
+			t.Fatalf("Track: unexpected error: %v", err)
+		}
+
+		got, _ := b.GetBroadcast(ctx, hash)
+		if got.Status != parser.BroadcastStatusReplaced {
+			t.Errorf("Track: want %s, got %s", parser.BroadcastStatusReplaced, got.Status)
+		}
+	})
+
+	t.Run("node error", func(t *testing.T) {
+		fc := newBroadcastClient()
+		b := broadcast.NewBroadcaster(fc, ethereumtest.FakeRepo{}, 3, 0, log.Default())
+		if _, err := b.Broadcast(ctx, rawTx); err != nil {
+			t.Fatalf("Broadcast: unexpected error: %v", err)
+		}
+
+		fc.GetTxCountErr = test.DummyErr
+		if err := b.Track(ctx); !errors.Is(err, test.DummyErr) {
+			t.Errorf("Track: expected %v, got %v", test.DummyErr, err)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package broadcast
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/pollers"
+)
+
+type trackingPoller struct {
+	broadcaster ethereum.Broadcaster
+}
+
+// NewTrackingPoller adapts the broadcast lifecycle tracking to a poller so it can be scheduled with a runner.
+func NewTrackingPoller(broadcaster ethereum.Broadcaster) pollers.Poller {
+	return &trackingPoller{
+		broadcaster: broadcaster,
+	}
+}
+
+func (p *trackingPoller) Poll(ctx context.Context) error {
+	return p.broadcaster.Track(ctx)
+}
@@ -0,0 +1,26 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/svcerrors"
+)
+
+var (
+	ErrInvalidRawTransaction = fmt.Errorf("%w: error invalid raw transaction", svcerrors.ErrBadRequest)
+	ErrBroadcastNotFound     = fmt.Errorf("%w: error broadcast transaction not found", svcerrors.ErrNotFound)
+	ErrTransactionRejected   = fmt.Errorf("%w: error transaction rejected by the node", svcerrors.ErrBadRequest)
+)
+
+type Broadcaster interface {
+	// submit a signed raw transaction, subscribe its sender and start tracking it
+	Broadcast(ctx context.Context, rawTx string) (parser.BroadcastTransaction, error)
+
+	// lifecycle of a broadcast transaction
+	GetBroadcast(ctx context.Context, hash string) (parser.BroadcastTransaction, error)
+
+	// refresh the status of every tracked transaction that isn't final
+	Track(ctx context.Context) error
+}
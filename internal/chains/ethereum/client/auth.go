@@ -0,0 +1,197 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwtRefresh is the age a token is renewed at, nodes reject tokens issued more than a minute ago.
+const jwtRefresh = 30 * time.Second
+
+// Auth are the credentials sent to an endpoint with every call.
+type Auth struct {
+	Headers  map[string]string
+	Username string
+	Password string
+	// JWTSecret signs HS256 bearer tokens, as the engine API of execution clients expects.
+	JWTSecret []byte
+}
+
+// ParseAuth parses the credentials of each endpoint host from entries separated by
+// semicolons, each being the host followed by space separated settings:
+//
+//	header.<Name>=<secret>
+//	basic=<username>:<secret>
+//	jwt=<secret>
+//
+// Secrets are read from an environment variable with env:NAME, from a file with
+// file:/path, or taken as they are otherwise. JWT secrets are hex encoded.
+func ParseAuth(spec string) (map[string]Auth, error) {
+	auths := make(map[string]Auth)
+	for _, entry := range strings.Split(spec, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		host, settings := fields[0], fields[1:]
+		if len(settings) == 0 {
+			return nil, fmt.Errorf("invalid auth %q: expected settings after the host", entry)
+		}
+
+		auth := auths[host]
+		for _, setting := range settings {
+			if err := auth.set(setting); err != nil {
+				return nil, fmt.Errorf("invalid auth for %s: %w", host, err)
+			}
+		}
+		auths[host] = auth
+	}
+	return auths, nil
+}
+
+func (a *Auth) set(setting string) error {
+	key, value, ok := strings.Cut(setting, "=")
+	if !ok {
+		return fmt.Errorf("invalid setting %q: expected key=value", setting)
+	}
+
+	switch {
+	case strings.HasPrefix(key, "header."):
+		secret, err := readSecret(value)
+		if err != nil {
+			return err
+		}
+		if a.Headers == nil {
+			a.Headers = make(map[string]string)
+		}
+		a.Headers[strings.TrimPrefix(key, "header.")] = secret
+
+	case key == "basic":
+		username, password, ok := strings.Cut(value, ":")
+		if !ok {
+			return fmt.Errorf("invalid basic auth %q: expected username:secret", value)
+		}
+		secret, err := readSecret(password)
+		if err != nil {
+			return err
+		}
+		a.Username, a.Password = username, secret
+
+	case key == "jwt":
+		secret, err := readSecret(value)
+		if err != nil {
+			return err
+		}
+		a.JWTSecret, err = hex.DecodeString(strings.TrimPrefix(secret, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid jwt secret: expected hex: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+// readSecret resolves env:NAME and file:/path references, files being trimmed of their
+// trailing new line.
+func readSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, "env:"):
+		name := strings.TrimPrefix(ref, "env:")
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret variable %s is not set", name)
+		}
+		return value, nil
+
+	case strings.HasPrefix(ref, "file:"):
+		value, err := os.ReadFile(strings.TrimPrefix(ref, "file:"))
+		if err != nil {
+			return "", fmt.Errorf("reading secret file: %w", err)
+		}
+		return strings.TrimSpace(string(value)), nil
+	}
+	return ref, nil
+}
+
+// authenticator sets the credentials of an endpoint on its requests, keeping the last
+// JWT until it is due for renewal.
+type authenticator struct {
+	auth Auth
+	now  func() time.Time
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func newAuthenticator(auth Auth) *authenticator {
+	return &authenticator{
+		auth: auth,
+		now:  time.Now,
+	}
+}
+
+func (a *authenticator) apply(req *http.Request) error {
+	for name, value := range a.auth.Headers {
+		req.Header.Set(name, value)
+	}
+	if a.auth.Username != "" {
+		req.SetBasicAuth(a.auth.Username, a.auth.Password)
+	}
+	if len(a.auth.JWTSecret) > 0 {
+		token, err := a.jwt()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+func (a *authenticator) jwt() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if a.token != "" && now.Sub(a.issuedAt) < jwtRefresh {
+		return a.token, nil
+	}
+
+	token, err := signJWT(a.auth.JWTSecret, now)
+	if err != nil {
+		return "", err
+	}
+	a.token, a.issuedAt = token, now
+	return token, nil
+}
+
+// signJWT issues an HS256 token holding the issued at claim.
+func signJWT(secret []byte, issuedAt time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{"iat": issuedAt.Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + encoding.EncodeToString(mac.Sum(nil)), nil
+}
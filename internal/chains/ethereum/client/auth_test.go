@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAuth(t *testing.T) {
+	t.Setenv("TEST_RPC_API_KEY", "key-from-env")
+	secretFile := filepath.Join(t.TempDir(), "jwt.hex")
+	if err := os.WriteFile(secretFile, []byte("0x0102\n"), 0o600); err != nil {
+		t.Fatalf("writing secret: %v", err)
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		auths, err := ParseAuth("rpc.example.com header.X-Api-Key=env:TEST_RPC_API_KEY basic=alice:s3cret ; erigon:8545 jwt=file:" + secretFile + ";")
+		if err != nil {
+			t.Fatalf("ParseAuth: unexpected error: %v", err)
+		}
+
+		want := map[string]Auth{
+			"rpc.example.com": {Headers: map[string]string{"X-Api-Key": "key-from-env"}, Username: "alice", Password: "s3cret"},
+			"erigon:8545":     {JWTSecret: []byte{0x01, 0x02}},
+		}
+		if !reflect.DeepEqual(auths, want) {
+			t.Errorf("ParseAuth = %+v; want %+v", auths, want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, spec := range []string{
+			"rpc.example.com",
+			"rpc.example.com basic",
+			"rpc.example.com basic=alice",
+			"rpc.example.com token=abc",
+			"rpc.example.com jwt=not-hex",
+			"rpc.example.com header.X-Api-Key=env:TEST_RPC_MISSING",
+			"rpc.example.com jwt=file:/does/not/exist",
+		} {
+			if _, err := ParseAuth(spec); err == nil {
+				t.Errorf("ParseAuth(%q): expected error, got none", spec)
+			}
+		}
+	})
+}
+
+func TestAuthenticator_JWT(t *testing.T) {
+	secret := []byte("engine-secret")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a := newAuthenticator(Auth{JWTSecret: secret})
+	a.now = func() time.Time { return now }
+
+	first, err := a.jwt()
+	if err != nil {
+		t.Fatalf("jwt: unexpected error: %v", err)
+	}
+	if iat := verifyJWT(t, first, secret); iat != now.Unix() {
+		t.Errorf("jwt: want iat %d, got %d", now.Unix(), iat)
+	}
+
+	now = now.Add(10 * time.Second)
+	if token, _ := a.jwt(); token != first {
+		t.Errorf("jwt: expected the token to be reused while fresh")
+	}
+
+	now = now.Add(jwtRefresh)
+	refreshed, _ := a.jwt()
+	if refreshed == first || verifyJWT(t, refreshed, secret) != now.Unix() {
+		t.Errorf("jwt: expected a token issued now once stale")
+	}
+}
+
+func TestClient_Auth(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"0x1"}`)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	cli := NewClient([]string{ts.URL}, nil, WithAuth(map[string]Auth{
+		u.Host: {Headers: map[string]string{"X-Api-Key": "key"}, JWTSecret: []byte("secret")},
+	}))
+	if _, err := cli.BlockNumber(context.Background()); err != nil {
+		t.Fatalf("BlockNumber: unexpected error: %v", err)
+	}
+
+	if got.Get("X-Api-Key") != "key" {
+		t.Errorf("expected the api key header, got %v", got)
+	}
+	token, ok := strings.CutPrefix(got.Get("Authorization"), "Bearer ")
+	if !ok {
+		t.Fatalf("expected a bearer token, got %v", got)
+	}
+	verifyJWT(t, token, []byte("secret"))
+}
+
+// verifyJWT checks the token signature and returns its issued at claim.
+func verifyJWT(t *testing.T, token string, secret []byte) int64 {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("invalid token %q", token)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if want := base64.RawURLEncoding.EncodeToString(mac.Sum(nil)); parts[2] != want {
+		t.Fatalf("invalid signature for token %q", token)
+	}
+
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		IssuedAt int64 `json:"iat"`
+	}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		t.Fatalf("invalid claims %s: %v", claimsJSON, err)
+	}
+	return claims.IssuedAt
+}
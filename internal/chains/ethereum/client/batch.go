@@ -0,0 +1,288 @@
+package client
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+// maxBatchSize is the number of calls sent per request, most providers reject larger batches.
+const maxBatchSize = 100
+
+var ErrMissingBatchResponse = errors.New("error missing batch response")
+
+// BatchElem is one call of a batch request. Result must be a pointer the call result is
+// unmarshalled into, Error holds the failure of this call alone once the batch is sent.
+type BatchElem struct {
+	Method string
+	Params []interface{}
+	Result interface{}
+	Error  error
+}
+
+// BatchError holds the failed calls of a batch, keyed by their index in the batch.
+type BatchError map[int]error
+
+func (e BatchError) Error() string {
+	return errors.Join(e.Unwrap()...).Error()
+}
+
+func (e BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, i := range slices.Sorted(maps.Keys(e)) {
+		errs = append(errs, e[i])
+	}
+	return errs
+}
+
+func (e BatchError) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// BatchCall sends the calls in as few requests as possible. The returned error is only set
+// when a whole request failed, per call failures are reported in each element Error.
+func (c *client) BatchCall(ctx context.Context, batch []BatchElem) error {
+	for start := 0; start < len(batch); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(batch))
+		if err := c.retryBatch(ctx, batch[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryBatch sends the batch again while the request or some of its calls fail with a
+// retryable error, only resending the calls that didn't succeed.
+func (c *client) retryBatch(ctx context.Context, batch []BatchElem) error {
+	pending := make([]int, len(batch))
+	for i := range batch {
+		pending[i] = i
+	}
+
+	var callsFailed bool
+	err := c.retry(ctx, func() error {
+		callsFailed = false
+
+		sub := make([]BatchElem, len(pending))
+		for i, idx := range pending {
+			sub[i] = batch[idx]
+		}
+		if err := c.sendBatch(ctx, sub); err != nil {
+			return err
+		}
+
+		var retryable []int
+		var firstErr error
+		for i, idx := range pending {
+			batch[idx].Error = sub[i].Error
+			if IsRetryable(sub[i].Error) {
+				retryable = append(retryable, idx)
+				firstErr = cmp.Or(firstErr, sub[i].Error)
+			}
+		}
+
+		pending = retryable
+		callsFailed = len(pending) > 0
+		return firstErr
+	})
+	if callsFailed {
+		// the failures are left in the elements
+		return nil
+	}
+	return err
+}
+
+func (c *client) sendBatch(ctx context.Context, batch []BatchElem) error {
+	payload := make([]rpcRequest, len(batch))
+	indexByID := make(map[uint64]int, len(batch))
+	for i := range batch {
+		payload[i] = c.newRequest(batch[i].Method, batch[i].Params)
+		indexByID[payload[i].ID] = i
+		batch[i].Error = nil
+	}
+
+	responseBody, err := c.post(ctx, payload)
+	if err != nil {
+		return err
+	}
+	defer responseBody.Close()
+
+	resps, err := decodeBatchResponse(responseBody)
+	if err != nil {
+		return err
+	}
+
+	answered := make([]bool, len(batch))
+	for _, resp := range resps {
+		i, ok := indexByID[resp.ID]
+		if !ok || answered[i] {
+			continue
+		}
+		answered[i] = true
+
+		switch elem := &batch[i]; {
+		case resp.Error != nil:
+			elem.Error = resp.Error
+		case elem.Result != nil:
+			elem.Error = json.Unmarshal(resp.Result, elem.Result)
+		}
+	}
+
+	for i := range batch {
+		if !answered[i] {
+			batch[i].Error = fmt.Errorf("%w: %s", ErrMissingBatchResponse, batch[i].Method)
+		}
+	}
+	return nil
+}
+
+// GetBlocks returns the transactions of each block, in the order of blockIDs.
+// Failed blocks are left empty and reported in a BatchError.
+func (c *client) GetBlocks(ctx context.Context, blockIDs []string) ([][]TransactionResponse, error) {
+	blocks := make([]*blockResponse, len(blockIDs))
+	batch := make([]BatchElem, len(blockIDs))
+	for i, blockID := range blockIDs {
+		batch[i] = BatchElem{
+			Method: EthGetBlockByNumber,
+			Params: []interface{}{blockID, ReturnFullTransactionObjects},
+			Result: &blocks[i],
+		}
+	}
+
+	if err := c.BatchCall(ctx, batch); err != nil {
+		c.logger.Printf("error making get blocks batch request: %v\n", err)
+		return nil, err
+	}
+
+	txs := make([][]TransactionResponse, len(blockIDs))
+	errs := make(BatchError)
+	for i, elem := range batch {
+		switch {
+		case elem.Error != nil:
+			errs[i] = fmt.Errorf("block %s: %w", blockIDs[i], elem.Error)
+		case blocks[i] == nil:
+			errs[i] = fmt.Errorf("block %s: %w", blockIDs[i], ErrBlockNotFound)
+		default:
+			txs[i] = blocks[i].Transactions
+			for j := range txs[i] {
+				txs[i][j].BlockTimestamp = blocks[i].Timestamp
+			}
+		}
+	}
+	return txs, errs.orNil()
+}
+
+// GetTransactionReceipts returns the receipt of each hash, in the order of hashes.
+// Unknown transactions have a nil receipt, failed calls too and are reported in a BatchError.
+func (c *client) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*ReceiptResponse, error) {
+	receipts := make([]*ReceiptResponse, len(hashes))
+	batch := make([]BatchElem, len(hashes))
+	for i, hash := range hashes {
+		batch[i] = BatchElem{
+			Method: EthGetReceipt,
+			Params: []interface{}{hash},
+			Result: &receipts[i],
+		}
+	}
+
+	if err := c.BatchCall(ctx, batch); err != nil {
+		c.logger.Printf("error making get receipts batch request: %v\n", err)
+		return nil, err
+	}
+
+	errs := make(BatchError)
+	for i, elem := range batch {
+		if elem.Error != nil {
+			receipts[i] = nil
+			errs[i] = fmt.Errorf("receipt %s: %w", hashes[i], elem.Error)
+		}
+	}
+	return receipts, errs.orNil()
+}
+
+// GetBalances returns the balance of each address at blockNumber, in the order of addresses.
+// Failed balances are left as zero and reported in a BatchError.
+func (c *client) GetBalances(ctx context.Context, addresses []evm.Address, blockNumber evm.BlockNumber) ([]evm.Quantity, error) {
+	balances := make([]evm.Quantity, len(addresses))
+	batch := make([]BatchElem, len(addresses))
+	for i, address := range addresses {
+		batch[i] = BatchElem{
+			Method: EthGetBalance,
+			Params: []interface{}{address, blockNumber.Hex()},
+			Result: &balances[i],
+		}
+	}
+
+	if err := c.BatchCall(ctx, batch); err != nil {
+		c.logger.Printf("error making get balances batch request: %v\n", err)
+		return nil, err
+	}
+
+	errs := make(BatchError)
+	for i, elem := range batch {
+		if elem.Error != nil {
+			balances[i] = evm.Quantity{}
+			errs[i] = fmt.Errorf("balance %s: %w", addresses[i], elem.Error)
+		}
+	}
+	return balances, errs.orNil()
+}
+
+// decodeBatchResponse reads the answers to a batch one at a time. Nodes answer with a
+// single error object when they reject the whole batch, which is returned as the error.
+func decodeBatchResponse(r io.Reader) ([]rpcResponse, error) {
+	body := bufio.NewReader(r)
+	first, err := peekToken(body)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(body)
+	if first == '{' {
+		var resp rpcResponse
+		if err := dec.Decode(&resp); err != nil {
+			return nil, err
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return nil, fmt.Errorf("%w: expected an array of responses", ErrUnexpectedResponse)
+	}
+
+	var resps []rpcResponse
+	err = decodeArray(dec, func() error {
+		var resp rpcResponse
+		if err := dec.Decode(&resp); err != nil {
+			return err
+		}
+		resps = append(resps, resp)
+		return nil
+	})
+	return resps, err
+}
+
+// peekToken returns the first byte that isn't white space, leaving it unread.
+func peekToken(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, r.UnreadByte()
+	}
+}
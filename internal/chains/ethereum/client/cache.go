@@ -0,0 +1,378 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+// cacheKeyRegex keeps cache keys safe to use as file names.
+var cacheKeyRegex = regexp.MustCompile(`^(block|receipt|trace)-0x[0-9a-f]+$`)
+
+// cachingClient serves finalized blocks, receipts and traces from a cache, as they
+// can't change anymore once buried under the finality depth. Other calls go to the node.
+type cachingClient struct {
+	Client
+	finality uint64
+	cache    *lruCache
+	logger   *log.Logger
+
+	// head is the highest block seen in the responses, blocks are final relative to it.
+	head atomic.Uint64
+}
+
+// NewCachingClient decorates the client with a cache holding up to size entries in memory,
+// and every entry on disk when dir isn't empty.
+func NewCachingClient(next Client, finality uint64, size int, dir string, logger *log.Logger) (Client, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cachingClient{
+		Client:   next,
+		finality: finality,
+		cache:    newLRUCache(size, dir, logger),
+		logger:   logger,
+	}, nil
+}
+
+func (c *cachingClient) BlockNumber(ctx context.Context) (evm.BlockNumber, error) {
+	head, err := c.Client.BlockNumber(ctx)
+	if err == nil {
+		c.observe(head)
+	}
+	return head, err
+}
+
+func (c *cachingClient) GetBlock(ctx context.Context, blockID string) ([]TransactionResponse, error) {
+	key, blockNumber, cacheable := blockKey(blockID)
+
+	var txs []TransactionResponse
+	if cacheable && c.cache.get(key, &txs) {
+		return txs, nil
+	}
+
+	txs, err := c.Client.GetBlock(ctx, blockID)
+	if err != nil {
+		return nil, err
+	}
+	c.observeBlock(txs)
+
+	if cacheable && c.final(blockNumber) {
+		c.cache.put(key, txs)
+	}
+	return txs, nil
+}
+
+// FilterBlock filters final blocks out of the cache, fetching them whole on a miss so
+// they are cached for the next callers. Other blocks are filtered as they are decoded.
+func (c *cachingClient) FilterBlock(ctx context.Context, blockID string, keep func(*TransactionResponse) bool) ([]TransactionResponse, error) {
+	if _, blockNumber, cacheable := blockKey(blockID); !cacheable || !c.final(blockNumber) {
+		txs, err := c.Client.FilterBlock(ctx, blockID, keep)
+		if err != nil {
+			return nil, err
+		}
+		c.observeBlock(txs)
+		return txs, nil
+	}
+
+	txs, err := c.GetBlock(ctx, blockID)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []TransactionResponse
+	for i := range txs {
+		if keep == nil || keep(&txs[i]) {
+			kept = append(kept, txs[i])
+		}
+	}
+	return kept, nil
+}
+
+func (c *cachingClient) GetBlocks(ctx context.Context, blockIDs []string) ([][]TransactionResponse, error) {
+	blocks := make([][]TransactionResponse, len(blockIDs))
+
+	var misses []int
+	for i, blockID := range blockIDs {
+		key, _, cacheable := blockKey(blockID)
+		if !cacheable || !c.cache.get(key, &blocks[i]) {
+			misses = append(misses, i)
+		}
+	}
+	if len(misses) == 0 {
+		return blocks, nil
+	}
+
+	missingIDs := make([]string, len(misses))
+	for i, idx := range misses {
+		missingIDs[i] = blockIDs[idx]
+	}
+
+	fetched, err := c.Client.GetBlocks(ctx, missingIDs)
+	var failed BatchError
+	if err != nil && !errors.As(err, &failed) {
+		return nil, err
+	}
+
+	errs := make(BatchError)
+	for i, idx := range misses {
+		if failedErr, ok := failed[i]; ok {
+			errs[idx] = failedErr
+			continue
+		}
+
+		blocks[idx] = fetched[i]
+		c.observeBlock(fetched[i])
+		if key, blockNumber, cacheable := blockKey(blockIDs[idx]); cacheable && c.final(blockNumber) {
+			c.cache.put(key, fetched[i])
+		}
+	}
+	return blocks, errs.orNil()
+}
+
+func (c *cachingClient) GetTransactionReceipt(ctx context.Context, hash string) (*ReceiptResponse, error) {
+	key, cacheable := hashKey("receipt", hash)
+
+	var receipt *ReceiptResponse
+	if cacheable && c.cache.get(key, &receipt) {
+		return receipt, nil
+	}
+
+	receipt, err := c.Client.GetTransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable && c.final(receipt.BlockNumber) {
+		c.cache.put(key, receipt)
+	}
+	return receipt, nil
+}
+
+func (c *cachingClient) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*ReceiptResponse, error) {
+	receipts := make([]*ReceiptResponse, len(hashes))
+
+	var misses []int
+	for i, hash := range hashes {
+		key, cacheable := hashKey("receipt", hash)
+		if !cacheable || !c.cache.get(key, &receipts[i]) {
+			misses = append(misses, i)
+		}
+	}
+	if len(misses) == 0 {
+		return receipts, nil
+	}
+
+	missingHashes := make([]string, len(misses))
+	for i, idx := range misses {
+		missingHashes[i] = hashes[idx]
+	}
+
+	fetched, err := c.Client.GetTransactionReceipts(ctx, missingHashes)
+	var failed BatchError
+	if err != nil && !errors.As(err, &failed) {
+		return nil, err
+	}
+
+	errs := make(BatchError)
+	for i, idx := range misses {
+		if failedErr, ok := failed[i]; ok {
+			errs[idx] = failedErr
+			continue
+		}
+
+		receipts[idx] = fetched[i]
+		if fetched[i] == nil {
+			continue
+		}
+		if key, cacheable := hashKey("receipt", hashes[idx]); cacheable && c.final(fetched[i].BlockNumber) {
+			c.cache.put(key, fetched[i])
+		}
+	}
+	return receipts, errs.orNil()
+}
+
+// TraceTransaction caches the trace once the receipt of the transaction proves it final.
+func (c *cachingClient) TraceTransaction(ctx context.Context, hash string) (json.RawMessage, error) {
+	key, cacheable := hashKey("trace", hash)
+
+	var trace json.RawMessage
+	if cacheable && c.cache.get(key, &trace) {
+		return trace, nil
+	}
+
+	trace, err := c.Client.TraceTransaction(ctx, hash)
+	if err != nil || !cacheable {
+		return trace, err
+	}
+
+	receipt, err := c.GetTransactionReceipt(ctx, hash)
+	if err != nil {
+		c.logger.Printf("error retrieving receipt of traced transaction: %s: %v\n", hash, err)
+		return trace, nil
+	}
+	if c.final(receipt.BlockNumber) {
+		c.cache.put(key, trace)
+	}
+	return trace, nil
+}
+
+func (c *cachingClient) observe(blockNumber evm.BlockNumber) {
+	for {
+		head := c.head.Load()
+		if uint64(blockNumber) <= head || c.head.CompareAndSwap(head, uint64(blockNumber)) {
+			return
+		}
+	}
+}
+
+func (c *cachingClient) observeBlock(txs []TransactionResponse) {
+	if len(txs) > 0 {
+		c.observe(txs[0].BlockNumber)
+	}
+}
+
+// final reports whether the block is buried under the finality depth of the highest block seen.
+func (c *cachingClient) final(blockNumber evm.BlockNumber) bool {
+	head := c.head.Load()
+	return head >= c.finality && uint64(blockNumber) <= head-c.finality
+}
+
+// blockKey only accepts block numbers, tags like latest move with the chain.
+func blockKey(blockID string) (string, evm.BlockNumber, bool) {
+	blockNumber, err := evm.ParseBlockNumber(blockID)
+	if err != nil {
+		return "", 0, false
+	}
+	return "block-" + blockNumber.Hex(), blockNumber, true
+}
+
+func hashKey(kind, hash string) (string, bool) {
+	key := kind + "-" + strings.ToLower(hash)
+	return key, cacheKeyRegex.MatchString(key)
+}
+
+// lruCache keeps the most recently used entries in memory, encoded so callers can't alter
+// them, and every entry on disk when it has a directory.
+type lruCache struct {
+	size   int
+	dir    string
+	logger *log.Logger
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+func newLRUCache(size int, dir string, logger *log.Logger) *lruCache {
+	return &lruCache{
+		size:    size,
+		dir:     dir,
+		logger:  logger,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get decodes the entry into v, falling back to the disk on a memory miss.
+func (l *lruCache) get(key string, v any) bool {
+	value, ok := l.load(key)
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(value, v); err != nil {
+		l.logger.Printf("error decoding cache entry %s: %v\n", key, err)
+		return false
+	}
+	return true
+}
+
+func (l *lruCache) put(key string, v any) {
+	value, err := json.Marshal(v)
+	if err != nil {
+		l.logger.Printf("error encoding cache entry %s: %v\n", key, err)
+		return
+	}
+
+	l.remember(key, value)
+	if l.dir == "" {
+		return
+	}
+
+	// written aside and renamed so readers never see a partial file
+	path := l.path(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, value, 0o644); err != nil {
+		l.logger.Printf("error writing cache entry %s: %v\n", key, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		l.logger.Printf("error writing cache entry %s: %v\n", key, err)
+	}
+}
+
+func (l *lruCache) load(key string) ([]byte, bool) {
+	l.mu.Lock()
+	if elem, ok := l.entries[key]; ok {
+		l.order.MoveToFront(elem)
+		l.mu.Unlock()
+		return elem.Value.(*lruEntry).value, true
+	}
+	l.mu.Unlock()
+
+	if l.dir == "" {
+		return nil, false
+	}
+	value, err := os.ReadFile(l.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	l.remember(key, value)
+	return value, true
+}
+
+func (l *lruCache) remember(key string, value []byte) {
+	if l.size <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[key]; ok {
+		elem.Value.(*lruEntry).value = value
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value})
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (l *lruCache) path(key string) string {
+	return filepath.Join(l.dir, key+".json")
+}
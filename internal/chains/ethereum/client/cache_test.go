@@ -0,0 +1,184 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/test/ethereumtest"
+)
+
+// countingClient counts the calls reaching the node.
+type countingClient struct {
+	*ethereumtest.FakeClient
+	blocks   int
+	receipts int
+	traces   int
+}
+
+func (c *countingClient) GetBlock(ctx context.Context, blockID string) ([]client.TransactionResponse, error) {
+	c.blocks++
+	return c.FakeClient.GetBlock(ctx, blockID)
+}
+
+func (c *countingClient) GetTransactionReceipt(ctx context.Context, hash string) (*client.ReceiptResponse, error) {
+	c.receipts++
+	return c.FakeClient.GetTransactionReceipt(ctx, hash)
+}
+
+func (c *countingClient) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*client.ReceiptResponse, error) {
+	c.receipts += len(hashes)
+
+	receipts := make([]*client.ReceiptResponse, len(hashes))
+	errs := make(client.BatchError)
+	for i, hash := range hashes {
+		receipt, err := c.FakeClient.GetTransactionReceipt(ctx, hash)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		receipts[i] = receipt
+	}
+	if len(errs) > 0 {
+		return receipts, errs
+	}
+	return receipts, nil
+}
+
+func (c *countingClient) TraceTransaction(ctx context.Context, hash string) (json.RawMessage, error) {
+	c.traces++
+	return c.FakeClient.TraceTransaction(ctx, hash)
+}
+
+func newCachingClient(t *testing.T, size int, dir string) (client.Client, *countingClient) {
+	node := &countingClient{FakeClient: &ethereumtest.FakeClient{
+		BlockNumberResp: 100,
+		GetBlockResp:    []client.TransactionResponse{{Hash: "0x01", BlockNumber: 100}},
+		GetReceiptResp: map[string]*client.ReceiptResponse{
+			"0xaa": {TransactionHash: "0xaa", BlockNumber: 80, Status: "0x1"},
+			"0xbb": {TransactionHash: "0xbb", BlockNumber: 99, Status: "0x1"},
+		},
+		GetReceiptNotFound: true,
+		TraceTxResp: map[string]json.RawMessage{
+			"0xaa": json.RawMessage(`{"type":"CALL"}`),
+		},
+	}}
+
+	cached, err := client.NewCachingClient(node, 10, size, dir, log.Default())
+	if err != nil {
+		t.Fatalf("NewCachingClient: unexpected error: %v", err)
+	}
+	if _, err := cached.BlockNumber(context.Background()); err != nil {
+		t.Fatalf("BlockNumber: unexpected error: %v", err)
+	}
+	return cached, node
+}
+
+func TestCachingClient_GetBlock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("final blocks are cached", func(t *testing.T) {
+		cached, node := newCachingClient(t, 10, "")
+
+		for range 2 {
+			if _, err := cached.GetBlock(ctx, "0x5a"); err != nil {
+				t.Fatalf("GetBlock: unexpected error: %v", err)
+			}
+		}
+		if node.blocks != 1 {
+			t.Errorf("expected the final block to be fetched once, got %d calls", node.blocks)
+		}
+	})
+
+	t.Run("recent blocks and tags are not cached", func(t *testing.T) {
+		cached, node := newCachingClient(t, 10, "")
+
+		for _, blockID := range []string{"0x60", "0x60", client.LatestBlock, client.LatestBlock} {
+			if _, err := cached.GetBlock(ctx, blockID); err != nil {
+				t.Fatalf("GetBlock: unexpected error: %v", err)
+			}
+		}
+		if node.blocks != 4 {
+			t.Errorf("expected every call to reach the node, got %d calls", node.blocks)
+		}
+	})
+
+	t.Run("least recently used entries are evicted", func(t *testing.T) {
+		cached, node := newCachingClient(t, 1, "")
+
+		for _, blockID := range []string{"0x1", "0x2", "0x1"} {
+			if _, err := cached.GetBlock(ctx, blockID); err != nil {
+				t.Fatalf("GetBlock: unexpected error: %v", err)
+			}
+		}
+		if node.blocks != 3 {
+			t.Errorf("expected the evicted block to be fetched again, got %d calls", node.blocks)
+		}
+	})
+
+	t.Run("entries survive on disk", func(t *testing.T) {
+		dir := t.TempDir()
+
+		first, _ := newCachingClient(t, 10, dir)
+		if _, err := first.GetBlock(ctx, "0x5a"); err != nil {
+			t.Fatalf("GetBlock: unexpected error: %v", err)
+		}
+
+		second, node := newCachingClient(t, 10, dir)
+		got, err := second.GetBlock(ctx, "0x5a")
+		if err != nil {
+			t.Fatalf("GetBlock: unexpected error: %v", err)
+		}
+		if node.blocks != 0 || len(got) != 1 || got[0].Hash != "0x01" {
+			t.Errorf("expected the block to be read from disk, got %+v after %d calls", got, node.blocks)
+		}
+	})
+}
+
+func TestCachingClient_GetTransactionReceipts(t *testing.T) {
+	ctx := context.Background()
+	cached, node := newCachingClient(t, 10, "")
+
+	if _, err := cached.GetTransactionReceipt(ctx, "0xaa"); err != nil {
+		t.Fatalf("GetTransactionReceipt: unexpected error: %v", err)
+	}
+
+	receipts, err := cached.GetTransactionReceipts(ctx, []string{"0xcc", "0xaa", "0xbb"})
+
+	var failed client.BatchError
+	if !errors.As(err, &failed) || len(failed) != 1 || !errors.Is(failed[0], client.ErrReceiptNotFound) {
+		t.Fatalf("GetTransactionReceipts: expected the unknown receipt to fail at its index, got %v", err)
+	}
+	if receipts[1].BlockNumber != 80 || receipts[2].BlockNumber != 99 {
+		t.Errorf("GetTransactionReceipts: unexpected receipts %+v", receipts)
+	}
+	// one call for the single receipt, then only the two misses
+	if node.receipts != 3 {
+		t.Errorf("expected 3 receipts fetched, got %d", node.receipts)
+	}
+}
+
+func TestCachingClient_TraceTransaction(t *testing.T) {
+	ctx := context.Background()
+	cached, node := newCachingClient(t, 10, "")
+
+	for range 2 {
+		trace, err := cached.TraceTransaction(ctx, "0xaa")
+		if err != nil {
+			t.Fatalf("TraceTransaction: unexpected error: %v", err)
+		}
+		if string(trace) != `{"type":"CALL"}` {
+			t.Errorf("TraceTransaction: unexpected trace %s", trace)
+		}
+	}
+	if node.traces != 1 {
+		t.Errorf("expected the final trace to be fetched once, got %d calls", node.traces)
+	}
+
+	if _, err := cached.TraceTransaction(ctx, "0xbb"); !errors.Is(err, client.ErrTransactionNotFound) {
+		t.Errorf("TraceTransaction: expected %v, got %v", client.ErrTransactionNotFound, err)
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+var (
+	ErrWrongChain  = errors.New("error rpc endpoint on a different chain")
+	ErrNodeSyncing = errors.New("error rpc endpoint still syncing")
+)
+
+// WithChainID only admits the endpoints on the given chain and network, which are the same
+// on most chains. A zero chain id skips the verification.
+func WithChainID(chainID, networkID uint64) Option {
+	return func(c *client) {
+		c.chainID = chainID
+		c.networkID = networkID
+	}
+}
+
+// checkNode verifies the endpoint is on the configured chain and done syncing, returning
+// ErrWrongChain or ErrNodeSyncing otherwise. Other errors mean the node couldn't be asked.
+func (c *client) checkNode(ctx context.Context, e *endpoint) error {
+	payload := []rpcRequest{
+		c.newRequest(EthChainID, nil),
+		c.newRequest(NetVersion, nil),
+		c.newRequest(EthSyncing, nil),
+	}
+
+	responseBody, err := c.postTo(ctx, e, payload)
+	if err != nil {
+		return err
+	}
+	defer responseBody.Close()
+
+	resps, err := decodeBatchResponse(responseBody)
+	if err != nil {
+		return err
+	}
+
+	results := make(map[uint64]json.RawMessage, len(resps))
+	for _, resp := range resps {
+		if resp.Error != nil {
+			return resp.Error
+		}
+		results[resp.ID] = resp.Result
+	}
+	for _, req := range payload {
+		if _, ok := results[req.ID]; !ok {
+			return fmt.Errorf("%w: %s", ErrMissingBatchResponse, req.Method)
+		}
+	}
+
+	var (
+		chainID   evm.Quantity
+		networkID string
+		syncing   = results[payload[2].ID]
+	)
+	if err := json.Unmarshal(results[payload[0].ID], &chainID); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(results[payload[1].ID], &networkID); err != nil {
+		return err
+	}
+
+	if c.chainID != 0 {
+		if !chainID.Equal(evm.QuantityFromUint64(c.chainID)) {
+			return fmt.Errorf("%w: chain id %s, expected %d", ErrWrongChain, chainID, c.chainID)
+		}
+		if networkID != strconv.FormatUint(c.networkID, 10) {
+			return fmt.Errorf("%w: network id %s, expected %d", ErrWrongChain, networkID, c.networkID)
+		}
+	}
+	// eth_syncing answers false, or the progress of the sync while it lasts
+	if string(syncing) != "false" {
+		return fmt.Errorf("%w: %s", ErrNodeSyncing, syncing)
+	}
+	return nil
+}
+
+// isFault reports whether err means the endpoint must not be used at all, as opposed to
+// failing to answer.
+func isFault(err error) bool {
+	return errors.Is(err, ErrWrongChain) || errors.Is(err, ErrNodeSyncing)
+}
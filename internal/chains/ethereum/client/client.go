@@ -3,98 +3,471 @@ package client
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
 )
 
 const (
 	JSONRPCVersion = "2.0"
 
 	EthGetBlockByNumber = "eth_getBlockByNumber"
+	EthCall             = "eth_call"
+	EthBlockNumber      = "eth_blockNumber"
+	EthGetBalance       = "eth_getBalance"
+	EthGetReceipt       = "eth_getTransactionReceipt"
+	EthGetTxCount       = "eth_getTransactionCount"
+	EthGetTxByHash      = "eth_getTransactionByHash"
+	EthSendRawTx        = "eth_sendRawTransaction"
+	DebugTraceTx        = "debug_traceTransaction"
+	EthChainID          = "eth_chainId"
+	NetVersion          = "net_version"
+	EthSyncing          = "eth_syncing"
 
 	ReturnFullTransactionObjects = true
 	LatestBlock                  = "latest"
+	PendingBlock                 = "pending"
 )
 
+// defaultTimeout bounds a request to an endpoint, a hung node failing over like an
+// unreachable one rather than blocking its caller.
+const defaultTimeout = 30 * time.Second
+
 type Client interface {
 	GetBlock(ctx context.Context, blockID string) ([]TransactionResponse, error)
+	FilterBlock(ctx context.Context, blockID string, keep func(*TransactionResponse) bool) ([]TransactionResponse, error)
+	Call(ctx context.Context, to evm.Address, data []byte, blockNumber evm.BlockNumber) ([]byte, error)
+	BlockNumber(ctx context.Context) (evm.BlockNumber, error)
+	GetBalance(ctx context.Context, address evm.Address, blockNumber evm.BlockNumber) (evm.Quantity, error)
+	GetTransactionReceipt(ctx context.Context, hash string) (*ReceiptResponse, error)
+	GetTransactionCount(ctx context.Context, address evm.Address, blockID string) (evm.Nonce, error)
+	GetTransactionByHash(ctx context.Context, hash string) (*TransactionResponse, error)
+	SendRawTransaction(ctx context.Context, rawTx string) (string, error)
+	TraceTransaction(ctx context.Context, hash string) (json.RawMessage, error)
+
+	// CheckHealth probes the node endpoints, see endpoints.go.
+	CheckHealth(ctx context.Context) error
+	// Usage reports the rate limit budget of the node endpoints, see limiter.go.
+	Usage() []Usage
+
+	BatchCall(ctx context.Context, batch []BatchElem) error
+	GetBlocks(ctx context.Context, blockIDs []string) ([][]TransactionResponse, error)
+	GetTransactionReceipts(ctx context.Context, hashes []string) ([]*ReceiptResponse, error)
+	GetBalances(ctx context.Context, addresses []evm.Address, blockNumber evm.BlockNumber) ([]evm.Quantity, error)
 }
 
+var (
+	ErrReceiptNotFound     = errors.New("error receipt not found")
+	ErrTransactionNotFound = errors.New("error transaction not found")
+	// ErrBlockNotFound is a block the node doesn't know yet, such as a lagging endpoint
+	// asked for the head of another one.
+	ErrBlockNotFound = errors.New("error block not found")
+	ErrTimeout       = errors.New("error rpc request timed out")
+)
+
 type client struct {
-	url        string
-	httpClient *http.Client
-	logger     *log.Logger
+	endpoints       []*endpoint
+	httpClient      *http.Client
+	retryPolicy     retryPolicy
+	rateLimit       float64
+	rateBurst       float64
+	methodCosts     MethodCosts
+	auths           map[string]Auth
+	maxResponseSize int64
+	timeout         time.Duration
+	chainID         uint64
+	networkID       uint64
+	logger          *log.Logger
+	nextID          atomic.Uint64
+}
+
+type Option func(c *client)
+
+// WithRateLimit caps the calls of each endpoint to rate cost units per second with bursts
+// of up to burst units, every caller of the client sharing the same budget.
+func WithRateLimit(rate, burst float64, costs MethodCosts) Option {
+	return func(c *client) {
+		c.rateLimit = rate
+		c.rateBurst = burst
+		if costs != nil {
+			c.methodCosts = costs
+		}
+	}
+}
+
+// WithTimeout bounds each request to an endpoint, reading the response included, 0 lifting
+// the limit. It applies to the default http client, one given by WithHTTPClient keeping its own.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.timeout = timeout
+	}
+}
+
+// WithHTTPClient sends the calls through httpClient instead of the default one.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAuth sets the credentials of the endpoints, keyed by their host.
+func WithAuth(auths map[string]Auth) Option {
+	return func(c *client) {
+		c.auths = auths
+	}
 }
 
-func NewClient(url string, logger *log.Logger) Client {
-	return &client{
-		url:        url,
-		httpClient: http.DefaultClient,
-		logger:     logger,
+// WithMaxResponseSize fails the calls whose response is larger than size bytes, 0 lifting the limit.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *client) {
+		c.maxResponseSize = size
 	}
 }
 
+// NewClient returns a client routing each call to the healthiest of the node urls,
+// failing over to the next one when a node is unreachable and retrying transient failures.
+// The urls are http endpoints, or Unix domain sockets given as ipc:// urls or file paths.
+func NewClient(urls []string, logger *log.Logger, opts ...Option) Client {
+	c := &client{
+		retryPolicy:     defaultRetryPolicy,
+		methodCosts:     DefaultMethodCosts,
+		maxResponseSize: defaultMaxResponseSize,
+		timeout:         defaultTimeout,
+		logger:          logger,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: c.timeout}
+	}
+
+	c.endpoints = make([]*endpoint, len(urls))
+	for i, url := range urls {
+		c.endpoints[i] = newEndpoint(url, newTokenBucket(c.rateLimit, c.rateBurst))
+		if auth, ok := c.auths[c.endpoints[i].name]; ok {
+			c.endpoints[i].auth = newAuthenticator(auth)
+		}
+	}
+	return c
+}
+
 func (c *client) GetBlock(ctx context.Context, blockID string) ([]TransactionResponse, error) {
+	return c.FilterBlock(ctx, blockID, nil)
+}
+
+// FilterBlock returns the transactions of the block keep accepts, the others being dropped
+// as the response is decoded so large blocks are never held in memory whole.
+func (c *client) FilterBlock(ctx context.Context, blockID string, keep func(*TransactionResponse) bool) ([]TransactionResponse, error) {
 	params := []interface{}{
 		blockID,
 		ReturnFullTransactionObjects,
 	}
 
-	resp, err := c.doRPCRequest(ctx, EthGetBlockByNumber, params...)
+	var txs []TransactionResponse
+	err := c.doRPCRequest(ctx, decodeBlock(&txs, keep), EthGetBlockByNumber, params...)
 	if err != nil {
 		c.logger.Printf("error making get block request: %v\n", err)
 		return nil, err
 	}
+	return txs, nil
+}
+
+// Call executes a read-only contract call against the state at blockNumber.
+func (c *client) Call(ctx context.Context, to evm.Address, data []byte, blockNumber evm.BlockNumber) ([]byte, error) {
+	params := []interface{}{
+		callRequest{
+			To:   to,
+			Data: "0x" + hex.EncodeToString(data),
+		},
+		blockNumber.Hex(),
+	}
+
+	var result string
+	err := c.doRPCRequest(ctx, decodeInto(&result), EthCall, params...)
+	if err != nil {
+		c.logger.Printf("error making call request: %v\n", err)
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimPrefix(result, "0x"))
+}
+
+func (c *client) BlockNumber(ctx context.Context) (evm.BlockNumber, error) {
+	var blockNumber evm.BlockNumber
+	err := c.doRPCRequest(ctx, decodeInto(&blockNumber), EthBlockNumber)
+	if err != nil {
+		c.logger.Printf("error making block number request: %v\n", err)
+		return 0, err
+	}
+	return blockNumber, nil
+}
+
+func (c *client) GetBalance(ctx context.Context, address evm.Address, blockNumber evm.BlockNumber) (evm.Quantity, error) {
+	var balance evm.Quantity
+	err := c.doRPCRequest(ctx, decodeInto(&balance), EthGetBalance, address, blockNumber.Hex())
+	if err != nil {
+		c.logger.Printf("error making get balance request: %v\n", err)
+		return evm.Quantity{}, err
+	}
+	return balance, nil
+}
+
+func (c *client) GetTransactionReceipt(ctx context.Context, hash string) (*ReceiptResponse, error) {
+	var receipt *ReceiptResponse
+	err := c.doRPCRequest(ctx, decodeInto(&receipt), EthGetReceipt, hash)
+	if err != nil {
+		c.logger.Printf("error making get receipt request: %v\n", err)
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, ErrReceiptNotFound
+	}
+	return receipt, nil
+}
 
-	var block blockResponse
-	err = json.Unmarshal([]byte(resp), &block)
+func (c *client) GetTransactionCount(ctx context.Context, address evm.Address, blockID string) (evm.Nonce, error) {
+	var nonce evm.Nonce
+	err := c.doRPCRequest(ctx, decodeInto(&nonce), EthGetTxCount, address, blockID)
 	if err != nil {
-		c.logger.Printf("error unmarshalling response block response: %v\n", err)
+		c.logger.Printf("error making get transaction count request: %v\n", err)
+		return 0, err
+	}
+	return nonce, nil
+}
+
+func (c *client) GetTransactionByHash(ctx context.Context, hash string) (*TransactionResponse, error) {
+	var tx *TransactionResponse
+	err := c.doRPCRequest(ctx, decodeInto(&tx), EthGetTxByHash, hash)
+	if err != nil {
+		c.logger.Printf("error making get transaction request: %v\n", err)
 		return nil, err
 	}
-	return block.Transactions, nil
+	if tx == nil {
+		return nil, ErrTransactionNotFound
+	}
+	return tx, nil
+}
+
+// SendRawTransaction submits a signed transaction and returns its hash.
+func (c *client) SendRawTransaction(ctx context.Context, rawTx string) (string, error) {
+	var hash string
+	err := c.sendOnce(ctx, decodeInto(&hash), EthSendRawTx, rawTx)
+	if err != nil {
+		c.logger.Printf("error making send raw transaction request: %v\n", err)
+		return "", err
+	}
+	return hash, nil
 }
 
-func (c *client) doRPCRequest(ctx context.Context, method string, params ...interface{}) (json.RawMessage, error) {
-	payload := rpcRequest{
+// TraceTransaction returns the call tree of a mined transaction, as built by the node call tracer.
+func (c *client) TraceTransaction(ctx context.Context, hash string) (json.RawMessage, error) {
+	var resp json.RawMessage
+	err := c.doRPCRequest(ctx, decodeInto(&resp), DebugTraceTx, hash, traceConfig{Tracer: "callTracer"})
+	if err != nil {
+		c.logger.Printf("error making trace transaction request: %v\n", err)
+		return nil, err
+	}
+
+	if len(resp) == 0 || string(resp) == "null" {
+		return nil, ErrTransactionNotFound
+	}
+	return resp, nil
+}
+
+// doRPCRequest calls method, decoding the result straight from the response body with result.
+func (c *client) doRPCRequest(ctx context.Context, result func(dec *json.Decoder) error, method string, params ...interface{}) error {
+	payload := c.newRequest(method, params)
+
+	return c.retry(ctx, func() error {
+		responseBody, err := c.post(ctx, payload)
+		if err != nil {
+			return err
+		}
+		defer responseBody.Close()
+
+		return decodeResponse(responseBody, result)
+	})
+}
+
+// sendOnce makes a request that mustn't reach a node twice, such as a raw transaction a node
+// may have accepted before the response got lost: resending it would be rejected as already
+// known. It isn't retried, and only fails over while no endpoint got the request.
+func (c *client) sendOnce(ctx context.Context, result func(dec *json.Decoder) error, method string, params ...interface{}) error {
+	payload := c.newRequest(method, params)
+	requestBody, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	cost := c.cost(payload)
+
+	var errs []error
+	for _, e := range c.route() {
+		responseBody, err := c.send(ctx, e, requestBody, cost)
+		if err == nil {
+			defer responseBody.Close()
+			return decodeResponse(responseBody, result)
+		}
+
+		c.logger.Printf("error calling rpc endpoint %s: %v\n", e.name, err)
+		errs = append(errs, fmt.Errorf("%s: %w", e.name, err))
+		if ctx.Err() != nil || !undelivered(err) {
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		return c.unavailable()
+	}
+	return errors.Join(errs...)
+}
+
+// undelivered tells whether a request failed before any node could act on it: the connection
+// was never made, or the endpoint or its budget turned it down.
+func undelivered(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return IsRateLimited(err) || errors.Is(err, ErrRateLimitBudget)
+}
+
+func (c *client) newRequest(method string, params []interface{}) rpcRequest {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	return rpcRequest{
 		JSONRPC: JSONRPCVersion,
 		Method:  method,
 		Params:  params,
-		ID:      1,
+		ID:      c.nextID.Add(1),
 	}
+}
 
+// post sends a single or batch JSON-RPC payload and returns the response body, to be closed
+// by the caller, trying the endpoints from the healthiest until one answers.
+func (c *client) post(ctx context.Context, payload any) (io.ReadCloser, error) {
 	requestBody, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewBuffer(requestBody))
+	cost := c.cost(payload)
+
+	var errs []error
+	for _, e := range c.route() {
+		responseBody, err := c.send(ctx, e, requestBody, cost)
+		if err == nil {
+			return responseBody, nil
+		}
+		// every endpoint would answer with the same oversized response
+		if ctx.Err() != nil || errors.Is(err, ErrResponseTooLarge) {
+			return nil, err
+		}
+
+		c.logger.Printf("error calling rpc endpoint %s: %v\n", e.name, err)
+		errs = append(errs, fmt.Errorf("%s: %w", e.name, err))
+	}
+
+	if len(errs) == 0 {
+		return nil, c.unavailable()
+	}
+	return nil, errors.Join(errs...)
+}
+
+// postTo sends the payload to a given endpoint, bypassing the routing.
+func (c *client) postTo(ctx context.Context, e *endpoint, payload any) (io.ReadCloser, error) {
+	requestBody, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
+	return c.send(ctx, e, requestBody, c.cost(payload))
+}
 
-	httpResp, err := c.httpClient.Do(httpReq)
+func (c *client) send(ctx context.Context, e *endpoint, requestBody []byte, cost float64) (io.ReadCloser, error) {
+	if err := e.limiter.wait(ctx, cost); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	responseBody, err := c.do(ctx, e, requestBody)
+	if ctx.Err() != nil {
+		// a cancelled call says nothing about the endpoint health
+		if responseBody != nil {
+			responseBody.Close()
+		}
+		return nil, ctx.Err()
+	}
+
+	// throttling and oversized responses say nothing either
+	if IsRateLimited(err) || errors.Is(err, ErrResponseTooLarge) {
+		return nil, err
+	}
+	if e.observe(time.Since(start), err) {
+		c.logger.Printf("[WARN] ejecting rpc endpoint %s: too many failures\n", e.name)
+	}
+	return responseBody, err
+}
+
+func (c *client) do(ctx context.Context, e *endpoint, requestBody []byte) (io.ReadCloser, error) {
+	if e.ipc != nil {
+		return c.doIPC(ctx, e, requestBody)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, err
 	}
-	defer httpResp.Body.Close()
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	if e.auth != nil {
+		if err := e.auth.apply(httpReq); err != nil {
+			return nil, err
+		}
+	}
 
-	responseBody, err := io.ReadAll(httpResp.Body)
+	httpResp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
 
-	var resp rpcResponse
-	if err := json.Unmarshal(responseBody, &resp); err != nil {
+	if httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode >= http.StatusInternalServerError {
+		httpResp.Body.Close()
+		return nil, newHTTPError(httpResp)
+	}
+
+	body, err := newResponseBody(httpResp, c.maxResponseSize)
+	if err != nil {
+		httpResp.Body.Close()
 		return nil, err
 	}
+	return body, nil
+}
 
-	if resp.Error != nil {
-		return nil, errors.New(resp.Error.Message)
+// doIPC bounds the call by the client timeout, the response being read whole before it returns.
+func (c *client) doIPC(ctx context.Context, e *endpoint, requestBody []byte) (io.ReadCloser, error) {
+	if c.timeout <= 0 {
+		return e.ipc.do(ctx, requestBody, c.maxResponseSize)
 	}
 
-	return resp.Result, nil
+	callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	responseBody, err := e.ipc.do(callCtx, requestBody, c.maxResponseSize)
+	// the endpoint timing out fails over, unlike the caller giving up
+	if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s", ErrTimeout, c.timeout)
+	}
+	return responseBody, err
 }
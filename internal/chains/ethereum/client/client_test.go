@@ -3,12 +3,17 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
 )
 
 func TestGetBlock_Success(t *testing.T) {
@@ -20,8 +25,8 @@ func TestGetBlock_Success(t *testing.T) {
 					Hash:        "h1",
 					From:        "0xfoo",
 					To:          "0xbar",
-					Value:       "123",
-					BlockNumber: "0x2",
+					Value:       evm.QuantityFromUint64(123),
+					BlockNumber: 2,
 				},
 			}
 		)
@@ -93,8 +98,164 @@ func TestGetBlock_Success(t *testing.T) {
 	})
 }
 
+func TestCall(t *testing.T) {
+	cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("unmarshal request: %v", err)
+		}
+		if req.Method != EthCall {
+			t.Errorf("want method %q, got %q", EthCall, req.Method)
+		}
+		if got := string(req.Params[0]); got != `{"to":"0x0000000000000000000000000000000000000001","data":"0x313ce567"}` {
+			t.Errorf("unexpected call object %s", got)
+		}
+		if got := string(req.Params[1]); got != `"0xa"` {
+			t.Errorf("want block tag \"0xa\", got %s", got)
+		}
+		io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"0x0000000000000000000000000000000000000000000000000000000000000008"}`)
+	}))
+	defer teardown()
+
+	got, err := cli.Call(context.Background(), "0x0000000000000000000000000000000000000001", []byte{0x31, 0x3c, 0xe5, 0x67}, 10)
+	if err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if len(got) != 32 || got[31] != 8 {
+		t.Errorf("Call = %x; want a 32 byte word holding 8", got)
+	}
+}
+
+func TestBatchCall(t *testing.T) {
+	t.Run("responses matched by id", func(t *testing.T) {
+		var requests int
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			var reqs []rpcRequest
+			if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+				t.Fatalf("unmarshal batch request: %v", err)
+			}
+
+			switch requests {
+			case 1:
+				if len(reqs) != 3 {
+					t.Fatalf("expected 3 calls, got %d", len(reqs))
+				}
+				if reqs[0].ID == reqs[1].ID || reqs[1].ID == reqs[2].ID || reqs[0].ID == reqs[2].ID {
+					t.Errorf("expected unique ids, got %d, %d and %d", reqs[0].ID, reqs[1].ID, reqs[2].ID)
+				}
+
+				// answered out of order, with a failed call and the last one missing
+				fmt.Fprintf(w, `[{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"header not found"}},{"jsonrpc":"2.0","id":%d,"result":"0xa"}]`, reqs[1].ID, reqs[0].ID)
+			default:
+				// only the missing call is sent again
+				if len(reqs) != 1 || reqs[0].Params[0] != "0x0000000000000000000000000000000000000003" {
+					t.Fatalf("expected the missing call to be retried alone, got %+v", reqs)
+				}
+				fmt.Fprintf(w, `[{"jsonrpc":"2.0","id":%d,"result":"0x14"}]`, reqs[0].ID)
+			}
+		}))
+		defer teardown()
+
+		addresses := []evm.Address{"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003"}
+		balances, err := cli.GetBalances(context.Background(), addresses, 10)
+
+		var batchErr BatchError
+		if !errors.As(err, &batchErr) {
+			t.Fatalf("GetBalances: expected a BatchError, got %v", err)
+		}
+		var rpcErr *RPCError
+		if len(batchErr) != 1 || !errors.As(batchErr[1], &rpcErr) || rpcErr.Code != -32000 {
+			t.Errorf("GetBalances: unexpected failed calls %v", batchErr)
+		}
+		if !balances[0].Equal(evm.QuantityFromUint64(10)) || !balances[1].IsZero() || !balances[2].Equal(evm.QuantityFromUint64(20)) {
+			t.Errorf("GetBalances = %v; want [10 0 20]", balances)
+		}
+	})
+
+	t.Run("unknown block", func(t *testing.T) {
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reqs []rpcRequest
+			if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+				t.Fatalf("unmarshal batch request: %v", err)
+			}
+			fmt.Fprintf(w, `[{"jsonrpc":"2.0","id":%d,"result":{"transactions":[]}},{"jsonrpc":"2.0","id":%d,"result":null}]`, reqs[0].ID, reqs[1].ID)
+		}))
+		defer teardown()
+
+		_, err := cli.GetBlocks(context.Background(), []string{"0x1", "0x2"})
+
+		var batchErr BatchError
+		if !errors.As(err, &batchErr) || len(batchErr) != 1 || !errors.Is(batchErr[1], ErrBlockNotFound) {
+			t.Errorf("GetBlocks: expected the second block not to be found, got %v", err)
+		}
+	})
+
+	t.Run("missing responses", func(t *testing.T) {
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `[]`)
+		}))
+		defer teardown()
+
+		_, err := cli.GetTransactionReceipts(context.Background(), []string{"h1"})
+		if !errors.Is(err, ErrMissingBatchResponse) {
+			t.Errorf("GetTransactionReceipts: expected %v, got %v", ErrMissingBatchResponse, err)
+		}
+	})
+
+	t.Run("batch rejected", func(t *testing.T) {
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batch too large"}}`)
+		}))
+		defer teardown()
+
+		_, err := cli.GetTransactionReceipts(context.Background(), []string{"h1", "h2"})
+		if err == nil || err.Error() != "batch too large" {
+			t.Errorf("expected RPC error \"batch too large\", got %v", err)
+		}
+	})
+
+	t.Run("split in chunks", func(t *testing.T) {
+		var requests int
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			var reqs []rpcRequest
+			if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+				t.Fatalf("unmarshal batch request: %v", err)
+			}
+			if len(reqs) > maxBatchSize {
+				t.Errorf("expected at most %d calls per request, got %d", maxBatchSize, len(reqs))
+			}
+
+			resps := make([]rpcResponse, len(reqs))
+			for i, req := range reqs {
+				resps[i] = rpcResponse{JSONRPC: JSONRPCVersion, ID: req.ID, Result: json.RawMessage(`null`)}
+			}
+			json.NewEncoder(w).Encode(resps)
+		}))
+		defer teardown()
+
+		receipts, err := cli.GetTransactionReceipts(context.Background(), make([]string, maxBatchSize+1))
+		if err != nil {
+			t.Fatalf("GetTransactionReceipts error: %v", err)
+		}
+		if requests != 2 || len(receipts) != maxBatchSize+1 {
+			t.Errorf("expected %d receipts in 2 requests, got %d in %d", maxBatchSize+1, len(receipts), requests)
+		}
+	})
+}
+
 func newTestClient(handler http.Handler) (Client, func()) {
 	ts := httptest.NewServer(handler)
-	cli := NewClient(ts.URL, log.Default())
-	return cli, ts.Close
+	return newTestEndpointsClient(ts.URL), ts.Close
+}
+
+// newTestEndpointsClient returns a client retrying without waiting.
+func newTestEndpointsClient(urls ...string) *client {
+	cli := NewClient(urls, log.Default()).(*client)
+	cli.retryPolicy = retryPolicy{maxAttempts: 3, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+	return cli
 }
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+// defaultMaxResponseSize bounds the bodies read from the nodes, a block with every
+// transaction object is a few megabytes at most.
+const defaultMaxResponseSize = 64 << 20
+
+// noResponseLimit is the size limit of responses when there is none.
+const noResponseLimit = math.MaxInt64
+
+// drainSize is how much of an unread body is discarded so the connection can be reused.
+const drainSize = 4 << 10
+
+var (
+	ErrResponseTooLarge   = errors.New("error response too large")
+	ErrUnexpectedResponse = errors.New("error unexpected response")
+)
+
+// responseBody reads up to limit bytes of a response, failing with ErrResponseTooLarge
+// past them, and drains what is left on close to keep the connection alive.
+type responseBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func newResponseBody(resp *http.Response, limit int64) (*responseBody, error) {
+	if limit > 0 && resp.ContentLength > limit {
+		return nil, fmt.Errorf("%w: %d bytes", ErrResponseTooLarge, resp.ContentLength)
+	}
+	if limit <= 0 {
+		limit = noResponseLimit
+	}
+	return &responseBody{
+		body:      resp.Body,
+		remaining: limit,
+	}, nil
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// an exact fit still has to prove there is nothing more
+		var probe [1]byte
+		if n, _ := b.body.Read(probe[:]); n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	io.CopyN(io.Discard, b.body, drainSize)
+	return b.body.Close()
+}
+
+// decodeResponse walks a JSON-RPC response envelope, handing the decoder to result when it
+// reaches the result so it can be decoded in place rather than buffered.
+func decodeResponse(r io.Reader, result func(dec *json.Decoder) error) error {
+	dec := json.NewDecoder(r)
+
+	var rpcErr *RPCError
+	_, err := decodeObject(dec, func(key string) error {
+		switch key {
+		case "result":
+			return result(dec)
+		case "error":
+			return dec.Decode(&rpcErr)
+		default:
+			return skipValue(dec)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if rpcErr != nil {
+		return rpcErr
+	}
+	return nil
+}
+
+// decodeInto returns a result decoder storing the result in v.
+func decodeInto(v any) func(dec *json.Decoder) error {
+	return func(dec *json.Decoder) error {
+		return dec.Decode(v)
+	}
+}
+
+// decodeBlock returns a result decoder reading the transactions of a block one at a time,
+// appending the ones keep accepts to txs. A nil keep accepts every transaction. The block
+// timestamp is set on the kept transactions once the whole block is read, as it may come
+// after them. A null block, unknown to the node, is ErrBlockNotFound.
+func decodeBlock(txs *[]TransactionResponse, keep func(*TransactionResponse) bool) func(dec *json.Decoder) error {
+	return func(dec *json.Decoder) error {
+		*txs = nil
+
+		var timestamp evm.Timestamp
+		found, err := decodeObject(dec, func(key string) error {
+			switch key {
+			case "timestamp":
+				return dec.Decode(&timestamp)
+			case "transactions":
+				return decodeArray(dec, func() error {
+					var tx TransactionResponse
+					if err := dec.Decode(&tx); err != nil {
+						return err
+					}
+					if keep == nil || keep(&tx) {
+						*txs = append(*txs, tx)
+					}
+					return nil
+				})
+			default:
+				return skipValue(dec)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrBlockNotFound
+		}
+
+		for i := range *txs {
+			(*txs)[i].BlockTimestamp = timestamp
+		}
+		return nil
+	}
+}
+
+// decodeObject reads the object the decoder is at, handing each key to member, which
+// must consume its value. A null, such as an unknown block, is reported as not found.
+func decodeObject(dec *json.Decoder, member func(key string) error) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return false, nil
+	}
+	if tok != json.Delim('{') {
+		return false, fmt.Errorf("%w: expected an object, got %v", ErrUnexpectedResponse, tok)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return true, err
+		}
+		if err := member(tok.(string)); err != nil {
+			return true, err
+		}
+	}
+	_, err = dec.Token()
+	return true, err
+}
+
+// decodeArray reads the array the decoder is at, calling elem for each element, which
+// must consume it. A null is read as an empty array.
+func decodeArray(dec *json.Decoder, elem func() error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("%w: expected an array, got %v", ErrUnexpectedResponse, tok)
+	}
+
+	for dec.More() {
+		if err := elem(); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// skipValue consumes the value the decoder is at without keeping it.
+func skipValue(dec *json.Decoder) error {
+	var skipped json.RawMessage
+	return dec.Decode(&skipped)
+}
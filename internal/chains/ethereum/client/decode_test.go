@@ -0,0 +1,203 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+func TestClient_FilterBlock(t *testing.T) {
+	t.Run("keeps the accepted transactions", func(t *testing.T) {
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(newBlockResponse(10))
+		}))
+		defer teardown()
+
+		txs, err := cli.FilterBlock(context.Background(), "0x1", func(tx *TransactionResponse) bool {
+			return tx.From == testTxSender(3) || tx.To == testTxSender(7)
+		})
+		if err != nil {
+			t.Fatalf("FilterBlock: unexpected error: %v", err)
+		}
+
+		want := []TransactionResponse{newTestTx(3), newTestTx(6)}
+		if !reflect.DeepEqual(txs, want) {
+			t.Errorf("FilterBlock = %+v; want %+v", txs, want)
+		}
+	})
+
+	t.Run("sets the block timestamp", func(t *testing.T) {
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"transactions":[{"hash":"0x1"},{"hash":"0x2"}],"timestamp":"0x6553f100"}}`)
+		}))
+		defer teardown()
+
+		txs, err := cli.FilterBlock(context.Background(), "0x1", nil)
+		if err != nil {
+			t.Fatalf("FilterBlock: unexpected error: %v", err)
+		}
+		for _, tx := range txs {
+			if tx.BlockTimestamp != 1_700_000_000 {
+				t.Errorf("FilterBlock: expected the block timestamp on %s, got %d", tx.Hash, tx.BlockTimestamp)
+			}
+		}
+	})
+
+	t.Run("unknown block", func(t *testing.T) {
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":null}`)
+		}))
+		defer teardown()
+
+		// an empty block would move the cursor past it
+		_, err := cli.FilterBlock(context.Background(), "0x1", nil)
+		if !errors.Is(err, ErrBlockNotFound) {
+			t.Errorf("FilterBlock: expected %v, got %v", ErrBlockNotFound, err)
+		}
+	})
+
+	t.Run("unexpected result", func(t *testing.T) {
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"transactions":"0x1"}}`)
+		}))
+		defer teardown()
+
+		_, err := cli.FilterBlock(context.Background(), "0x1", nil)
+		if !errors.Is(err, ErrUnexpectedResponse) {
+			t.Errorf("FilterBlock: expected %v, got %v", ErrUnexpectedResponse, err)
+		}
+	})
+}
+
+func TestClient_MaxResponseSize(t *testing.T) {
+	body := newBlockResponse(10)
+
+	for _, tt := range []struct {
+		name    string
+		chunked bool
+	}{
+		{name: "announced length"},
+		{name: "chunked", chunked: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if !tt.chunked {
+					w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+				}
+				w.Write(body)
+			}))
+			defer teardown()
+
+			cli.(*client).maxResponseSize = int64(len(body)) - 1
+			_, err := cli.GetBlock(context.Background(), "0x1")
+			if !errors.Is(err, ErrResponseTooLarge) {
+				t.Errorf("GetBlock: expected %v, got %v", ErrResponseTooLarge, err)
+			}
+			if calls != 1 {
+				t.Errorf("expected oversized responses not to be retried, got %d calls", calls)
+			}
+
+			cli.(*client).maxResponseSize = int64(len(body))
+			if _, err := cli.GetBlock(context.Background(), "0x1"); err != nil {
+				t.Errorf("GetBlock: unexpected error at the exact limit: %v", err)
+			}
+		})
+	}
+}
+
+// BenchmarkDecodeBlock compares reading a large block whole, as the client used to, with
+// decoding it as it streams in, keeping every transaction or only a few.
+func BenchmarkDecodeBlock(b *testing.B) {
+	body := newBlockResponse(2000)
+	subscribed := testTxSender(42)
+
+	b.Run("read all", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+		for b.Loop() {
+			data, err := io.ReadAll(bytes.NewReader(body))
+			if err != nil {
+				b.Fatal(err)
+			}
+			var resp rpcResponse
+			if err := json.Unmarshal(data, &resp); err != nil {
+				b.Fatal(err)
+			}
+			var block blockResponse
+			if err := json.Unmarshal(resp.Result, &block); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+		for b.Loop() {
+			var txs []TransactionResponse
+			if err := decodeResponse(bytes.NewReader(body), decodeBlock(&txs, nil)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("stream filtered", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(body)))
+		for b.Loop() {
+			var txs []TransactionResponse
+			keep := func(tx *TransactionResponse) bool { return tx.From == subscribed }
+			if err := decodeResponse(bytes.NewReader(body), decodeBlock(&txs, keep)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// newBlockResponse encodes a block holding count transactions, each sent from its own address.
+func newBlockResponse(count int) []byte {
+	txs := make([]TransactionResponse, count)
+	for i := range txs {
+		txs[i] = newTestTx(i)
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"jsonrpc": JSONRPCVersion,
+		"id":      1,
+		"result": map[string]any{
+			"number":       "0x1",
+			"hash":         "0x" + strings.Repeat("ab", 32),
+			"logsBloom":    "0x" + strings.Repeat("00", 256),
+			"transactions": txs,
+			"uncles":       []string{},
+		},
+	})
+	return body
+}
+
+func newTestTx(i int) TransactionResponse {
+	return TransactionResponse{
+		Hash:        fmt.Sprintf("0x%064x", i),
+		From:        testTxSender(i),
+		To:          testTxSender(i + 1),
+		Value:       evm.QuantityFromUint64(uint64(i)),
+		Nonce:       evm.Nonce(i),
+		Input:       "0x" + strings.Repeat("00", 68),
+		BlockNumber: 1,
+	}
+}
+
+func testTxSender(i int) string {
+	return fmt.Sprintf("0x%040x", i)
+}
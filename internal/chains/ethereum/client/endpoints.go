@@ -0,0 +1,242 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+const (
+	// ewmaWeight is the weight of the latest sample in the latency and error rate averages.
+	ewmaWeight = 0.2
+	// maxErrorRate ejects an endpoint, it is reached after a few consecutive failures.
+	maxErrorRate = 0.5
+	// maxHeadLag is the number of blocks an endpoint can be behind the best head before it is ejected.
+	maxHeadLag = 5
+)
+
+var (
+	ErrNoEndpoints      = errors.New("error no rpc endpoints configured")
+	ErrUnexpectedStatus = errors.New("error unexpected http status")
+	ErrInvalidEndpoint  = errors.New("error invalid rpc endpoint url")
+)
+
+// ValidateURLs checks the endpoint urls are http(s) urls, ipc:// urls or absolute socket
+// paths, so a host missing its scheme is refused at startup rather than dialed as a socket.
+func ValidateURLs(urls []string) error {
+	for _, rawURL := range urls {
+		if _, ok := ipcPath(rawURL); ok {
+			continue
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: %q, expected an http(s) url, an ipc:// url or an absolute socket path", ErrInvalidEndpoint, rawURL)
+		}
+	}
+	return nil
+}
+
+type endpoint struct {
+	url string
+	// name identifies the endpoint in logs without leaking credentials from the url.
+	name    string
+	limiter *tokenBucket
+	// auth is nil for endpoints without credentials.
+	auth *authenticator
+	// ipc is set for the endpoints reached over a Unix domain socket rather than http.
+	ipc *ipcTransport
+
+	mu        sync.Mutex
+	latency   time.Duration
+	errorRate float64
+	head      evm.BlockNumber
+	ejected   bool
+	// fault is why the endpoint failed its node checks, it isn't routed to while set.
+	fault error
+}
+
+func newEndpoint(rawURL string, limiter *tokenBucket) *endpoint {
+	if path, ok := ipcPath(rawURL); ok {
+		return &endpoint{
+			url:     rawURL,
+			name:    path,
+			limiter: limiter,
+			ipc:     newIPCTransport(path),
+		}
+	}
+
+	name := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		name = u.Host
+	}
+	return &endpoint{
+		url:     rawURL,
+		name:    name,
+		limiter: limiter,
+	}
+}
+
+// observe records the outcome of a request and reports whether it got the endpoint ejected.
+func (e *endpoint) observe(latency time.Duration, err error) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	failure := 0.0
+	if err != nil {
+		failure = 1
+	} else if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(ewmaWeight*float64(latency) + (1-ewmaWeight)*float64(e.latency))
+	}
+	e.errorRate = ewmaWeight*failure + (1-ewmaWeight)*e.errorRate
+
+	if !e.ejected && e.errorRate > maxErrorRate {
+		e.ejected = true
+		return true
+	}
+	return false
+}
+
+// score ranks admitted endpoints, the lower the healthier.
+func (e *endpoint) score() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return float64(e.latency) * (1 + 10*e.errorRate)
+}
+
+// route returns the endpoints in the order they should be tried: admitted ones from the
+// healthiest, then ejected ones as a last resort so a call is never refused outright.
+// Endpoints on another chain or still syncing are left out, their answers can't be trusted.
+func (c *client) route() []*endpoint {
+	var admitted, ejected []*endpoint
+	for _, e := range c.endpoints {
+		e.mu.Lock()
+		isEjected, fault := e.ejected, e.fault
+		e.mu.Unlock()
+
+		switch {
+		case fault != nil:
+			continue
+		case isEjected:
+			ejected = append(ejected, e)
+		default:
+			admitted = append(admitted, e)
+		}
+	}
+
+	slices.SortStableFunc(admitted, func(a, b *endpoint) int {
+		return cmp.Compare(a.score(), b.score())
+	})
+	return append(admitted, ejected...)
+}
+
+// CheckHealth checks every endpoint is on the configured chain and done syncing, then probes
+// them for their head. It ejects the ones that fail the checks or lag behind the best head
+// and re-admits the ejected ones that caught up. It fails when no endpoint is usable.
+func (c *client) CheckHealth(ctx context.Context) error {
+	heads := make([]evm.BlockNumber, len(c.endpoints))
+	errs := make([]error, len(c.endpoints))
+
+	var wg sync.WaitGroup
+	for i, e := range c.endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[i] = c.checkNode(ctx, e); errs[i] != nil {
+				errs[i] = fmt.Errorf("%s: %w", e.name, errs[i])
+				return
+			}
+			heads[i], errs[i] = c.probe(ctx, e)
+		}()
+	}
+	wg.Wait()
+
+	for i, e := range c.endpoints {
+		if isFault(errs[i]) {
+			c.setFault(e, errs[i])
+		}
+	}
+
+	var best evm.BlockNumber
+	for i := range c.endpoints {
+		if errs[i] == nil && heads[i] > best {
+			best = heads[i]
+		}
+	}
+	if best == 0 {
+		c.logger.Printf("error probing rpc endpoints: %v\n", errors.Join(errs...))
+		return errors.Join(errs...)
+	}
+
+	for i, e := range c.endpoints {
+		if errs[i] != nil {
+			continue
+		}
+
+		e.mu.Lock()
+		e.head = heads[i]
+		lagging := heads[i]+maxHeadLag < best
+		e.fault = nil
+		switch {
+		case lagging && !e.ejected:
+			e.ejected = true
+			c.logger.Printf("[WARN] ejecting rpc endpoint %s: head %s is behind %s\n", e.name, heads[i], best)
+		case !lagging && e.ejected:
+			e.ejected = false
+			e.errorRate = 0
+			c.logger.Printf("[INFO] re-admitting rpc endpoint %s at head %s\n", e.name, heads[i])
+		}
+		e.mu.Unlock()
+	}
+	return nil
+}
+
+func (c *client) setFault(e *endpoint, fault error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.fault == nil {
+		c.logger.Printf("[WARN] ejecting rpc endpoint %v\n", fault)
+	}
+	e.fault = fault
+	e.ejected = true
+}
+
+// unavailable explains why no endpoint could be routed to.
+func (c *client) unavailable() error {
+	var faults []error
+	for _, e := range c.endpoints {
+		e.mu.Lock()
+		if e.fault != nil {
+			faults = append(faults, e.fault)
+		}
+		e.mu.Unlock()
+	}
+	if len(faults) == 0 {
+		return ErrNoEndpoints
+	}
+	return errors.Join(faults...)
+}
+
+func (c *client) probe(ctx context.Context, e *endpoint) (evm.BlockNumber, error) {
+	responseBody, err := c.postTo(ctx, e, c.newRequest(EthBlockNumber, nil))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", e.name, err)
+	}
+	defer responseBody.Close()
+
+	var head evm.BlockNumber
+	if err := decodeResponse(responseBody, decodeInto(&head)); err != nil {
+		return 0, fmt.Errorf("%s: %w", e.name, err)
+	}
+	return head, nil
+}
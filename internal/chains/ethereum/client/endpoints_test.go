@@ -0,0 +1,264 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Failover(t *testing.T) {
+	t.Run("fails over to the next endpoint", func(t *testing.T) {
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer down.Close()
+		up := newHeadServer(func() uint64 { return 10 })
+		defer up.Close()
+
+		cli := newTestEndpointsClient(down.URL, up.URL)
+		// the broken endpoint looks the healthiest until it fails
+		cli.endpoints[1].latency = time.Second
+
+		for range 4 {
+			got, err := cli.BlockNumber(context.Background())
+			if err != nil {
+				t.Fatalf("BlockNumber: unexpected error: %v", err)
+			}
+			if got != 10 {
+				t.Errorf("BlockNumber: want 10, got %d", got)
+			}
+		}
+
+		if !cli.endpoints[0].ejected {
+			t.Errorf("expected the failing endpoint to be ejected")
+		}
+		if got := cli.route()[0]; got != cli.endpoints[1] {
+			t.Errorf("expected calls routed to %s, got %s", cli.endpoints[1].name, got.name)
+		}
+	})
+
+	t.Run("fails over a hung endpoint", func(t *testing.T) {
+		release := make(chan struct{})
+		hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer hung.Close()
+		defer close(release)
+		up := newHeadServer(func() uint64 { return 10 })
+		defer up.Close()
+
+		cli := NewClient([]string{hung.URL, up.URL}, log.Default(), WithTimeout(50*time.Millisecond)).(*client)
+		cli.endpoints[1].latency = time.Second
+
+		got, err := cli.BlockNumber(context.Background())
+		if err != nil {
+			t.Fatalf("BlockNumber: unexpected error: %v", err)
+		}
+		if got != 10 {
+			t.Errorf("BlockNumber: want 10, got %d", got)
+		}
+	})
+
+	t.Run("every endpoint down", func(t *testing.T) {
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer down.Close()
+
+		cli := newTestEndpointsClient(down.URL, down.URL)
+
+		_, err := cli.BlockNumber(context.Background())
+		if !errors.Is(err, ErrUnexpectedStatus) {
+			t.Errorf("BlockNumber: expected %v, got %v", ErrUnexpectedStatus, err)
+		}
+	})
+
+	t.Run("no endpoints", func(t *testing.T) {
+		cli := newTestEndpointsClient()
+
+		_, err := cli.BlockNumber(context.Background())
+		if !errors.Is(err, ErrNoEndpoints) {
+			t.Errorf("BlockNumber: expected %v, got %v", ErrNoEndpoints, err)
+		}
+	})
+}
+
+func TestClient_SendRawTransaction_Once(t *testing.T) {
+	// accepting answers the hash, and already knows the transaction when sent again
+	newNode := func(sent *atomic.Int32, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if sent.Add(1) > 1 {
+				fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"already known"}}`)
+				return
+			}
+			w.WriteHeader(status)
+			if status == http.StatusOK {
+				fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":"0xabc"}`)
+			}
+		}))
+	}
+
+	t.Run("not resent once the node may have it", func(t *testing.T) {
+		var sent atomic.Int32
+		node := newNode(&sent, http.StatusBadGateway)
+		defer node.Close()
+
+		cli := newTestEndpointsClient(node.URL, node.URL)
+
+		_, err := cli.SendRawTransaction(context.Background(), "0x02")
+		if !errors.Is(err, ErrUnexpectedStatus) {
+			t.Errorf("SendRawTransaction: expected %v, got %v", ErrUnexpectedStatus, err)
+		}
+		if got := sent.Load(); got != 1 {
+			t.Errorf("expected the transaction sent once, got %d", got)
+		}
+	})
+
+	t.Run("fails over while no node got it", func(t *testing.T) {
+		var throttled, sent atomic.Int32
+		limited := newNode(&throttled, http.StatusTooManyRequests)
+		defer limited.Close()
+		node := newNode(&sent, http.StatusOK)
+		defer node.Close()
+
+		cli := newTestEndpointsClient(limited.URL, node.URL)
+		cli.endpoints[1].latency = time.Second
+
+		hash, err := cli.SendRawTransaction(context.Background(), "0x02")
+		if err != nil {
+			t.Fatalf("SendRawTransaction: unexpected error: %v", err)
+		}
+		if hash != "0xabc" || throttled.Load() != 1 || sent.Load() != 1 {
+			t.Errorf("expected 0xabc sent once to each endpoint, got %q after %d and %d", hash, throttled.Load(), sent.Load())
+		}
+	})
+}
+
+func TestClient_CheckHealth(t *testing.T) {
+	var laggingHead atomic.Uint64
+	laggingHead.Store(90)
+
+	synced := newHeadServer(func() uint64 { return 100 })
+	defer synced.Close()
+	lagging := newHeadServer(laggingHead.Load)
+	defer lagging.Close()
+
+	cli := newTestEndpointsClient(lagging.URL, synced.URL)
+
+	if err := cli.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("CheckHealth: unexpected error: %v", err)
+	}
+	if !cli.endpoints[0].ejected || cli.endpoints[1].ejected {
+		t.Fatalf("expected only the lagging endpoint to be ejected")
+	}
+	if got := cli.route()[0]; got != cli.endpoints[1] {
+		t.Errorf("expected calls routed to %s, got %s", cli.endpoints[1].name, got.name)
+	}
+
+	laggingHead.Store(99)
+	if err := cli.CheckHealth(context.Background()); err != nil {
+		t.Fatalf("CheckHealth: unexpected error: %v", err)
+	}
+	if cli.endpoints[0].ejected {
+		t.Errorf("expected the endpoint to be re-admitted once caught up")
+	}
+	if cli.endpoints[0].head != 99 {
+		t.Errorf("expected head 99, got %d", cli.endpoints[0].head)
+	}
+}
+
+func TestClient_CheckHealth_NodeChecks(t *testing.T) {
+	t.Run("wrong chain", func(t *testing.T) {
+		mainnet := newHeadServer(func() uint64 { return 100 })
+		defer mainnet.Close()
+		sepolia := newNodeServer(func() uint64 { return 100 }, 11155111, synced)
+		defer sepolia.Close()
+
+		cli := newTestEndpointsClient(sepolia.URL, mainnet.URL)
+		WithChainID(1, 1)(cli)
+
+		if err := cli.CheckHealth(context.Background()); err != nil {
+			t.Fatalf("CheckHealth: unexpected error: %v", err)
+		}
+		if !errors.Is(cli.endpoints[0].fault, ErrWrongChain) {
+			t.Errorf("expected the endpoint on another chain to be faulted, got %v", cli.endpoints[0].fault)
+		}
+		if route := cli.route(); len(route) != 1 || route[0] != cli.endpoints[1] {
+			t.Errorf("expected calls only routed to %s", cli.endpoints[1].name)
+		}
+	})
+
+	t.Run("syncing until caught up", func(t *testing.T) {
+		var syncing atomic.Bool
+		syncing.Store(true)
+
+		node := newNodeServer(func() uint64 { return 100 }, 1, func() string {
+			if syncing.Load() {
+				return `{"startingBlock":"0x0","currentBlock":"0x10","highestBlock":"0x64"}`
+			}
+			return "false"
+		})
+		defer node.Close()
+
+		cli := newTestEndpointsClient(node.URL)
+		WithChainID(1, 1)(cli)
+
+		err := cli.CheckHealth(context.Background())
+		if !errors.Is(err, ErrNodeSyncing) {
+			t.Fatalf("CheckHealth: expected %v, got %v", ErrNodeSyncing, err)
+		}
+		if _, err := cli.BlockNumber(context.Background()); !errors.Is(err, ErrNodeSyncing) {
+			t.Errorf("BlockNumber: expected %v, got %v", ErrNodeSyncing, err)
+		}
+
+		syncing.Store(false)
+		if err := cli.CheckHealth(context.Background()); err != nil {
+			t.Fatalf("CheckHealth: unexpected error: %v", err)
+		}
+		if cli.endpoints[0].fault != nil || cli.endpoints[0].ejected {
+			t.Errorf("expected the endpoint to be re-admitted once synced")
+		}
+	})
+}
+
+// newHeadServer answers as a synced mainnet node at the given head.
+func newHeadServer(head func() uint64) *httptest.Server {
+	return newNodeServer(head, 1, synced)
+}
+
+func synced() string { return "false" }
+
+// newNodeServer answers the node checks with the chain id and syncing status, and every
+// other call with the head.
+func newNodeServer(head func() uint64, chainID uint64, syncing func() string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"0x%x"}`, head())
+			return
+		}
+
+		resps := make([]string, len(batch))
+		for i, req := range batch {
+			result := fmt.Sprintf(`"0x%x"`, head())
+			switch req.Method {
+			case EthChainID:
+				result = fmt.Sprintf(`"0x%x"`, chainID)
+			case NetVersion:
+				result = fmt.Sprintf(`"%d"`, chainID)
+			case EthSyncing:
+				result = syncing()
+			}
+			resps[i] = fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, result)
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(resps, ","))
+	}))
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// JSON-RPC error codes, the ones above -32100 are reserved by the spec and
+// the server range below is used by nodes and providers.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeLimitExceeded  = -32005
+)
+
+// RPCError is an error object returned by the node.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// HTTPError is a response rejected by the endpoint before reaching the node.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	// RetryAfter is the wait asked by the endpoint, zero when unset.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrUnexpectedStatus, e.Status)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+func newHTTPError(resp *http.Response) *HTTPError {
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter reads both the delay in seconds and the http date forms of the header.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// IsRateLimited reports whether the endpoint throttled the call.
+func IsRateLimited(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == CodeLimitExceeded
+	}
+	return false
+}
+
+// IsRetryable reports whether the call may succeed when sent again: rate limits, server
+// side failures and transport errors are transient, node errors and cancellations aren't.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// a lagging endpoint catches up with the head by the next attempt, or another one answers
+	if IsRateLimited(err) || errors.Is(err, ErrBlockNotFound) {
+		return true
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == CodeInternalError
+	}
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, ErrNoEndpoints) || errors.Is(err, ErrRateLimitBudget) ||
+		errors.Is(err, ErrResponseTooLarge) || errors.Is(err, ErrUnexpectedResponse) {
+		return false
+	}
+	// anything else failed on the way to the node
+	return true
+}
+
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{
+	maxAttempts: 4,
+	baseDelay:   250 * time.Millisecond,
+	maxDelay:    10 * time.Second,
+}
+
+// backoff returns the wait before the given retry, a full jitter exponential delay
+// unless the endpoint asked for a specific one.
+func (p retryPolicy) backoff(attempt int, err error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		return httpErr.RetryAfter
+	}
+
+	delay := min(p.baseDelay<<attempt, p.maxDelay)
+	return rand.N(delay + 1)
+}
+
+// retry runs call until it succeeds, fails with a permanent error, runs out of attempts
+// or the next wait would outlive the context deadline.
+func (c *client) retry(ctx context.Context, call func() error) error {
+	var err error
+	for attempt := range c.retryPolicy.maxAttempts {
+		err = call()
+		if !IsRetryable(err) || attempt == c.retryPolicy.maxAttempts-1 {
+			return err
+		}
+
+		wait := c.retryPolicy.backoff(attempt, err)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		c.logger.Printf("[WARN] retrying rpc call in %s: %v\n", wait, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		rateLimited bool
+		retryable   bool
+	}{
+		{name: "too many requests", err: &HTTPError{StatusCode: http.StatusTooManyRequests}, rateLimited: true, retryable: true},
+		{name: "limit exceeded", err: &RPCError{Code: CodeLimitExceeded}, rateLimited: true, retryable: true},
+		{name: "bad gateway", err: &HTTPError{StatusCode: http.StatusBadGateway}, retryable: true},
+		{name: "internal error", err: fmt.Errorf("wrapped: %w", &RPCError{Code: CodeInternalError}), retryable: true},
+		{name: "transport error", err: io.ErrUnexpectedEOF, retryable: true},
+		{name: "unknown block", err: ErrBlockNotFound, retryable: true},
+		{name: "invalid params", err: &RPCError{Code: CodeInvalidParams}},
+		{name: "execution reverted", err: &RPCError{Code: 3, Message: "execution reverted"}},
+		{name: "cancelled", err: context.Canceled},
+		{name: "rate limit budget", err: ErrRateLimitBudget},
+		{name: "no endpoints", err: ErrNoEndpoints},
+		{name: "no error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRateLimited(tt.err); got != tt.rateLimited {
+				t.Errorf("IsRateLimited(%v) = %v; want %v", tt.err, got, tt.rateLimited)
+			}
+			if got := IsRetryable(tt.err); got != tt.retryable {
+				t.Errorf("IsRetryable(%v) = %v; want %v", tt.err, got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "3", want: 3 * time.Second},
+		{value: "Wed, 01 Jan 2025 00:00:05 GMT", want: 5 * time.Second},
+		{value: "Tue, 31 Dec 2024 23:59:00 GMT", want: 0},
+		{value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s; want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	t.Run("retries rate limited calls", func(t *testing.T) {
+		var requests int
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			if requests == 2 {
+				io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"limit exceeded"}}`)
+				return
+			}
+			io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"0xa"}`)
+		}))
+		defer teardown()
+
+		got, err := cli.BlockNumber(context.Background())
+		if err != nil {
+			t.Fatalf("BlockNumber: unexpected error: %v", err)
+		}
+		if got != 10 || requests != 3 {
+			t.Errorf("BlockNumber: want 10 after 3 requests, got %d after %d", got, requests)
+		}
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		var requests int
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid argument","data":"0x01"}}`)
+		}))
+		defer teardown()
+
+		_, err := cli.BlockNumber(context.Background())
+
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			t.Fatalf("BlockNumber: expected an RPCError, got %v", err)
+		}
+		if rpcErr.Code != CodeInvalidParams || string(rpcErr.Data) != `"0x01"` {
+			t.Errorf("BlockNumber: unexpected error %+v", rpcErr)
+		}
+		if requests != 1 {
+			t.Errorf("expected a single request, got %d", requests)
+		}
+	})
+
+	t.Run("gives up when out of attempts", func(t *testing.T) {
+		var requests int
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer teardown()
+
+		_, err := cli.BlockNumber(context.Background())
+		if !errors.Is(err, ErrUnexpectedStatus) {
+			t.Errorf("BlockNumber: expected %v, got %v", ErrUnexpectedStatus, err)
+		}
+		if requests != 3 {
+			t.Errorf("expected 3 attempts, got %d", requests)
+		}
+	})
+
+	t.Run("honours retry after within the deadline", func(t *testing.T) {
+		var requests int
+		cli, teardown := newTestClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer teardown()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := cli.BlockNumber(ctx)
+
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.RetryAfter != time.Minute {
+			t.Errorf("BlockNumber: expected a 429 asking to wait a minute, got %v", err)
+		}
+		if requests != 1 {
+			t.Errorf("expected no retry past the deadline, got %d requests", requests)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ipcScheme = "ipc://"
+	// maxIdleIPCConns is the number of connections kept open to a socket between calls.
+	maxIdleIPCConns = 4
+)
+
+// ipcPath returns the socket path of an ipc:// url or an absolute file path, and false for
+// anything else, a relative path being too easily mistaken for a host without a scheme.
+func ipcPath(rawURL string) (string, bool) {
+	if path, ok := strings.CutPrefix(rawURL, ipcScheme); ok {
+		return path, true
+	}
+	if filepath.IsAbs(rawURL) {
+		return rawURL, true
+	}
+	return "", false
+}
+
+// ipcTransport sends the calls to a node over its Unix domain socket, where requests and
+// responses are JSON values written back to back. A connection carries one call at a time.
+type ipcTransport struct {
+	path   string
+	dialer net.Dialer
+
+	mu   sync.Mutex
+	idle []*ipcConn
+}
+
+type ipcConn struct {
+	conn net.Conn
+	// body enforces the response size limit of the call in progress.
+	body *responseBody
+	dec  *json.Decoder
+}
+
+func newIPCTransport(path string) *ipcTransport {
+	return &ipcTransport{
+		path: path,
+	}
+}
+
+// do sends the request and returns the response read whole, JSON values not being
+// delimited on the socket otherwise.
+func (t *ipcTransport) do(ctx context.Context, requestBody []byte, maxResponseSize int64) (io.ReadCloser, error) {
+	conn, err := t.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// a cancelled call interrupts the blocked read or write
+	stop := context.AfterFunc(ctx, func() {
+		conn.conn.SetDeadline(time.Now())
+	})
+
+	var response json.RawMessage
+	err = conn.call(requestBody, maxResponseSize, &response)
+
+	// a connection the cancellation set a deadline on, or might still, isn't reused
+	if stop() && err == nil {
+		t.put(conn)
+	} else {
+		conn.conn.Close()
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(response)), nil
+}
+
+func (c *ipcConn) call(requestBody []byte, maxResponseSize int64, response *json.RawMessage) error {
+	if _, err := c.conn.Write(requestBody); err != nil {
+		return err
+	}
+
+	c.body.remaining = maxResponseSize
+	if maxResponseSize <= 0 {
+		c.body.remaining = noResponseLimit
+	}
+	return c.dec.Decode(response)
+}
+
+func (t *ipcTransport) get(ctx context.Context) (*ipcConn, error) {
+	t.mu.Lock()
+	if n := len(t.idle); n > 0 {
+		conn := t.idle[n-1]
+		t.idle = t.idle[:n-1]
+		t.mu.Unlock()
+
+		if err := conn.conn.SetDeadline(time.Time{}); err != nil {
+			conn.conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+	t.mu.Unlock()
+
+	conn, err := t.dialer.DialContext(ctx, "unix", t.path)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &responseBody{body: conn}
+	return &ipcConn{
+		conn: conn,
+		body: body,
+		dec:  json.NewDecoder(body),
+	}, nil
+}
+
+func (t *ipcTransport) put(conn *ipcConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.idle) >= maxIdleIPCConns {
+		conn.conn.Close()
+		return
+	}
+	t.idle = append(t.idle, conn)
+}
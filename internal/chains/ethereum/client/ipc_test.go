@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+func TestIPCPath(t *testing.T) {
+	for _, tt := range []struct {
+		url  string
+		path string
+		ok   bool
+	}{
+		{url: "ipc:///var/run/geth.ipc", path: "/var/run/geth.ipc", ok: true},
+		{url: "/var/run/geth.ipc", path: "/var/run/geth.ipc", ok: true},
+		{url: "geth.ipc"},
+		{url: "https://ethereum-rpc.publicnode.com"},
+		{url: "http://127.0.0.1:8545"},
+		{url: "localhost:8545"},
+	} {
+		path, ok := ipcPath(tt.url)
+		if path != tt.path || ok != tt.ok {
+			t.Errorf("ipcPath(%q) = %q, %v; want %q, %v", tt.url, path, ok, tt.path, tt.ok)
+		}
+	}
+}
+
+func TestValidateURLs(t *testing.T) {
+	for _, tt := range []struct {
+		url   string
+		valid bool
+	}{
+		{url: "https://ethereum-rpc.publicnode.com", valid: true},
+		{url: "http://127.0.0.1:8545", valid: true},
+		{url: "ipc:///var/run/geth.ipc", valid: true},
+		{url: "/var/run/geth.ipc", valid: true},
+		{url: "node.example.com:8545"},
+		{url: "localhost:8545"},
+		{url: "geth.ipc"},
+		{url: "ws://127.0.0.1:8546"},
+	} {
+		err := ValidateURLs([]string{tt.url})
+		if tt.valid && err != nil {
+			t.Errorf("ValidateURLs(%q): unexpected error: %v", tt.url, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidEndpoint) {
+			t.Errorf("ValidateURLs(%q): expected %v, got %v", tt.url, ErrInvalidEndpoint, err)
+		}
+	}
+}
+
+func TestClient_IPC(t *testing.T) {
+	t.Run("single and batch calls", func(t *testing.T) {
+		path, conns := newIPCServer(t, func(req rpcRequest) string {
+			switch req.Method {
+			case EthBlockNumber:
+				return `"0x2a"`
+			case EthGetBalance:
+				return `"0x64"`
+			}
+			return "null"
+		})
+
+		cli := newTestEndpointsClient("ipc://" + path)
+
+		for range 3 {
+			head, err := cli.BlockNumber(context.Background())
+			if err != nil {
+				t.Fatalf("BlockNumber: unexpected error: %v", err)
+			}
+			if head != 42 {
+				t.Errorf("BlockNumber: want 42, got %d", head)
+			}
+		}
+
+		balances, err := cli.GetBalances(context.Background(), []evm.Address{"0x1", "0x2"}, 42)
+		if err != nil {
+			t.Fatalf("GetBalances: unexpected error: %v", err)
+		}
+		for i, balance := range balances {
+			if !balance.Equal(evm.QuantityFromUint64(100)) {
+				t.Errorf("GetBalances: want 100 for %d, got %s", i, balance)
+			}
+		}
+
+		if got := conns.Load(); got != 1 {
+			t.Errorf("expected the connection to be reused, got %d connections", got)
+		}
+	})
+
+	t.Run("response too large", func(t *testing.T) {
+		path, _ := newIPCServer(t, func(req rpcRequest) string {
+			return fmt.Sprintf("%q", strings.Repeat("a", 100))
+		})
+
+		cli := newTestEndpointsClient(path)
+		cli.maxResponseSize = 64
+
+		_, err := cli.TraceTransaction(context.Background(), "0x1")
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("TraceTransaction: expected %v, got %v", ErrResponseTooLarge, err)
+		}
+	})
+
+	t.Run("hung node times out", func(t *testing.T) {
+		release := make(chan struct{})
+		path, _ := newIPCServer(t, func(req rpcRequest) string {
+			<-release
+			return `"0x2a"`
+		})
+		t.Cleanup(func() { close(release) })
+
+		cli := NewClient([]string{path}, log.Default(), WithTimeout(50*time.Millisecond)).(*client)
+		cli.retryPolicy = retryPolicy{maxAttempts: 1}
+
+		if _, err := cli.BlockNumber(context.Background()); !errors.Is(err, ErrTimeout) {
+			t.Errorf("BlockNumber: expected %v, got %v", ErrTimeout, err)
+		}
+	})
+
+	t.Run("socket not listening", func(t *testing.T) {
+		cli := newTestEndpointsClient(filepath.Join(t.TempDir(), "missing.ipc"))
+
+		if _, err := cli.BlockNumber(context.Background()); err == nil {
+			t.Errorf("BlockNumber: expected an error, got none")
+		}
+	})
+}
+
+// newIPCServer serves JSON-RPC calls on a Unix domain socket, answering each call with the
+// result returned by answer. It returns the socket path and the count of accepted connections.
+func newIPCServer(t *testing.T, answer func(req rpcRequest) string) (string, *atomic.Int32) {
+	path := filepath.Join(t.TempDir(), "node.ipc")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listening on %s: %v", path, err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var conns atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns.Add(1)
+
+			go func() {
+				defer conn.Close()
+
+				dec := json.NewDecoder(conn)
+				for {
+					var msg json.RawMessage
+					if err := dec.Decode(&msg); err != nil {
+						return
+					}
+
+					respond := func(req rpcRequest) string {
+						return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, answer(req))
+					}
+
+					var batch []rpcRequest
+					if json.Unmarshal(msg, &batch) == nil {
+						resps := make([]string, len(batch))
+						for i, req := range batch {
+							resps[i] = respond(req)
+						}
+						fmt.Fprintf(conn, "[%s]\n", strings.Join(resps, ","))
+						continue
+					}
+
+					var req rpcRequest
+					json.Unmarshal(msg, &req)
+					fmt.Fprintln(conn, respond(req))
+				}
+			}()
+		}
+	}()
+	return path, &conns
+}
@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMethodCost is charged for the methods missing from the cost table.
+const defaultMethodCost = 20
+
+// DefaultMethodCosts are weights in compute units, close to what the main providers charge.
+var DefaultMethodCosts = MethodCosts{
+	EthBlockNumber:      10,
+	EthGetBalance:       19,
+	EthGetBlockByNumber: 16,
+	EthCall:             26,
+	EthGetReceipt:       15,
+	EthGetTxCount:       26,
+	EthGetTxByHash:      17,
+	EthSendRawTx:        250,
+	"debug_trace*":      309,
+	"trace_*":           75,
+}
+
+var ErrRateLimitBudget = errors.New("error rate limit budget not available before the deadline")
+
+// MethodCosts weights the calls charged to the rate limit budget, a key ending with
+// a * applies to every method starting with it.
+type MethodCosts map[string]float64
+
+// Cost returns the weight of the method, the longest matching prefix wins over shorter ones.
+func (m MethodCosts) Cost(method string) float64 {
+	if cost, ok := m[method]; ok {
+		return cost
+	}
+
+	cost, matched := float64(defaultMethodCost), 0
+	for key, keyCost := range m {
+		prefix, ok := strings.CutSuffix(key, "*")
+		if ok && strings.HasPrefix(method, prefix) && len(prefix) >= matched {
+			cost, matched = keyCost, len(prefix)
+		}
+	}
+	return cost
+}
+
+// ParseMethodCosts parses a comma separated list of method=cost pairs on top of the defaults.
+func ParseMethodCosts(costs string) (MethodCosts, error) {
+	parsed := make(MethodCosts, len(DefaultMethodCosts))
+	for method, cost := range DefaultMethodCosts {
+		parsed[method] = cost
+	}
+
+	for _, entry := range strings.Split(costs, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		method, rawCost, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid method cost %q: expected method=cost", entry)
+		}
+
+		cost, err := strconv.ParseFloat(strings.TrimSpace(rawCost), 64)
+		if err != nil || cost < 0 {
+			return nil, fmt.Errorf("invalid method cost %q: expected a positive number", entry)
+		}
+		parsed[strings.TrimSpace(method)] = cost
+	}
+	return parsed, nil
+}
+
+// Usage is a snapshot of the rate limit budget of a node endpoint.
+type Usage struct {
+	Endpoint string `json:"endpoint"`
+	// Rate is the budget refilled per second, zero when unlimited.
+	Rate      float64 `json:"rate"`
+	Burst     float64 `json:"burst"`
+	Available float64 `json:"available"`
+	// Consumed and Requests are totals since the client started.
+	Consumed  float64       `json:"consumed"`
+	Requests  uint64        `json:"requests"`
+	Throttled time.Duration `json:"throttled"`
+}
+
+// tokenBucket is the budget of an endpoint, shared by every caller of the client.
+// Calls reserve their cost up front and wait for the bucket to refill when it goes negative.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	tokens    float64
+	last      time.Time
+	consumed  float64
+	requests  uint64
+	throttled time.Duration
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	if burst <= 0 {
+		burst = max(rate, 1)
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) wait(ctx context.Context, cost float64) error {
+	b.mu.Lock()
+	b.consumed += cost
+	b.requests++
+	if b.rate <= 0 {
+		b.mu.Unlock()
+		return nil
+	}
+
+	// a call costing more than the burst would never fit, it drains the bucket instead
+	reserved := min(cost, b.burst)
+	b.refill(time.Now())
+	b.tokens -= reserved
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+		b.throttled += wait
+	}
+	b.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		b.refund(cost, reserved, wait)
+		return ErrRateLimitBudget
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		b.refund(cost, reserved, wait)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// refund gives back the reservation of a call that won't be sent, the reserved tokens
+// and the cost charged to the usage.
+func (b *tokenBucket) refund(cost, reserved float64, wait time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += reserved
+	b.consumed -= cost
+	b.requests--
+	b.throttled -= wait
+}
+
+func (b *tokenBucket) usage() Usage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate > 0 {
+		b.refill(time.Now())
+	}
+	return Usage{
+		Rate:      b.rate,
+		Burst:     b.burst,
+		Available: max(b.tokens, 0),
+		Consumed:  b.consumed,
+		Requests:  b.requests,
+		Throttled: b.throttled,
+	}
+}
+
+// cost weights a single or batch payload.
+func (c *client) cost(payload any) float64 {
+	switch p := payload.(type) {
+	case rpcRequest:
+		return c.methodCosts.Cost(p.Method)
+	case []rpcRequest:
+		var total float64
+		for _, req := range p {
+			total += c.methodCosts.Cost(req.Method)
+		}
+		return total
+	}
+	return defaultMethodCost
+}
+
+// Usage returns the rate limit budget of every endpoint, in the configured order.
+func (c *client) Usage() []Usage {
+	usages := make([]Usage, len(c.endpoints))
+	for i, e := range c.endpoints {
+		usages[i] = e.limiter.usage()
+		usages[i].Endpoint = e.name
+	}
+	return usages
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMethodCosts(t *testing.T) {
+	costs := MethodCosts{
+		EthBlockNumber:             1,
+		"debug_*":                  50,
+		"debug_traceTransaction*":  100,
+		"debug_traceBlockByNumber": 200,
+	}
+
+	tests := map[string]float64{
+		EthBlockNumber:             1,
+		"debug_traceTransaction":   100,
+		"debug_traceBlockByNumber": 200,
+		"debug_getRawBlock":        50,
+		EthCall:                    defaultMethodCost,
+	}
+	for method, want := range tests {
+		if got := costs.Cost(method); got != want {
+			t.Errorf("Cost(%s) = %v; want %v", method, got, want)
+		}
+	}
+}
+
+func TestParseMethodCosts(t *testing.T) {
+	t.Run("overrides the defaults", func(t *testing.T) {
+		costs, err := ParseMethodCosts(" eth_call=5, debug_trace*=1000 ,")
+		if err != nil {
+			t.Fatalf("ParseMethodCosts: unexpected error: %v", err)
+		}
+		if costs.Cost(EthCall) != 5 || costs.Cost("debug_traceCall") != 1000 {
+			t.Errorf("ParseMethodCosts: unexpected costs %v", costs)
+		}
+		if costs.Cost(EthBlockNumber) != DefaultMethodCosts[EthBlockNumber] {
+			t.Errorf("ParseMethodCosts: expected defaults to be kept, got %v", costs)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, costs := range []string{"eth_call", "eth_call=cheap", "eth_call=-1"} {
+			if _, err := ParseMethodCosts(costs); err == nil {
+				t.Errorf("ParseMethodCosts(%q): expected error, got none", costs)
+			}
+		}
+	})
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"0xa"}`)
+	}))
+	defer ts.Close()
+
+	t.Run("calls wait for the budget", func(t *testing.T) {
+		// a block number costs 10 units, the bucket refills one call every 50ms
+		cli := newTestEndpointsClient(ts.URL)
+		cli.endpoints[0].limiter = newTokenBucket(200, 20)
+
+		start := time.Now()
+		for range 4 {
+			if _, err := cli.BlockNumber(context.Background()); err != nil {
+				t.Fatalf("BlockNumber: unexpected error: %v", err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("expected the last calls to be throttled, took %s", elapsed)
+		}
+
+		usage := cli.Usage()[0]
+		if usage.Requests != 4 || usage.Consumed != 40 || usage.Throttled == 0 {
+			t.Errorf("unexpected usage %+v", usage)
+		}
+	})
+
+	t.Run("budget not available before the deadline", func(t *testing.T) {
+		cli := newTestEndpointsClient(ts.URL)
+		cli.endpoints[0].limiter = newTokenBucket(1, 10)
+
+		if _, err := cli.BlockNumber(context.Background()); err != nil {
+			t.Fatalf("BlockNumber: unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := cli.BlockNumber(ctx)
+		if !errors.Is(err, ErrRateLimitBudget) {
+			t.Errorf("BlockNumber: expected %v, got %v", ErrRateLimitBudget, err)
+		}
+		if usage := cli.Usage()[0]; usage.Requests != 1 {
+			t.Errorf("expected the refused call to be refunded, got %+v", usage)
+		}
+	})
+
+	t.Run("call over the burst refunded", func(t *testing.T) {
+		cli := newTestEndpointsClient(ts.URL)
+		cli.endpoints[0].limiter = newTokenBucket(1, 5)
+
+		if _, err := cli.BlockNumber(context.Background()); err != nil {
+			t.Fatalf("BlockNumber: unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := cli.BlockNumber(ctx)
+		if !errors.Is(err, ErrRateLimitBudget) {
+			t.Errorf("BlockNumber: expected %v, got %v", ErrRateLimitBudget, err)
+		}
+		if usage := cli.Usage()[0]; usage.Requests != 1 || usage.Consumed != DefaultMethodCosts[EthBlockNumber] {
+			t.Errorf("expected the refused call to be refunded, got %+v", usage)
+		}
+	})
+
+	t.Run("batches are charged per call", func(t *testing.T) {
+		batchServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `[]`)
+		}))
+		defer batchServer.Close()
+		cli := newTestEndpointsClient(batchServer.URL)
+		cli.retryPolicy.maxAttempts = 1
+
+		cli.BatchCall(context.Background(), []BatchElem{{Method: EthBlockNumber}, {Method: EthGetBalance}})
+
+		if usage := cli.Usage()[0]; usage.Consumed != DefaultMethodCosts[EthBlockNumber]+DefaultMethodCosts[EthGetBalance] {
+			t.Errorf("unexpected usage %+v", usage)
+		}
+	})
+}
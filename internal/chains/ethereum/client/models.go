@@ -1,37 +1,61 @@
 package client
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
 
 type (
 	rpcRequest struct {
 		JSONRPC string        `json:"jsonrpc"`
 		Method  string        `json:"method"`
 		Params  []interface{} `json:"params"`
-		ID      int           `json:"id"`
+		ID      uint64        `json:"id"`
 	}
 
 	rpcResponse struct {
 		JSONRPC string          `json:"jsonrpc"`
-		ID      int             `json:"id"`
+		ID      uint64          `json:"id"`
 		Result  json.RawMessage `json:"result"`
-		Error   *rpcError       `json:"error,omitempty"`
-	}
-
-	rpcError struct {
-		Code    int    `json:"code"`
-		Message string `json:"message"`
+		Error   *RPCError       `json:"error,omitempty"`
 	}
 
 	blockResponse struct {
-		Number       string                `json:"number"`
+		Number       evm.BlockNumber       `json:"number"`
+		Timestamp    evm.Timestamp         `json:"timestamp"`
 		Transactions []TransactionResponse `json:"transactions"`
 	}
 
+	callRequest struct {
+		To   evm.Address `json:"to"`
+		Data string      `json:"data"`
+	}
+
+	traceConfig struct {
+		Tracer string `json:"tracer"`
+	}
+
 	TransactionResponse struct {
-		Hash        string `json:"hash"`
-		From        string `json:"from"`
-		To          string `json:"to"`
-		Value       string `json:"value"`
-		BlockNumber string `json:"blockNumber"`
+		Hash             string               `json:"hash"`
+		From             string               `json:"from"`
+		To               string               `json:"to"`
+		Value            evm.Quantity         `json:"value"`
+		Nonce            evm.Nonce            `json:"nonce"`
+		Input            string               `json:"input,omitempty"`
+		BlockNumber      evm.BlockNumber      `json:"blockNumber"`
+		TransactionIndex evm.TransactionIndex `json:"transactionIndex"`
+		// BlockTimestamp is copied from the block the transaction was read from, it is zero
+		// for transactions fetched on their own.
+		BlockTimestamp evm.Timestamp `json:"blockTimestamp,omitempty"`
+	}
+
+	ReceiptResponse struct {
+		TransactionHash   string          `json:"transactionHash"`
+		BlockNumber       evm.BlockNumber `json:"blockNumber"`
+		GasUsed           evm.Quantity    `json:"gasUsed"`
+		EffectiveGasPrice evm.Quantity    `json:"effectiveGasPrice"`
+		// Status is 0x1 on success and 0x0 on failure, pre-Byzantium receipts don't have it.
+		Status string `json:"status,omitempty"`
 	}
 )
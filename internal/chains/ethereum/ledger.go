@@ -0,0 +1,20 @@
+package ethereum
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+type Ledger interface {
+	// running native balance of a subscribed address and its last reconciliation
+	GetBalance(ctx context.Context, address evm.Address) (parser.Balance, error)
+
+	// compare every running balance against the node at the cursor height
+	Reconcile(ctx context.Context) error
+
+	// drop the running balance of an address, reopening it at the cursor height next time, once
+	// unsubscribed or missing evicted transactions
+	Forget(address evm.Address)
+}
@@ -0,0 +1,240 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+// account anchors a running balance to the on-chain balance at the opening block,
+// every stored transaction after it is applied on top.
+type account struct {
+	openingBlock   evm.BlockNumber
+	openingBalance evm.Quantity
+	reconciliation *parser.Reconciliation
+}
+
+type ledger struct {
+	ethClient client.Client
+	repo      ethereum.Repository
+	logger    *log.Logger
+
+	mu       sync.RWMutex
+	accounts map[evm.Address]*account
+}
+
+func NewLedger(ethClient client.Client, repo ethereum.Repository, logger *log.Logger) ethereum.Ledger {
+	return &ledger{
+		ethClient: ethClient,
+		repo:      repo,
+		logger:    logger,
+		accounts:  make(map[evm.Address]*account),
+	}
+}
+
+func (l *ledger) GetBalance(ctx context.Context, address evm.Address) (parser.Balance, error) {
+	acc, err := l.getAccount(ctx, address)
+	if err != nil {
+		return parser.Balance{}, err
+	}
+
+	cursor, err := l.repo.GetLastParsedBlock(ctx)
+	if err != nil {
+		l.logger.Printf("error retrieving the last parsed block: %v\n", err)
+		return parser.Balance{}, err
+	}
+	blockNumber := max(cursor, acc.openingBlock)
+
+	balance, err := l.balanceAt(ctx, acc, address, blockNumber)
+	if err != nil {
+		return parser.Balance{}, err
+	}
+
+	l.mu.RLock()
+	reconciliation := acc.reconciliation
+	l.mu.RUnlock()
+
+	return parser.Balance{
+		Address:        address,
+		Balance:        balance,
+		BlockNumber:    blockNumber,
+		Reconciliation: reconciliation,
+	}, nil
+}
+
+func (l *ledger) Reconcile(ctx context.Context) error {
+	cursor, err := l.repo.GetLastParsedBlock(ctx)
+	if err != nil {
+		l.logger.Printf("error retrieving the last parsed block: %v\n", err)
+		return err
+	}
+	if cursor == 0 {
+		return nil
+	}
+
+	subscribed, err := l.repo.GetAddresses(ctx)
+	if err != nil {
+		l.logger.Printf("error retrieving subscribed addresses: %v\n", err)
+		return err
+	}
+
+	var (
+		errs      []error
+		addresses []evm.Address
+		accounts  []*account
+	)
+	for _, address := range subscribed {
+		acc, err := l.getAccount(ctx, address)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if cursor < acc.openingBlock {
+			continue
+		}
+		addresses = append(addresses, address)
+		accounts = append(accounts, acc)
+	}
+	if len(addresses) == 0 {
+		return errors.Join(errs...)
+	}
+
+	onChainBalances, err := l.ethClient.GetBalances(ctx, addresses, cursor)
+	var failed client.BatchError
+	if err != nil && !errors.As(err, &failed) {
+		l.logger.Printf("error retrieving balances: %v\n", err)
+		return errors.Join(append(errs, err)...)
+	}
+	if err != nil {
+		l.logger.Printf("error retrieving some balances: %v\n", err)
+		errs = append(errs, err)
+	}
+
+	reconciledAt := time.Now().UTC()
+	for i, address := range addresses {
+		if _, ok := failed[i]; ok {
+			continue
+		}
+		if err := l.reconcile(ctx, address, accounts[i], cursor, onChainBalances[i], reconciledAt); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (l *ledger) reconcile(ctx context.Context, address evm.Address, acc *account, blockNumber evm.BlockNumber, onChain evm.Quantity, reconciledAt time.Time) error {
+	expected, err := l.balanceAt(ctx, acc, address, blockNumber)
+	if err != nil {
+		return err
+	}
+	reconciliation := &parser.Reconciliation{
+		BlockNumber:  blockNumber,
+		Expected:     expected,
+		OnChain:      onChain,
+		Discrepancy:  onChain.Sub(expected),
+		ReconciledAt: reconciledAt,
+	}
+	if reconciliation.HasDiscrepancy() {
+		l.logger.Printf("[WARN] balance discrepancy for %s at block %d: expected %s got %s\n", address, blockNumber, expected, onChain)
+	}
+
+	l.mu.Lock()
+	acc.reconciliation = reconciliation
+	l.mu.Unlock()
+	return nil
+}
+
+// Forget drops the account of the address, so a later subscription opens a new one rather
+// than missing the transactions of the blocks parsed in between, and evicted transactions
+// are left behind the opening block of the next one.
+func (l *ledger) Forget(address evm.Address) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.accounts, address)
+}
+
+// getAccount returns the account of address, opening it at the cursor height the first time.
+func (l *ledger) getAccount(ctx context.Context, address evm.Address) (*account, error) {
+	l.mu.RLock()
+	acc, ok := l.accounts[address]
+	l.mu.RUnlock()
+	if ok {
+		return acc, nil
+	}
+
+	openingBlock, err := l.repo.GetLastParsedBlock(ctx)
+	if err != nil {
+		l.logger.Printf("error retrieving the last parsed block: %v\n", err)
+		return nil, err
+	}
+	if openingBlock == 0 {
+		openingBlock, err = l.ethClient.BlockNumber(ctx)
+		if err != nil {
+			l.logger.Printf("error retrieving block number: %v\n", err)
+			return nil, err
+		}
+	}
+
+	openingBalance, err := l.ethClient.GetBalance(ctx, address, openingBlock)
+	if err != nil {
+		l.logger.Printf("error retrieving opening balance: %s: %v\n", address, err)
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if acc, ok := l.accounts[address]; ok {
+		return acc, nil
+	}
+	acc = &account{
+		openingBlock:   openingBlock,
+		openingBalance: openingBalance,
+	}
+	l.accounts[address] = acc
+	return acc, nil
+}
+
+// balanceAt applies the stored transactions in (openingBlock, blockNumber] to the opening balance.
+func (l *ledger) balanceAt(ctx context.Context, acc *account, address evm.Address, blockNumber evm.BlockNumber) (evm.Quantity, error) {
+	txs, err := l.repo.GetTransactions(ctx, address)
+	if err != nil {
+		l.logger.Printf("error retrieving transactions: %s: %v\n", address, err)
+		return evm.Quantity{}, err
+	}
+
+	balance := acc.openingBalance
+	for _, tx := range txs {
+		if tx.BlockNumber <= acc.openingBlock || tx.BlockNumber > blockNumber {
+			continue
+		}
+		balance = balance.Add(Delta(address, tx))
+	}
+	return balance, nil
+}
+
+// Delta is the change of the native balance of address caused by tx.
+// Failed transactions only cost their fee to the sender.
+func Delta(address evm.Address, tx parser.Transaction) evm.Quantity {
+	var delta evm.Quantity
+	succeeded := tx.Status != parser.TransactionStatusFailed
+
+	if tx.From == address {
+		delta = delta.Sub(tx.Fee)
+		if succeeded {
+			delta = delta.Sub(tx.Value)
+		}
+	}
+	if tx.To == address && succeeded {
+		delta = delta.Add(tx.Value)
+	}
+	return delta
+}
@@ -0,0 +1,207 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/ledger"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/test"
+	"github.com/jeronimobarea/transaction_parser/internal/test/ethereumtest"
+)
+
+const (
+	watched = evm.Address("0x1111111111111111111111111111111111111111")
+	other   = evm.Address("0x2222222222222222222222222222222222222222")
+)
+
+func TestDelta(t *testing.T) {
+	testCases := []struct {
+		name string
+		tx   parser.Transaction
+		want int64
+	}{
+		{
+			name: "inbound",
+			tx:   parser.Transaction{From: other, To: watched, Value: evm.QuantityFromUint64(100), Fee: evm.QuantityFromUint64(1)},
+			want: 100,
+		},
+		{
+			name: "outbound pays value and fee",
+			tx:   parser.Transaction{From: watched, To: other, Value: evm.QuantityFromUint64(100), Fee: evm.QuantityFromUint64(1)},
+			want: -101,
+		},
+		{
+			name: "failed outbound only pays fee",
+			tx:   parser.Transaction{From: watched, To: other, Value: evm.QuantityFromUint64(100), Fee: evm.QuantityFromUint64(1), Status: parser.TransactionStatusFailed},
+			want: -1,
+		},
+		{
+			name: "failed inbound",
+			tx:   parser.Transaction{From: other, To: watched, Value: evm.QuantityFromUint64(100), Status: parser.TransactionStatusFailed},
+			want: 0,
+		},
+		{
+			name: "self transfer only pays fee",
+			tx:   parser.Transaction{From: watched, To: watched, Value: evm.QuantityFromUint64(100), Fee: evm.QuantityFromUint64(1)},
+			want: -1,
+		},
+		{
+			name: "token transfer recipient",
+			tx:   parser.Transaction{From: other, To: other, Token: &parser.TokenTransfer{To: watched, Amount: evm.QuantityFromUint64(5)}},
+			want: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ledger.Delta(watched, tc.tx)
+			if got.Big().Int64() != tc.want {
+				t.Errorf("Delta: want %d, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLedger_GetBalance(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("opens at the node balance and applies later transactions", func(t *testing.T) {
+		repo := repository.NewMemoryStorage()
+		repo.AddAddress(ctx, watched)
+		// included in the opening balance
+		repo.SaveBlock(ctx, 10, map[evm.Address][]parser.Transaction{watched: {{Hash: "h1", From: other, To: watched, Value: evm.QuantityFromUint64(50), BlockNumber: 10}}})
+
+		fc := &ethereumtest.FakeClient{GetBalanceResp: map[evm.Address]evm.Quantity{watched: evm.QuantityFromUint64(1000)}}
+		l := ledger.NewLedger(fc, repo, log.Default())
+
+		got, err := l.GetBalance(ctx, watched)
+		if err != nil {
+			t.Fatalf("GetBalance: unexpected error: %v", err)
+		}
+		if got.Balance.String() != "1000" || got.BlockNumber != 10 {
+			t.Errorf("GetBalance: want 1000 at block 10, got %s at block %d", got.Balance, got.BlockNumber)
+		}
+
+		repo.SaveBlock(ctx, 11, map[evm.Address][]parser.Transaction{watched: {{Hash: "h2", From: watched, To: other, Value: evm.QuantityFromUint64(300), Fee: evm.QuantityFromUint64(21), BlockNumber: 11}}})
+		repo.SaveBlock(ctx, 12, map[evm.Address][]parser.Transaction{watched: {{Hash: "h3", From: other, To: watched, Value: evm.QuantityFromUint64(100), BlockNumber: 12}}})
+
+		got, err = l.GetBalance(ctx, watched)
+		if err != nil {
+			t.Fatalf("GetBalance: unexpected error: %v", err)
+		}
+		if got.Balance.String() != "779" || got.BlockNumber != 12 {
+			t.Errorf("GetBalance: want 779 at block 12, got %s at block %d", got.Balance, got.BlockNumber)
+		}
+		if got.Reconciliation != nil {
+			t.Errorf("GetBalance: expected no reconciliation yet, got %+v", got.Reconciliation)
+		}
+	})
+
+	t.Run("opens at the node head before anything is parsed", func(t *testing.T) {
+		repo := repository.NewMemoryStorage()
+		repo.AddAddress(ctx, watched)
+
+		fc := &ethereumtest.FakeClient{
+			BlockNumberResp: 500,
+			GetBalanceResp:  map[evm.Address]evm.Quantity{watched: evm.QuantityFromUint64(7)},
+		}
+		l := ledger.NewLedger(fc, repo, log.Default())
+
+		got, err := l.GetBalance(ctx, watched)
+		if err != nil {
+			t.Fatalf("GetBalance: unexpected error: %v", err)
+		}
+		if got.Balance.String() != "7" || got.BlockNumber != 500 {
+			t.Errorf("GetBalance: want 7 at block 500, got %s at block %d", got.Balance, got.BlockNumber)
+		}
+	})
+
+	t.Run("forgotten accounts reopen at the node balance", func(t *testing.T) {
+		repo := repository.NewMemoryStorage()
+		repo.AddAddress(ctx, watched)
+		repo.SaveBlock(ctx, 10, nil)
+
+		fc := &ethereumtest.FakeClient{GetBalanceResp: map[evm.Address]evm.Quantity{watched: evm.QuantityFromUint64(1000)}}
+		l := ledger.NewLedger(fc, repo, log.Default())
+		if _, err := l.GetBalance(ctx, watched); err != nil {
+			t.Fatalf("GetBalance: unexpected error: %v", err)
+		}
+
+		// the blocks parsed while unsubscribed changed the balance
+		repo.SaveBlock(ctx, 20, nil)
+		fc.GetBalanceResp[watched] = evm.QuantityFromUint64(400)
+		l.Forget(watched)
+
+		got, err := l.GetBalance(ctx, watched)
+		if err != nil {
+			t.Fatalf("GetBalance: unexpected error: %v", err)
+		}
+		if got.Balance.String() != "400" || got.BlockNumber != 20 {
+			t.Errorf("GetBalance: want 400 at block 20, got %s at block %d", got.Balance, got.BlockNumber)
+		}
+	})
+
+	t.Run("node error", func(t *testing.T) {
+		fc := &ethereumtest.FakeClient{GetBalanceErr: test.DummyErr}
+		l := ledger.NewLedger(fc, repository.NewMemoryStorage(), log.Default())
+
+		_, err := l.GetBalance(ctx, watched)
+		if !errors.Is(err, test.DummyErr) {
+			t.Errorf("GetBalance: expected %v, got %v", test.DummyErr, err)
+		}
+	})
+}
+
+func TestLedger_Reconcile(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repository.NewMemoryStorage()
+	repo.AddAddress(ctx, watched)
+	repo.SaveBlock(ctx, 10, map[evm.Address][]parser.Transaction{watched: {{Hash: "h1", From: other, To: watched, Value: evm.QuantityFromUint64(50), BlockNumber: 10}}})
+
+	fc := &ethereumtest.FakeClient{GetBalanceResp: map[evm.Address]evm.Quantity{watched: evm.QuantityFromUint64(1000)}}
+	l := ledger.NewLedger(fc, repo, log.Default())
+	reconciler := ledger.NewReconciler(l)
+
+	if err := reconciler.Poll(ctx); err != nil {
+		t.Fatalf("Reconcile: unexpected error: %v", err)
+	}
+
+	got, _ := l.GetBalance(ctx, watched)
+	if got.Reconciliation == nil || got.Reconciliation.HasDiscrepancy() {
+		t.Fatalf("Reconcile: expected a clean reconciliation, got %+v", got.Reconciliation)
+	}
+
+	t.Run("missed transfer", func(t *testing.T) {
+		repo.SaveBlock(ctx, 11, map[evm.Address][]parser.Transaction{watched: {{Hash: "h2", From: other, To: watched, Value: evm.QuantityFromUint64(100), BlockNumber: 11}}})
+		// the node also saw an untracked outbound transfer of 40
+		fc.GetBalanceResp[watched] = evm.QuantityFromUint64(1060)
+
+		if err := reconciler.Poll(ctx); err != nil {
+			t.Fatalf("Reconcile: unexpected error: %v", err)
+		}
+
+		got, _ := l.GetBalance(ctx, watched)
+		rec := got.Reconciliation
+		if rec == nil || !rec.HasDiscrepancy() {
+			t.Fatalf("Reconcile: expected a discrepancy, got %+v", rec)
+		}
+		if rec.BlockNumber != 11 || rec.Expected.String() != "1100" || rec.Discrepancy.String() != "-40" {
+			t.Errorf("Reconcile: want -40 discrepancy against 1100 at block 11, got %+v", rec)
+		}
+	})
+
+	t.Run("node error", func(t *testing.T) {
+		fc.GetBalanceErr = test.DummyErr
+		defer func() { fc.GetBalanceErr = nil }()
+
+		if err := reconciler.Poll(ctx); !errors.Is(err, test.DummyErr) {
+			t.Errorf("Reconcile: expected %v, got %v", test.DummyErr, err)
+		}
+	})
+}
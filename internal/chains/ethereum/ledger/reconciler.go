@@ -0,0 +1,23 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/pollers"
+)
+
+type reconciler struct {
+	ledger ethereum.Ledger
+}
+
+// NewReconciler adapts the ledger reconciliation to a poller so it can be scheduled with a runner.
+func NewReconciler(ledger ethereum.Ledger) pollers.Poller {
+	return &reconciler{
+		ledger: ledger,
+	}
+}
+
+func (r *reconciler) Poll(ctx context.Context) error {
+	return r.ledger.Reconcile(ctx)
+}
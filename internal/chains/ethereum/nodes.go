@@ -0,0 +1,10 @@
+package ethereum
+
+import (
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+)
+
+type NodeMonitor interface {
+	// rate limit budget and usage of every node endpoint, in the configured order
+	Usage() []parser.NodeUsage
+}
@@ -0,0 +1,16 @@
+package ethereum
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+type NonceTracker interface {
+	// nonce usage and status of the outbound transactions of a subscribed address
+	GetNonceReport(ctx context.Context, address evm.Address) (parser.NonceReport, error)
+
+	// record the pending outbound transactions of the subscribed addresses
+	Track(ctx context.Context) error
+}
@@ -0,0 +1,23 @@
+package nonces
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/pollers"
+)
+
+type pendingPoller struct {
+	tracker ethereum.NonceTracker
+}
+
+// NewPendingPoller adapts the pending transactions tracking to a poller so it can be scheduled with a runner.
+func NewPendingPoller(tracker ethereum.NonceTracker) pollers.Poller {
+	return &pendingPoller{
+		tracker: tracker,
+	}
+}
+
+func (p *pendingPoller) Poll(ctx context.Context) error {
+	return p.tracker.Track(ctx)
+}
@@ -0,0 +1,261 @@
+package nonces
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+const (
+	// reportWindow is how many nonces below the confirmed one are reported.
+	reportWindow = 100
+	// maxGaps bounds the gaps reported when nonces are far apart.
+	maxGaps = 1000
+	// pendingRetention is how long pending sightings are kept once seen.
+	pendingRetention = 24 * time.Hour
+)
+
+type pendingTx struct {
+	hash      string
+	firstSeen time.Time
+}
+
+// tracker follows the nonces of outbound transactions. Mined transactions come from the
+// repository and pending ones from sightings in the node pending block, a nonce seen with
+// more than one hash reveals a replacement.
+type tracker struct {
+	ethClient  client.Client
+	repo       ethereum.Repository
+	stuckAfter time.Duration
+	logger     *log.Logger
+
+	mu      sync.Mutex
+	pending map[evm.Address]map[evm.Nonce][]pendingTx
+}
+
+func NewTracker(ethClient client.Client, repo ethereum.Repository, stuckAfter time.Duration, logger *log.Logger) ethereum.NonceTracker {
+	return &tracker{
+		ethClient:  ethClient,
+		repo:       repo,
+		stuckAfter: stuckAfter,
+		logger:     logger,
+		pending:    make(map[evm.Address]map[evm.Nonce][]pendingTx),
+	}
+}
+
+func (t *tracker) Track(ctx context.Context) error {
+	// only the transactions sent by subscribed addresses are kept, the first repository
+	// error failing the track once the block is read
+	var subscribedErr error
+	txs, err := t.ethClient.FilterBlock(ctx, client.PendingBlock, func(tx *client.TransactionResponse) bool {
+		if subscribedErr != nil {
+			return false
+		}
+		subscribed, err := t.repo.HasAddress(ctx, evm.Address(tx.From))
+		if err != nil {
+			subscribedErr = err
+		}
+		return subscribed
+	})
+	if err != nil {
+		t.logger.Printf("error retrieving the pending block: %v\n", err)
+		return err
+	}
+	if subscribedErr != nil {
+		t.logger.Printf("error checking subscriptions: %v\n", subscribedErr)
+		return subscribedErr
+	}
+
+	now := time.Now().UTC()
+	for _, tx := range txs {
+		t.observe(evm.Address(tx.From), tx.Nonce, tx.Hash, now)
+	}
+	t.prune(now)
+	return nil
+}
+
+func (t *tracker) observe(address evm.Address, nonce evm.Nonce, hash string, seenAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	byNonce, ok := t.pending[address]
+	if !ok {
+		byNonce = make(map[evm.Nonce][]pendingTx)
+		t.pending[address] = byNonce
+	}
+
+	for _, seen := range byNonce[nonce] {
+		if seen.hash == hash {
+			return
+		}
+	}
+	byNonce[nonce] = append(byNonce[nonce], pendingTx{hash: hash, firstSeen: seenAt})
+	t.logger.Printf("[INFO] pending outbound transaction seen: %s nonce %d: %s\n", address, nonce, hash)
+}
+
+func (t *tracker) prune(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for address, byNonce := range t.pending {
+		for nonce, txs := range byNonce {
+			txs = slices.DeleteFunc(txs, func(tx pendingTx) bool {
+				return now.Sub(tx.firstSeen) > pendingRetention
+			})
+			if len(txs) == 0 {
+				delete(byNonce, nonce)
+				continue
+			}
+			byNonce[nonce] = txs
+		}
+		if len(byNonce) == 0 {
+			delete(t.pending, address)
+		}
+	}
+}
+
+func (t *tracker) GetNonceReport(ctx context.Context, address evm.Address) (parser.NonceReport, error) {
+	confirmedNonce, err := t.ethClient.GetTransactionCount(ctx, address, client.LatestBlock)
+	if err != nil {
+		t.logger.Printf("error retrieving confirmed nonce: %s: %v\n", address, err)
+		return parser.NonceReport{}, err
+	}
+
+	pendingNonce, err := t.ethClient.GetTransactionCount(ctx, address, client.PendingBlock)
+	if err != nil {
+		t.logger.Printf("error retrieving pending nonce: %s: %v\n", address, err)
+		return parser.NonceReport{}, err
+	}
+
+	windowStart := evm.Nonce(0)
+	if confirmedNonce > reportWindow {
+		windowStart = confirmedNonce - reportWindow
+	}
+
+	txs, err := t.repo.GetTransactions(ctx, address)
+	if err != nil {
+		t.logger.Printf("error retrieving transactions: %s: %v\n", address, err)
+		return parser.NonceReport{}, err
+	}
+
+	mined := make(map[evm.Nonce]parser.Transaction)
+	for _, tx := range txs {
+		if tx.From == address && tx.Nonce >= windowStart {
+			mined[tx.Nonce] = tx
+		}
+	}
+
+	report := parser.NonceReport{
+		Address:        address,
+		ConfirmedNonce: confirmedNonce,
+		PendingNonce:   pendingNonce,
+	}
+	for nonce, tx := range mined {
+		report.Transactions = append(report.Transactions, parser.NonceTransaction{
+			Nonce:       nonce,
+			Hash:        tx.Hash,
+			Status:      parser.NonceStatusMined,
+			BlockNumber: tx.BlockNumber,
+		})
+	}
+
+	pendingTxs, err := t.resolvePending(ctx, address, confirmedNonce, mined)
+	if err != nil {
+		return parser.NonceReport{}, err
+	}
+	report.Transactions = append(report.Transactions, pendingTxs...)
+
+	slices.SortFunc(report.Transactions, func(a, b parser.NonceTransaction) int {
+		return cmp.Or(cmp.Compare(a.Nonce, b.Nonce), a.FirstSeen.Compare(b.FirstSeen))
+	})
+	report.Gaps = gaps(report.Transactions, confirmedNonce)
+	return report, nil
+}
+
+// resolvePending classifies every pending sighting of address against the mined transactions.
+func (t *tracker) resolvePending(ctx context.Context, address evm.Address, confirmedNonce evm.Nonce, mined map[evm.Nonce]parser.Transaction) ([]parser.NonceTransaction, error) {
+	t.mu.Lock()
+	byNonce := make(map[evm.Nonce][]pendingTx, len(t.pending[address]))
+	for nonce, txs := range t.pending[address] {
+		byNonce[nonce] = slices.Clone(txs)
+	}
+	t.mu.Unlock()
+
+	var resolved []parser.NonceTransaction
+	for nonce, txs := range byNonce {
+		minedTx, isMined := mined[nonce]
+		latest := txs[len(txs)-1]
+
+		for _, tx := range txs {
+			entry := parser.NonceTransaction{
+				Nonce:     nonce,
+				Hash:      tx.hash,
+				FirstSeen: tx.firstSeen,
+			}
+
+			switch {
+			case isMined && tx.hash == minedTx.Hash:
+				continue
+			case isMined:
+				entry.Status = parser.NonceStatusReplaced
+				entry.ReplacedBy = minedTx.Hash
+			case nonce < confirmedNonce:
+				// the nonce was used by a transaction we didn't store, check if it was this one
+				receipt, err := t.ethClient.GetTransactionReceipt(ctx, tx.hash)
+				switch {
+				case err == nil:
+					entry.Status = parser.NonceStatusMined
+					entry.BlockNumber = receipt.BlockNumber
+				case errors.Is(err, client.ErrReceiptNotFound):
+					entry.Status = parser.NonceStatusReplaced
+				default:
+					t.logger.Printf("error retrieving receipt: %s: %v\n", tx.hash, err)
+					return nil, err
+				}
+			case tx.hash != latest.hash:
+				entry.Status = parser.NonceStatusReplaced
+				entry.ReplacedBy = latest.hash
+			case time.Since(tx.firstSeen) >= t.stuckAfter:
+				entry.Status = parser.NonceStatusStuck
+			default:
+				entry.Status = parser.NonceStatusPending
+			}
+			resolved = append(resolved, entry)
+		}
+	}
+	return resolved, nil
+}
+
+// gaps returns the nonces without any known transaction from the confirmed nonce up to the
+// highest known one. The nonces below the confirmed one are all mined, the ones the poller
+// missed included, and pending transactions above it with nothing at it are blocked, so the
+// confirmed nonce counts as a gap then.
+func gaps(txs []parser.NonceTransaction, confirmedNonce evm.Nonce) []evm.Nonce {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	known := make(map[evm.Nonce]struct{}, len(txs))
+	for _, tx := range txs {
+		known[tx.Nonce] = struct{}{}
+	}
+
+	highest := txs[len(txs)-1].Nonce
+
+	var missing []evm.Nonce
+	for nonce := confirmedNonce; nonce < highest && len(missing) < maxGaps; nonce++ {
+		if _, ok := known[nonce]; !ok {
+			missing = append(missing, nonce)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,188 @@
+package nonces_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/nonces"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/test"
+	"github.com/jeronimobarea/transaction_parser/internal/test/ethereumtest"
+)
+
+const (
+	wallet = evm.Address("0x1111111111111111111111111111111111111111")
+	other  = evm.Address("0x2222222222222222222222222222222222222222")
+)
+
+func pendingTx(hash string, nonce evm.Nonce) client.TransactionResponse {
+	return client.TransactionResponse{Hash: hash, From: string(wallet), To: string(other), Nonce: nonce}
+}
+
+func TestTracker_GetNonceReport(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repository.NewMemoryStorage()
+	repo.AddAddress(ctx, wallet)
+	// nonce 2 was mined in a block the poller missed, it is no gap below the confirmed nonce
+	repo.SaveBlock(ctx, 10, map[evm.Address][]parser.Transaction{wallet: {{Hash: "m0", From: wallet, To: other, Nonce: 0, BlockNumber: 10}}})
+	repo.SaveBlock(ctx, 11, map[evm.Address][]parser.Transaction{wallet: {{Hash: "m1", From: wallet, To: other, Nonce: 1, BlockNumber: 11}}})
+	repo.SaveBlock(ctx, 13, map[evm.Address][]parser.Transaction{wallet: {
+		{Hash: "m3", From: wallet, To: other, Nonce: 3, BlockNumber: 13},
+		{Hash: "in", From: other, To: wallet, Nonce: 7, BlockNumber: 13},
+	}})
+
+	fc := &ethereumtest.FakeClient{
+		GetBlockPendingResp: []client.TransactionResponse{
+			pendingTx("r3", 3),
+			pendingTx("p5", 5),
+			pendingTx("p6", 6),
+			{Hash: "foreign", From: string(other), Nonce: 4},
+		},
+		GetTxCountResp: map[string]evm.Nonce{client.LatestBlock: 4, client.PendingBlock: 4},
+	}
+
+	tracker := nonces.NewTracker(fc, repo, time.Hour, log.Default())
+	if err := nonces.NewPendingPoller(tracker).Poll(ctx); err != nil {
+		t.Fatalf("Track: unexpected error: %v", err)
+	}
+
+	// p6 gets a fee bump
+	fc.GetBlockPendingResp = []client.TransactionResponse{pendingTx("p6-bump", 6)}
+	if err := tracker.Track(ctx); err != nil {
+		t.Fatalf("Track: unexpected error: %v", err)
+	}
+
+	report, err := tracker.GetNonceReport(ctx, wallet)
+	if err != nil {
+		t.Fatalf("GetNonceReport: unexpected error: %v", err)
+	}
+
+	type summary struct {
+		nonce      evm.Nonce
+		hash       string
+		status     parser.NonceStatus
+		replacedBy string
+	}
+	var got []summary
+	for _, tx := range report.Transactions {
+		got = append(got, summary{tx.Nonce, tx.Hash, tx.Status, tx.ReplacedBy})
+	}
+
+	want := []summary{
+		{0, "m0", parser.NonceStatusMined, ""},
+		{1, "m1", parser.NonceStatusMined, ""},
+		{3, "m3", parser.NonceStatusMined, ""},
+		{3, "r3", parser.NonceStatusReplaced, "m3"},
+		{5, "p5", parser.NonceStatusPending, ""},
+		{6, "p6", parser.NonceStatusReplaced, "p6-bump"},
+		{6, "p6-bump", parser.NonceStatusPending, ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNonceReport transactions:\n got %+v\nwant %+v", got, want)
+	}
+
+	if wantGaps := []evm.Nonce{4}; !reflect.DeepEqual(report.Gaps, wantGaps) {
+		t.Errorf("GetNonceReport gaps: want %v, got %v", wantGaps, report.Gaps)
+	}
+	if report.ConfirmedNonce != 4 || report.HasStuck() {
+		t.Errorf("GetNonceReport: unexpected report %+v", report)
+	}
+}
+
+func TestTracker_GetNonceReport_MissedMinedNonces(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repository.NewMemoryStorage()
+	repo.AddAddress(ctx, wallet)
+	// nonces 1 and 2 were mined before the address was subscribed
+	repo.SaveBlock(ctx, 10, map[evm.Address][]parser.Transaction{wallet: {{Hash: "m0", From: wallet, To: other, Nonce: 0, BlockNumber: 10}}})
+	repo.SaveBlock(ctx, 20, map[evm.Address][]parser.Transaction{wallet: {{Hash: "m3", From: wallet, To: other, Nonce: 3, BlockNumber: 20}}})
+
+	fc := &ethereumtest.FakeClient{
+		GetBlockPendingResp: []client.TransactionResponse{pendingTx("p4", 4)},
+		GetTxCountResp:      map[string]evm.Nonce{client.LatestBlock: 4, client.PendingBlock: 5},
+	}
+
+	tracker := nonces.NewTracker(fc, repo, time.Hour, log.Default())
+	if err := tracker.Track(ctx); err != nil {
+		t.Fatalf("Track: unexpected error: %v", err)
+	}
+
+	report, err := tracker.GetNonceReport(ctx, wallet)
+	if err != nil {
+		t.Fatalf("GetNonceReport: unexpected error: %v", err)
+	}
+	if len(report.Gaps) != 0 {
+		t.Errorf("GetNonceReport gaps: expected none, got %v", report.Gaps)
+	}
+}
+
+func TestTracker_StuckAndDropped(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repository.NewMemoryStorage()
+	repo.AddAddress(ctx, wallet)
+
+	fc := &ethereumtest.FakeClient{
+		GetBlockPendingResp: []client.TransactionResponse{pendingTx("dropped", 0), pendingTx("mined", 1), pendingTx("stuck", 2)},
+		GetTxCountResp:      map[string]evm.Nonce{client.LatestBlock: 2, client.PendingBlock: 3},
+		GetReceiptResp:      map[string]*client.ReceiptResponse{"mined": {TransactionHash: "mined", BlockNumber: 20, Status: "0x1"}},
+		GetReceiptNotFound:  true,
+	}
+
+	tracker := nonces.NewTracker(fc, repo, 0, log.Default())
+	if err := tracker.Track(ctx); err != nil {
+		t.Fatalf("Track: unexpected error: %v", err)
+	}
+
+	report, err := tracker.GetNonceReport(ctx, wallet)
+	if err != nil {
+		t.Fatalf("GetNonceReport: unexpected error: %v", err)
+	}
+
+	statuses := make(map[string]parser.NonceStatus)
+	for _, tx := range report.Transactions {
+		statuses[tx.Hash] = tx.Status
+	}
+	want := map[string]parser.NonceStatus{
+		"dropped": parser.NonceStatusReplaced,
+		"mined":   parser.NonceStatusMined,
+		"stuck":   parser.NonceStatusStuck,
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("GetNonceReport statuses: want %v, got %v", want, statuses)
+	}
+	if !report.HasStuck() {
+		t.Errorf("GetNonceReport: expected a stuck transaction")
+	}
+}
+
+func TestTracker_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("pending block", func(t *testing.T) {
+		fc := &ethereumtest.FakeClient{GetBlockErr: test.DummyErr}
+		tracker := nonces.NewTracker(fc, repository.NewMemoryStorage(), time.Hour, log.Default())
+
+		if err := tracker.Track(ctx); !errors.Is(err, test.DummyErr) {
+			t.Errorf("Track: expected %v, got %v", test.DummyErr, err)
+		}
+	})
+
+	t.Run("transaction count", func(t *testing.T) {
+		fc := &ethereumtest.FakeClient{GetTxCountErr: test.DummyErr}
+		tracker := nonces.NewTracker(fc, repository.NewMemoryStorage(), time.Hour, log.Default())
+
+		if _, err := tracker.GetNonceReport(ctx, wallet); !errors.Is(err, test.DummyErr) {
+			t.Errorf("GetNonceReport: expected %v, got %v", test.DummyErr, err)
+		}
+	})
+}
@@ -2,9 +2,9 @@ package ethereum
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"strconv"
 
 	"github.com/jeronimobarea/transaction_parser/internal/parser"
 	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
@@ -17,59 +17,217 @@ var (
 )
 
 type ethereumParser struct {
-	repo   Repository
-	logger *log.Logger
+	repo        Repository
+	pricer      Pricer
+	ledger      Ledger
+	nonces      NonceTracker
+	broadcaster Broadcaster
+	compactor   Compactor
+	nodes       NodeMonitor
+	logger      *log.Logger
 }
 
-func NewEthereumParser(repo Repository, logger *log.Logger) parser.Parser {
+// NewEthereumParser creates the ethereum parser, pricer is optional and
+// transactions are returned without fiat values when it is nil. compactor is nil
+// when no retention policy is configured.
+func NewEthereumParser(
+	repo Repository,
+	pricer Pricer,
+	ledger Ledger,
+	nonces NonceTracker,
+	broadcaster Broadcaster,
+	compactor Compactor,
+	nodes NodeMonitor,
+	logger *log.Logger,
+) parser.Parser {
 	return &ethereumParser{
-		repo:   repo,
-		logger: logger,
+		repo:        repo,
+		pricer:      pricer,
+		ledger:      ledger,
+		nonces:      nonces,
+		broadcaster: broadcaster,
+		compactor:   compactor,
+		nodes:       nodes,
+		logger:      logger,
 	}
 }
 
-func (p *ethereumParser) GetCurrentBlock(_ context.Context) (int64, error) {
-	hexValue := p.repo.GetLastParsedBlock()
-	return hexToDecimal(hexValue)
+func (p *ethereumParser) GetCurrentBlock(ctx context.Context) (int64, error) {
+	blockNumber, err := p.repo.GetLastParsedBlock(ctx)
+	if err != nil {
+		p.logger.Printf("error retrieving the last parsed block: %v\n", err)
+		return 0, err
+	}
+	return int64(blockNumber), nil
 }
 
-func hexToDecimal(hexValue string) (int64, error) {
-	if len(hexValue) < 2 || hexValue[0:2] != "0x" {
-		return -1, fmt.Errorf("unexpected result format: %s", hexValue)
+func (p *ethereumParser) GetTransactions(ctx context.Context, address string, query parser.TransactionQuery) (parser.TransactionPage, error) {
+	addr := evm.Address(address)
+	if err := addr.Validate(); err != nil {
+		p.logger.Printf("error validating address: %v\n", err)
+		return parser.TransactionPage{}, err
 	}
 
-	hexValue = hexValue[2:]
-	value, err := strconv.ParseInt(hexValue, 16, 64)
+	query, err := query.Normalize()
 	if err != nil {
-		return -1, fmt.Errorf("failed to parse hex %q: %v", hexValue, err)
+		p.logger.Printf("error validating transaction query: %v\n", err)
+		return parser.TransactionPage{}, err
+	}
+
+	if err := p.checkSubscribed(ctx, addr); err != nil {
+		return parser.TransactionPage{}, err
+	}
+
+	page, err := p.repo.QueryTransactions(ctx, addr, query)
+	if err != nil {
+		p.logger.Printf("error retrieving transactions: %s: %v\n", addr, err)
+		return parser.TransactionPage{}, err
+	}
+	page.Transactions = p.withFiatValues(ctx, page.Transactions)
+	return page, nil
+}
+
+// checkSubscribed returns ErrAddressNotSubscribed unless the address is subscribed.
+func (p *ethereumParser) checkSubscribed(ctx context.Context, addr evm.Address) error {
+	subscribed, err := p.repo.HasAddress(ctx, addr)
+	if err != nil {
+		p.logger.Printf("error checking subscription: %s: %v\n", addr, err)
+		return err
+	}
+	if !subscribed {
+		return ErrAddressNotSubscribed
+	}
+	return nil
+}
+
+// pricedAt identifies a price lookup, an asset priced at a block.
+type pricedAt struct {
+	asset       evm.Address
+	blockNumber evm.BlockNumber
+}
+
+// withFiatValues returns a copy of txs with the fiat value of their native and token transfers.
+// Pricing is best effort, a transfer is left without value when its price can't be resolved.
+// The pricer caches the prices it looked up, while a lookup that failed is remembered for the
+// request so the other transfers of the asset at that block don't repeat its calls.
+func (p *ethereumParser) withFiatValues(ctx context.Context, txs []parser.Transaction) []parser.Transaction {
+	if p.pricer == nil || len(txs) == 0 {
+		return txs
+	}
+
+	unpriced := make(map[pricedAt]struct{})
+	value := func(asset evm.Address, amount evm.Quantity, blockNumber evm.BlockNumber) *parser.FiatValue {
+		key := pricedAt{asset: asset, blockNumber: blockNumber}
+		if _, ok := unpriced[key]; ok {
+			return nil
+		}
+
+		fiat, err := p.pricer.Value(ctx, asset, amount, blockNumber)
+		if err != nil {
+			if !errors.Is(err, ErrNoPriceFeed) {
+				p.logger.Printf("error pricing %s at block %d: %v\n", asset, blockNumber, err)
+			}
+			unpriced[key] = struct{}{}
+			return nil
+		}
+		return fiat
+	}
+
+	valued := make([]parser.Transaction, len(txs))
+	for i, tx := range txs {
+		tx.Fiat = value(NativeAsset, tx.Value, tx.BlockNumber)
+
+		if tx.Token != nil {
+			token := *tx.Token
+			token.Fiat = value(token.Contract, token.Amount, tx.BlockNumber)
+			tx.Token = &token
+		}
+		valued[i] = tx
+	}
+	return valued
+}
+
+func (p *ethereumParser) GetBalance(ctx context.Context, address string) (parser.Balance, error) {
+	addr := evm.Address(address)
+	if err := addr.Validate(); err != nil {
+		p.logger.Printf("error validating address: %v\n", err)
+		return parser.Balance{}, err
 	}
 
-	return value, nil
+	if err := p.checkSubscribed(ctx, addr); err != nil {
+		return parser.Balance{}, err
+	}
+	return p.ledger.GetBalance(ctx, addr)
 }
 
-func (p *ethereumParser) GetTransactions(ctx context.Context, address string) ([]parser.Transaction, error) {
+func (p *ethereumParser) GetNonces(ctx context.Context, address string) (parser.NonceReport, error) {
 	addr := evm.Address(address)
 	if err := addr.Validate(); err != nil {
 		p.logger.Printf("error validating address: %v\n", err)
-		return nil, err
+		return parser.NonceReport{}, err
 	}
 
-	if !p.repo.HasAddress(addr) {
-		return nil, ErrAddressNotSubscribed
+	if err := p.checkSubscribed(ctx, addr); err != nil {
+		return parser.NonceReport{}, err
 	}
-	return p.repo.GetTransactions(addr), nil
+	return p.nonces.GetNonceReport(ctx, addr)
+}
+
+// Broadcast submits a signed transaction, the broadcaster subscribing its sender
+// so the transaction shows up in its transactions once mined.
+func (p *ethereumParser) Broadcast(ctx context.Context, rawTx string) (parser.BroadcastTransaction, error) {
+	return p.broadcaster.Broadcast(ctx, rawTx)
 }
 
-func (p *ethereumParser) Subscribe(_ context.Context, address string) error {
+func (p *ethereumParser) GetBroadcast(ctx context.Context, hash string) (parser.BroadcastTransaction, error) {
+	return p.broadcaster.GetBroadcast(ctx, hash)
+}
+
+func (p *ethereumParser) GetCompactionMetrics(_ context.Context) (parser.CompactionMetrics, error) {
+	if p.compactor == nil {
+		return parser.CompactionMetrics{}, ErrRetentionDisabled
+	}
+	return p.compactor.Metrics(), nil
+}
+
+func (p *ethereumParser) GetNodeUsage(_ context.Context) ([]parser.NodeUsage, error) {
+	return p.nodes.Usage(), nil
+}
+
+func (p *ethereumParser) Subscribe(ctx context.Context, address string) error {
 	addr := evm.Address(address)
 	if err := addr.Validate(); err != nil {
 		p.logger.Printf("error validating address: %v\n", err)
 		return err
 	}
 
-	if p.repo.HasAddress(addr) {
+	subscribed, err := p.repo.HasAddress(ctx, addr)
+	if err != nil {
+		p.logger.Printf("error checking subscription: %s: %v\n", addr, err)
+		return err
+	}
+	if subscribed {
 		return ErrAddressConflict
 	}
 
-	return p.repo.AddAddress(addr)
+	return p.repo.AddAddress(ctx, addr)
+}
+
+// Unsubscribe stops watching the address, the poller no longer matching it from the next
+// block on, and forgets its running balance.
+func (p *ethereumParser) Unsubscribe(ctx context.Context, address string, purge bool) error {
+	addr := evm.Address(address)
+	if err := addr.Validate(); err != nil {
+		p.logger.Printf("error validating address: %v\n", err)
+		return err
+	}
+
+	err := p.repo.RemoveAddress(ctx, addr, purge)
+	if err != nil {
+		p.logger.Printf("error unsubscribing address: %s: %v\n", addr, err)
+		return err
+	}
+
+	p.ledger.Forget(addr)
+	return nil
 }
@@ -2,75 +2,18 @@ package ethereum
 
 import (
 	"context"
+	"errors"
 	"log"
 	"reflect"
-	"strings"
 	"testing"
 
 	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/test"
 	"github.com/jeronimobarea/transaction_parser/internal/test/ethereumtest"
 	"github.com/jeronimobarea/transaction_parser/internal/test/evmtest"
 )
 
-func TestHexToDecimal(t *testing.T) {
-	testCases := []struct {
-		name     string
-		hexValue string
-		want     int64
-		errMsg   string
-	}{
-		{
-			name:     "happy path",
-			hexValue: "0x4b7",
-			want:     1207,
-		},
-		{
-			name:     "happy path zero value",
-			hexValue: "0x0",
-			want:     0,
-		},
-		{
-			name:     "missing 0x prefix",
-			hexValue: "4b7",
-			want:     -1,
-			errMsg:   "unexpected result format",
-		},
-		{
-			name:     "proper prefix wrong format",
-			hexValue: "0xZZZ",
-			want:     -1,
-			errMsg:   "failed to parse hex",
-		},
-		{
-			name:     "prefix only",
-			hexValue: "0x",
-			want:     -1,
-			errMsg:   "failed to parse hex",
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			got, err := hexToDecimal(tc.hexValue)
-			if tc.errMsg != "" {
-				if err == nil {
-					t.Errorf("hexToDecimal(%q): expected error, got none", tc.hexValue)
-				}
-				if !strings.Contains(err.Error(), tc.errMsg) {
-					t.Errorf("hexToDecimal(%q): error %q does not contain %q", tc.hexValue, err.Error(), tc.errMsg)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("hexToDecimal(%q): unexpected error: %v", tc.hexValue, err)
-				}
-				if got != tc.want {
-					t.Errorf("hexToDecimal(%q): want %d, got %d", tc.hexValue, tc.want, got)
-				}
-			}
-		})
-	}
-}
-
 func TestParser_GetCurrentBlock(t *testing.T) {
 	var (
 		ctx = context.Background()
@@ -81,9 +24,9 @@ func TestParser_GetCurrentBlock(t *testing.T) {
 	t.Run("happy path", func(t *testing.T) {
 		var (
 			repo = &ethereumtest.FakeRepo{
-				GetLastParsedBlockResp: "0x1",
+				GetLastParsedBlockResp: 1,
 			}
-			p = NewEthereumParser(repo, logger)
+			p = NewEthereumParser(repo, nil, nil, nil, nil, nil, nil, logger)
 		)
 
 		got, err := p.GetCurrentBlock(ctx)
@@ -97,23 +40,17 @@ func TestParser_GetCurrentBlock(t *testing.T) {
 		}
 	})
 
-	t.Run("error parsing latest block number", func(t *testing.T) {
+	t.Run("repository error", func(t *testing.T) {
 		var (
-			ctx = context.Background()
-
 			repo = &ethereumtest.FakeRepo{
-				GetLastParsedBlockResp: "invalid",
+				GetLastParsedBlockErr: test.DummyErr,
 			}
-
-			p = NewEthereumParser(repo, logger)
+			p = NewEthereumParser(repo, nil, nil, nil, nil, nil, nil, logger)
 		)
 
-		got, err := p.GetCurrentBlock(ctx)
-		if err == nil {
-			t.Fatal("GetCurrentBlock: expected error, got none")
-		}
-		if got != -1 {
-			t.Errorf("GetCurrentBlock on error: want -1, got %d", got)
+		_, err := p.GetCurrentBlock(ctx)
+		if !errors.Is(err, test.DummyErr) {
+			t.Errorf("GetCurrentBlock: expected %v, got %v", test.DummyErr, err)
 		}
 	})
 }
@@ -125,20 +62,23 @@ func TestParser_GetTransactions(t *testing.T) {
 		var (
 			ctx = context.Background()
 
-			want = []parser.Transaction{
-				{Hash: "h1", From: evmtest.EVMZeroValueAddress, To: "0x0000000000000000000000000000000000000001", Value: "10", BlockNumber: "0x2"},
-				{Hash: "h2", From: "0x0000000000000000000000000000000000000001", To: evmtest.EVMZeroValueAddress, Value: "20", BlockNumber: "0x2"},
+			want = parser.TransactionPage{
+				Transactions: []parser.Transaction{
+					{Hash: "h1", From: evmtest.EVMZeroValueAddress, To: "0x0000000000000000000000000000000000000001", Value: evm.QuantityFromUint64(10), BlockNumber: 2},
+					{Hash: "h2", From: "0x0000000000000000000000000000000000000001", To: evmtest.EVMZeroValueAddress, Value: evm.QuantityFromUint64(20), BlockNumber: 2},
+				},
+				NextCursor: "next",
 			}
 
 			repo = &ethereumtest.FakeRepo{
-				GetTransactionsResp: want,
-				HasAddressResp:      true,
+				QueryTransactionsResp: want,
+				HasAddressResp:        true,
 			}
 
-			p = NewEthereumParser(repo, logger)
+			p = NewEthereumParser(repo, nil, nil, nil, nil, nil, nil, logger)
 		)
 
-		got, err := p.GetTransactions(ctx, evmtest.EVMZeroValueAddress.String())
+		got, err := p.GetTransactions(ctx, evmtest.EVMZeroValueAddress.String(), parser.TransactionQuery{})
 		if err != nil {
 			t.Fatalf("GetTransactions: unexpected error: %v", err)
 		}
@@ -148,18 +88,322 @@ func TestParser_GetTransactions(t *testing.T) {
 		}
 	})
 
+	t.Run("with fiat values", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			stored = []parser.Transaction{
+				{Hash: "h1", From: evmtest.EVMZeroValueAddress, Value: evm.QuantityFromUint64(10), BlockNumber: 2},
+				{Hash: "h2", From: evmtest.EVMZeroValueAddress, BlockNumber: 2, Token: &parser.TokenTransfer{Amount: evm.QuantityFromUint64(5)}},
+			}
+			fiat = &parser.FiatValue{Currency: "USD", Amount: "1.00"}
+
+			repo = &ethereumtest.FakeRepo{
+				QueryTransactionsResp: parser.TransactionPage{Transactions: stored},
+				HasAddressResp:        true,
+			}
+
+			p = NewEthereumParser(repo, &ethereumtest.FakePricer{ValueResp: fiat}, nil, nil, nil, nil, nil, logger)
+		)
+
+		got, err := p.GetTransactions(ctx, evmtest.EVMZeroValueAddress.String(), parser.TransactionQuery{})
+		if err != nil {
+			t.Fatalf("GetTransactions: unexpected error: %v", err)
+		}
+
+		if got := got.Transactions; got[0].Fiat != fiat || got[1].Fiat != fiat || got[1].Token.Fiat != fiat {
+			t.Errorf("GetTransactions: expected fiat values on native and token transfers, got %+v", got)
+		}
+		if stored[0].Fiat != nil || stored[1].Token.Fiat != nil {
+			t.Errorf("GetTransactions: stored transactions must not be modified")
+		}
+	})
+
+	t.Run("failed lookups are not repeated", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			token  = evm.Address("0x1111111111111111111111111111111111111111")
+			stored = []parser.Transaction{
+				{Hash: "h1", BlockNumber: 2, Token: &parser.TokenTransfer{Contract: token, Amount: evm.QuantityFromUint64(5)}},
+				{Hash: "h2", BlockNumber: 2, Token: &parser.TokenTransfer{Contract: token, Amount: evm.QuantityFromUint64(7)}},
+				{Hash: "h3", BlockNumber: 3, Token: &parser.TokenTransfer{Contract: token, Amount: evm.QuantityFromUint64(9)}},
+			}
+
+			repo = &ethereumtest.FakeRepo{
+				QueryTransactionsResp: parser.TransactionPage{Transactions: stored},
+				HasAddressResp:        true,
+			}
+			pricer = &ethereumtest.FakePricer{ValueErr: test.DummyErr}
+
+			p = NewEthereumParser(repo, pricer, nil, nil, nil, nil, nil, logger)
+		)
+
+		if _, err := p.GetTransactions(ctx, evmtest.EVMZeroValueAddress.String(), parser.TransactionQuery{}); err != nil {
+			t.Fatalf("GetTransactions: unexpected error: %v", err)
+		}
+		// the native and token lookups once per block
+		if pricer.ValueCalls != 4 {
+			t.Errorf("GetTransactions: expected 4 price lookups, got %d", pricer.ValueCalls)
+		}
+	})
+
+	t.Run("pricing errors are not fatal", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			repo = &ethereumtest.FakeRepo{
+				QueryTransactionsResp: parser.TransactionPage{Transactions: []parser.Transaction{{Hash: "h1", BlockNumber: 2}}},
+				HasAddressResp:        true,
+			}
+
+			p = NewEthereumParser(repo, &ethereumtest.FakePricer{ValueErr: ErrNoPriceFeed}, nil, nil, nil, nil, nil, logger)
+		)
+
+		got, err := p.GetTransactions(ctx, evmtest.EVMZeroValueAddress.String(), parser.TransactionQuery{})
+		if err != nil {
+			t.Fatalf("GetTransactions: unexpected error: %v", err)
+		}
+		if len(got.Transactions) != 1 || got.Transactions[0].Fiat != nil {
+			t.Errorf("GetTransactions: expected transaction without fiat value, got %+v", got)
+		}
+	})
+
 	t.Run("invalid address", func(t *testing.T) {
 		var (
 			ctx = context.Background()
 
 			repo = &ethereumtest.FakeRepo{}
 
-			p = NewEthereumParser(repo, logger)
+			p = NewEthereumParser(repo, nil, nil, nil, nil, nil, nil, logger)
 		)
 
-		_, err := p.GetTransactions(ctx, "not-an-address")
+		_, err := p.GetTransactions(ctx, "not-an-address", parser.TransactionQuery{})
 		if err == nil {
 			t.Fatal("GetTransactions with invalid address: expected error, got none")
 		}
 	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			repo = &ethereumtest.FakeRepo{HasAddressResp: true}
+
+			p = NewEthereumParser(repo, nil, nil, nil, nil, nil, nil, logger)
+		)
+
+		_, err := p.GetTransactions(ctx, evmtest.EVMZeroValueAddress.String(), parser.TransactionQuery{Limit: parser.MaxPageSize + 1})
+		if !errors.Is(err, parser.ErrInvalidPageSize) {
+			t.Errorf("GetTransactions: expected %v, got %v", parser.ErrInvalidPageSize, err)
+		}
+	})
+}
+
+func TestParser_GetTransactions_RepositoryErrors(t *testing.T) {
+	logger := log.Default()
+
+	testCases := []struct {
+		name string
+		repo *ethereumtest.FakeRepo
+	}{
+		{
+			name: "checking the subscription",
+			repo: &ethereumtest.FakeRepo{HasAddressErr: test.DummyErr},
+		},
+		{
+			name: "retrieving the transactions",
+			repo: &ethereumtest.FakeRepo{HasAddressResp: true, QueryTransactionsErr: test.DummyErr},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewEthereumParser(tc.repo, nil, nil, nil, nil, nil, nil, logger)
+
+			_, err := p.GetTransactions(context.Background(), evmtest.EVMZeroValueAddress.String(), parser.TransactionQuery{})
+			if !errors.Is(err, test.DummyErr) {
+				t.Errorf("GetTransactions: expected %v, got %v", test.DummyErr, err)
+			}
+		})
+	}
+}
+
+func TestParser_GetBalance(t *testing.T) {
+	logger := log.Default()
+
+	t.Run("not subscribed", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			repo = &ethereumtest.FakeRepo{HasAddressResp: false}
+
+			p = NewEthereumParser(repo, nil, nil, nil, nil, nil, nil, logger)
+		)
+
+		_, err := p.GetBalance(ctx, evmtest.EVMZeroValueAddress.String())
+		if !errors.Is(err, ErrAddressNotSubscribed) {
+			t.Errorf("GetBalance: expected %v, got %v", ErrAddressNotSubscribed, err)
+		}
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			p = NewEthereumParser(&ethereumtest.FakeRepo{}, nil, nil, nil, nil, nil, nil, logger)
+		)
+
+		_, err := p.GetBalance(ctx, "not-an-address")
+		if !errors.Is(err, evm.ErrInvalidAddress) {
+			t.Errorf("GetBalance: expected %v, got %v", evm.ErrInvalidAddress, err)
+		}
+	})
+}
+
+func TestParser_Unsubscribe(t *testing.T) {
+	logger := log.Default()
+
+	testCases := []struct {
+		name       string
+		address    string
+		repo       *ethereumtest.FakeRepo
+		wantErr    error
+		wantForget bool
+	}{
+		{
+			name:       "happy path",
+			address:    evmtest.EVMZeroValueAddress.String(),
+			repo:       &ethereumtest.FakeRepo{},
+			wantForget: true,
+		},
+		{
+			name:    "invalid address",
+			address: "not-an-address",
+			repo:    &ethereumtest.FakeRepo{},
+			wantErr: evm.ErrInvalidAddress,
+		},
+		{
+			name:    "not subscribed",
+			address: evmtest.EVMZeroValueAddress.String(),
+			repo:    &ethereumtest.FakeRepo{RemoveAddressErr: ErrAddressNotSubscribed},
+			wantErr: ErrAddressNotSubscribed,
+		},
+		{
+			name:    "repository error",
+			address: evmtest.EVMZeroValueAddress.String(),
+			repo:    &ethereumtest.FakeRepo{RemoveAddressErr: test.DummyErr},
+			wantErr: test.DummyErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				ledger = &ethereumtest.FakeLedger{}
+
+				p = NewEthereumParser(tc.repo, nil, ledger, nil, nil, nil, nil, logger)
+			)
+
+			err := p.Unsubscribe(context.Background(), tc.address, true)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Unsubscribe(%q): expected error %v, got %v", tc.address, tc.wantErr, err)
+			}
+
+			forgotten := len(ledger.Forgotten) == 1 && ledger.Forgotten[0] == evm.Address(tc.address)
+			if forgotten != tc.wantForget {
+				t.Errorf("Unsubscribe(%q): expected the balance forgotten %t, got %v", tc.address, tc.wantForget, ledger.Forgotten)
+			}
+		})
+	}
+}
+
+func TestParser_Broadcast(t *testing.T) {
+	logger := log.Default()
+
+	t.Run("happy path", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			broadcaster = &ethereumtest.FakeBroadcaster{
+				BroadcastResp: parser.BroadcastTransaction{Hash: "h1", From: evmtest.EVMZeroValueAddress, Status: parser.BroadcastStatusPending},
+			}
+
+			p = NewEthereumParser(&ethereumtest.FakeRepo{}, nil, nil, nil, broadcaster, nil, nil, logger)
+		)
+
+		got, err := p.Broadcast(ctx, "0x01")
+		if err != nil {
+			t.Fatalf("Broadcast: unexpected error: %v", err)
+		}
+		if got.Hash != "h1" {
+			t.Errorf("Broadcast: want h1, got %+v", got)
+		}
+	})
+
+	t.Run("broadcast error", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			p = NewEthereumParser(&ethereumtest.FakeRepo{}, nil, nil, nil, &ethereumtest.FakeBroadcaster{BroadcastErr: ErrInvalidRawTransaction}, nil, nil, logger)
+		)
+
+		if _, err := p.Broadcast(ctx, "nope"); !errors.Is(err, ErrInvalidRawTransaction) {
+			t.Errorf("Broadcast: expected %v, got %v", ErrInvalidRawTransaction, err)
+		}
+	})
+}
+
+func TestParser_GetCompactionMetrics(t *testing.T) {
+	logger := log.Default()
+
+	t.Run("happy path", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			metrics   = parser.CompactionMetrics{Runs: 2, EvictedByAge: 3, RetainedBytes: 1024}
+			compactor = &ethereumtest.FakeCompactor{MetricsResp: metrics}
+
+			p = NewEthereumParser(&ethereumtest.FakeRepo{}, nil, nil, nil, nil, compactor, nil, logger)
+		)
+
+		got, err := p.GetCompactionMetrics(ctx)
+		if err != nil {
+			t.Fatalf("GetCompactionMetrics: unexpected error: %v", err)
+		}
+		if got != metrics {
+			t.Errorf("GetCompactionMetrics: want %+v, got %+v", metrics, got)
+		}
+	})
+
+	t.Run("no retention policy", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			p = NewEthereumParser(&ethereumtest.FakeRepo{}, nil, nil, nil, nil, nil, nil, logger)
+		)
+
+		if _, err := p.GetCompactionMetrics(ctx); !errors.Is(err, ErrRetentionDisabled) {
+			t.Errorf("GetCompactionMetrics: expected %v, got %v", ErrRetentionDisabled, err)
+		}
+	})
+}
+
+func TestParser_GetNodeUsage(t *testing.T) {
+	var (
+		ctx = context.Background()
+
+		usages = []parser.NodeUsage{{Endpoint: "node-1", Rate: 100, Burst: 100, Consumed: 40, Requests: 4}}
+		nodes  = &ethereumtest.FakeNodeMonitor{UsageResp: usages}
+
+		p = NewEthereumParser(&ethereumtest.FakeRepo{}, nil, nil, nil, nil, nil, nodes, log.Default())
+	)
+
+	got, err := p.GetNodeUsage(ctx)
+	if err != nil {
+		t.Fatalf("GetNodeUsage: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, usages) {
+		t.Errorf("GetNodeUsage: want %+v, got %+v", usages, got)
+	}
 }
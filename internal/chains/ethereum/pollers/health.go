@@ -0,0 +1,64 @@
+package pollers
+
+import (
+	"context"
+	"log"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+)
+
+type healthPoller struct {
+	ethClient client.Client
+	logger    *log.Logger
+}
+
+// NewHealthPoller adapts the node endpoints health checks to a poller so it can be scheduled
+// with a runner, logging the rate limit budget of each endpoint along the way.
+func NewHealthPoller(ethClient client.Client, logger *log.Logger) Poller {
+	return &healthPoller{
+		ethClient: ethClient,
+		logger:    logger,
+	}
+}
+
+func (p *healthPoller) Poll(ctx context.Context) error {
+	err := p.ethClient.CheckHealth(ctx)
+
+	for _, usage := range p.ethClient.Usage() {
+		p.logger.Printf(
+			"[INFO] rpc endpoint %s usage: %.0f units in %d requests, %.0f/%.0f available, throttled for %s\n",
+			usage.Endpoint, usage.Consumed, usage.Requests, usage.Available, usage.Burst, usage.Throttled,
+		)
+	}
+	return err
+}
+
+type nodeMonitor struct {
+	ethClient client.Client
+}
+
+// NewNodeMonitor reports the rate limit budget of the node endpoints, the one logged by the
+// health poller.
+func NewNodeMonitor(ethClient client.Client) ethereum.NodeMonitor {
+	return &nodeMonitor{ethClient: ethClient}
+}
+
+func (m *nodeMonitor) Usage() []parser.NodeUsage {
+	usages := m.ethClient.Usage()
+
+	nodeUsages := make([]parser.NodeUsage, len(usages))
+	for i, usage := range usages {
+		nodeUsages[i] = parser.NodeUsage{
+			Endpoint:  usage.Endpoint,
+			Rate:      usage.Rate,
+			Burst:     usage.Burst,
+			Available: usage.Available,
+			Consumed:  usage.Consumed,
+			Requests:  usage.Requests,
+			Throttled: usage.Throttled,
+		}
+	}
+	return nodeUsages
+}
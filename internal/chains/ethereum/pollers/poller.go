@@ -2,6 +2,7 @@ package pollers
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
@@ -28,42 +29,167 @@ func NewPoller(ethClient client.Client, repo ethereum.Repository, logger *log.Lo
 	}
 }
 
+// Poll processes the head block, committing its matches along with the cursor so an empty
+// block moves the cursor too, and a failed poll leaves nothing behind. The head is processed
+// again until the next block is mined, picking up the block replacing it on a reorg. A head
+// the node doesn't know yet, answered by an endpoint lagging the one reporting it, fails the
+// poll so the next one retries it rather than saving it empty.
 func (p *poller) Poll(ctx context.Context) error {
-	txs, err := p.ethClient.GetBlock(ctx, client.LatestBlock)
+	head, err := p.ethClient.BlockNumber(ctx)
 	if err != nil {
-		p.logger.Printf("error retrieving the latest block: %v\n", err)
+		p.logger.Printf("error retrieving block number: %v\n", err)
+		return err
+	}
+
+	// only the transactions of subscribed addresses are decoded out of the block, the first
+	// repository error failing the poll once the block is read. The matches are kept by hash,
+	// a retried call filtering the block again.
+	var (
+		found    = make(map[string][]match)
+		matchErr error
+	)
+	txs, err := p.ethClient.FilterBlock(ctx, head.Hex(), func(tx *client.TransactionResponse) bool {
+		if matchErr != nil {
+			return false
+		}
+		matches, err := p.match(ctx, newTransaction(*tx))
+		if err != nil {
+			matchErr = err
+			return false
+		}
+		if len(matches) == 0 {
+			return false
+		}
+		found[tx.Hash] = matches
+		return true
+	})
+	if err == nil {
+		err = matchErr
+	}
+	if err != nil {
+		p.logger.Printf("error retrieving block %d: %v\n", head, err)
 		return err
 	}
 	p.logger.Printf("[DEBUG] Latest block info: %+v\n", txs)
 
-	for _, tx := range txs {
-		var (
-			from = evm.Address(tx.From)
-			to   = evm.Address(tx.To)
-		)
-
-		if p.repo.HasAddress(from) {
-			p.repo.SaveTransaction(from, parser.Transaction{
-				Hash:        tx.Hash,
-				From:        from,
-				To:          to,
-				Value:       tx.Value,
-				BlockNumber: tx.BlockNumber,
-			})
-			p.logger.Printf("[INFO] new outbound transaction saved: %+v\n", tx)
-			continue
+	var (
+		matched = make([]parser.Transaction, len(txs))
+		matches = make([][]match, len(txs))
+	)
+	for i, tx := range txs {
+		matched[i] = newTransaction(tx)
+		matches[i] = found[tx.Hash]
+	}
+
+	err = p.withReceipts(ctx, matched)
+	if err != nil {
+		p.logger.Printf("error retrieving receipts: %v\n", err)
+		return err
+	}
+
+	saved := make(map[evm.Address][]parser.Transaction)
+	for i, transaction := range matched {
+		for _, m := range matches[i] {
+			saved[m.address] = append(saved[m.address], transaction)
 		}
+	}
 
-		if p.repo.HasAddress(to) {
-			p.repo.SaveTransaction(to, parser.Transaction{
-				Hash:        tx.Hash,
-				From:        from,
-				To:          to,
-				Value:       tx.Value,
-				BlockNumber: tx.BlockNumber,
-			})
-			p.logger.Printf("[INFO] new inbound transaction saved: %+v\n", tx)
+	err = p.repo.SaveBlock(ctx, head, saved)
+	if err != nil {
+		p.logger.Printf("error saving block %d: %v\n", head, err)
+		return err
+	}
+
+	for i, transaction := range matched {
+		for _, m := range matches[i] {
+			p.logger.Printf("[INFO] new %s saved: %+v\n", m.kind, transaction)
 		}
 	}
 	return nil
 }
+
+type match struct {
+	address evm.Address
+	kind    string
+}
+
+// match returns every subscribed address involved in the transaction,
+// a transfer between two subscribed addresses is saved for both.
+func (p *poller) match(ctx context.Context, tx parser.Transaction) ([]match, error) {
+	candidates := []match{{address: tx.From, kind: "outbound transaction"}}
+	if tx.To != tx.From {
+		candidates = append(candidates, match{address: tx.To, kind: "inbound transaction"})
+	}
+	if tx.Token != nil && tx.Token.To != tx.From && tx.Token.To != tx.To {
+		candidates = append(candidates, match{address: tx.Token.To, kind: "inbound token transfer"})
+	}
+
+	var matches []match
+	for _, candidate := range candidates {
+		subscribed, err := p.repo.HasAddress(ctx, candidate.address)
+		if err != nil {
+			return nil, err
+		}
+		if subscribed {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches, nil
+}
+
+// withReceipts sets the execution status and fee of the transactions from their receipts,
+// fetched in a single batch.
+func (p *poller) withReceipts(ctx context.Context, txs []parser.Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	hashes := make([]string, len(txs))
+	for i := range txs {
+		hashes[i] = txs[i].Hash
+	}
+
+	receipts, err := p.ethClient.GetTransactionReceipts(ctx, hashes)
+	if err != nil {
+		return err
+	}
+
+	for i, receipt := range receipts {
+		if receipt == nil {
+			return fmt.Errorf("%w: %s", client.ErrReceiptNotFound, hashes[i])
+		}
+
+		txs[i].Fee = receipt.GasUsed.Mul(receipt.EffectiveGasPrice)
+		switch receipt.Status {
+		case "0x1":
+			txs[i].Status = parser.TransactionStatusSuccess
+		case "0x0":
+			txs[i].Status = parser.TransactionStatusFailed
+		}
+	}
+	return nil
+}
+
+func newTransaction(tx client.TransactionResponse) parser.Transaction {
+	transaction := parser.Transaction{
+		Hash:             tx.Hash,
+		From:             evm.Address(tx.From),
+		To:               evm.Address(tx.To),
+		Value:            tx.Value,
+		BlockNumber:      tx.BlockNumber,
+		TransactionIndex: tx.TransactionIndex,
+		Nonce:            tx.Nonce,
+	}
+	if tx.BlockTimestamp != 0 {
+		transaction.Timestamp = tx.BlockTimestamp.Time()
+	}
+
+	if recipient, amount, ok := evm.DecodeERC20Transfer(tx.Input); ok {
+		transaction.Token = &parser.TokenTransfer{
+			Contract: transaction.To,
+			To:       recipient,
+			Amount:   amount,
+		}
+	}
+	return transaction
+}
@@ -5,15 +5,24 @@ import (
 	"errors"
 	"log"
 	"testing"
+	"time"
 
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
 	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/pollers"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
 	"github.com/jeronimobarea/transaction_parser/internal/test"
 	"github.com/jeronimobarea/transaction_parser/internal/test/ethereumtest"
+	"github.com/jeronimobarea/transaction_parser/internal/test/evmtest"
+	"github.com/jeronimobarea/transaction_parser/internal/test/rpctest"
 )
 
 func TestPoller_ErrorGettingBlock(t *testing.T) {
 	fc := &ethereumtest.FakeClient{
-		GetBlockErr: test.DummyErr,
+		BlockNumberResp: 1,
+		GetBlockErr:     test.DummyErr,
 	}
 	fr := ethereumtest.FakeRepo{HasAddressResp: false}
 	p := pollers.NewPoller(fc, fr, log.Default())
@@ -23,3 +32,287 @@ func TestPoller_ErrorGettingBlock(t *testing.T) {
 		t.Fatalf("Poll() error = %v; want rpc failure", err)
 	}
 }
+
+func TestPoller_TokenTransferRecipient(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		sender    = evm.Address("0x1111111111111111111111111111111111111111")
+		token     = evm.Address("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+		recipient = evm.Address("0xabcdefabcdefabcdefabcdefabcdefabcdefabcd")
+	)
+
+	fc := &ethereumtest.FakeClient{
+		BlockNumberResp: 1,
+		GetBlockResp: []client.TransactionResponse{{
+			Hash:        "h1",
+			From:        string(sender),
+			To:          string(token),
+			Input:       "0xa9059cbb000000000000000000000000abcdefabcdefabcdefabcdefabcdefabcdefabcd00000000000000000000000000000000000000000000000000000000000f4240",
+			BlockNumber: 1,
+		}},
+	}
+	repo := repository.NewMemoryStorage()
+	if err := repo.AddAddress(ctx, recipient); err != nil {
+		t.Fatalf("AddAddress: unexpected error: %v", err)
+	}
+
+	p := pollers.NewPoller(fc, repo, log.Default())
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("Poll: unexpected error: %v", err)
+	}
+
+	txs, _ := repo.GetTransactions(ctx, recipient)
+	if len(txs) != 1 {
+		t.Fatalf("GetTransactions(%q): expected the token transfer, got %+v", recipient, txs)
+	}
+	if txs[0].Token == nil || txs[0].Token.Contract != token || txs[0].Token.Amount.String() != "1000000" {
+		t.Errorf("GetTransactions(%q): unexpected token transfer %+v", recipient, txs[0].Token)
+	}
+}
+
+// countingRepo counts the subscription lookups made to the repository.
+type countingRepo struct {
+	ethereum.Repository
+	lookups int
+}
+
+func (r *countingRepo) HasAddress(ctx context.Context, address evm.Address) (bool, error) {
+	r.lookups++
+	return r.Repository.HasAddress(ctx, address)
+}
+
+func TestPoller_MatchesOnce(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		sender    = evm.Address("0x1111111111111111111111111111111111111111")
+		recipient = evm.Address("0x2222222222222222222222222222222222222222")
+	)
+
+	fc := &ethereumtest.FakeClient{
+		BlockNumberResp: 1,
+		GetBlockResp: []client.TransactionResponse{
+			{Hash: "h1", From: string(sender), To: string(recipient), BlockNumber: 1},
+			{Hash: "h2", From: string(recipient), To: string(recipient), BlockNumber: 1},
+		},
+	}
+	repo := &countingRepo{Repository: repository.NewMemoryStorage()}
+	if err := repo.AddAddress(ctx, sender); err != nil {
+		t.Fatalf("AddAddress: unexpected error: %v", err)
+	}
+
+	p := pollers.NewPoller(fc, repo, log.Default())
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("Poll: unexpected error: %v", err)
+	}
+
+	if txs, _ := repo.GetTransactions(ctx, sender); len(txs) != 1 || txs[0].Hash != "h1" {
+		t.Errorf("GetTransactions(%q): expected h1, got %+v", sender, txs)
+	}
+	// the sender and recipient of h1, the single address of h2
+	if repo.lookups != 3 {
+		t.Errorf("Poll: expected 3 subscription lookups, got %d", repo.lookups)
+	}
+}
+
+func TestPoller_SavesReceiptDetails(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		sender    = evm.Address("0x1111111111111111111111111111111111111111")
+		recipient = evm.Address("0x2222222222222222222222222222222222222222")
+	)
+
+	fc := &ethereumtest.FakeClient{
+		BlockNumberResp: 1,
+		GetBlockResp: []client.TransactionResponse{
+			{Hash: "h1", From: string(sender), To: string(recipient), Value: evm.QuantityFromUint64(100), BlockNumber: 1, BlockTimestamp: 1_700_000_000},
+			{Hash: "h2", From: "0x3333333333333333333333333333333333333333", To: string(sender), BlockNumber: 1},
+		},
+		GetReceiptResp: map[string]*client.ReceiptResponse{
+			"h1": {TransactionHash: "h1", Status: "0x0", GasUsed: evm.QuantityFromUint64(21000), EffectiveGasPrice: evm.QuantityFromUint64(2)},
+		},
+	}
+	repo := repository.NewMemoryStorage()
+	repo.AddAddress(ctx, sender)
+	repo.AddAddress(ctx, recipient)
+
+	p := pollers.NewPoller(fc, repo, log.Default())
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("Poll: unexpected error: %v", err)
+	}
+
+	senderTxs, _ := repo.GetTransactions(ctx, sender)
+	if len(senderTxs) != 2 {
+		t.Fatalf("GetTransactions(%q): expected outbound and inbound transactions, got %+v", sender, senderTxs)
+	}
+	if senderTxs[0].Status != parser.TransactionStatusFailed || senderTxs[0].Fee.String() != "42000" {
+		t.Errorf("GetTransactions(%q): expected failed transaction with 42000 fee, got %+v", sender, senderTxs[0])
+	}
+	if !senderTxs[0].Timestamp.Equal(time.Unix(1_700_000_000, 0)) {
+		t.Errorf("GetTransactions(%q): expected the block time, got %s", sender, senderTxs[0].Timestamp)
+	}
+	if senderTxs[1].Status != parser.TransactionStatusSuccess {
+		t.Errorf("GetTransactions(%q): expected successful transaction, got %+v", sender, senderTxs[1])
+	}
+
+	if recipientTxs, _ := repo.GetTransactions(ctx, recipient); len(recipientTxs) != 1 || recipientTxs[0].Hash != "h1" {
+		t.Errorf("GetTransactions(%q): expected the transfer between subscribed addresses, got %+v", recipient, recipientTxs)
+	}
+}
+
+func TestPoller_ErrorGettingReceipt(t *testing.T) {
+	fc := &ethereumtest.FakeClient{
+		BlockNumberResp: 1,
+		GetBlockResp:    []client.TransactionResponse{{Hash: "h1", From: evmtest.EVMZeroValueAddress.String(), BlockNumber: 1}},
+		GetReceiptErr:   test.DummyErr,
+	}
+	fr := ethereumtest.FakeRepo{HasAddressResp: true}
+	p := pollers.NewPoller(fc, fr, log.Default())
+
+	err := p.Poll(context.Background())
+	if !errors.Is(err, test.DummyErr) {
+		t.Fatalf("Poll() error = %v; want receipt failure", err)
+	}
+}
+
+func TestPoller_RepositoryErrors(t *testing.T) {
+	fc := &ethereumtest.FakeClient{
+		BlockNumberResp: 1,
+		GetBlockResp:    []client.TransactionResponse{{Hash: "h1", From: evmtest.EVMZeroValueAddress.String(), BlockNumber: 1}},
+		GetReceiptResp: map[string]*client.ReceiptResponse{
+			"h1": {TransactionHash: "h1", Status: "0x1"},
+		},
+	}
+
+	testCases := []struct {
+		name string
+		repo ethereumtest.FakeRepo
+	}{
+		{
+			name: "matching subscribed addresses",
+			repo: ethereumtest.FakeRepo{HasAddressErr: test.DummyErr},
+		},
+		{
+			name: "saving the block",
+			repo: ethereumtest.FakeRepo{HasAddressResp: true, SaveBlockErr: test.DummyErr},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := pollers.NewPoller(fc, tc.repo, log.Default())
+
+			err := p.Poll(context.Background())
+			if !errors.Is(err, test.DummyErr) {
+				t.Errorf("Poll() error = %v; want repository failure", err)
+			}
+		})
+	}
+}
+
+func TestPoller_Cursor(t *testing.T) {
+	const subscribed = evm.Address("0x1111111111111111111111111111111111111111")
+
+	ctx := context.Background()
+
+	t.Run("moves on empty blocks", func(t *testing.T) {
+		repo := repository.NewMemoryStorage()
+		repo.AddAddress(ctx, subscribed)
+
+		fc := &ethereumtest.FakeClient{BlockNumberResp: 7}
+		if err := pollers.NewPoller(fc, repo, log.Default()).Poll(ctx); err != nil {
+			t.Fatalf("Poll: unexpected error: %v", err)
+		}
+
+		if got, _ := repo.GetLastParsedBlock(ctx); got != 7 {
+			t.Errorf("GetLastParsedBlock: want 7, got %d", got)
+		}
+	})
+
+	t.Run("unknown block is retried", func(t *testing.T) {
+		repo := repository.NewMemoryStorage()
+		repo.AddAddress(ctx, subscribed)
+
+		fc := &ethereumtest.FakeClient{BlockNumberResp: 7, GetBlockErr: client.ErrBlockNotFound}
+		p := pollers.NewPoller(fc, repo, log.Default())
+		if err := p.Poll(ctx); !errors.Is(err, client.ErrBlockNotFound) {
+			t.Fatalf("Poll: expected %v, got %v", client.ErrBlockNotFound, err)
+		}
+		if got, _ := repo.GetLastParsedBlock(ctx); got != 0 {
+			t.Errorf("GetLastParsedBlock: expected the cursor to stay at 0, got %d", got)
+		}
+
+		fc.GetBlockErr = nil
+		fc.GetBlockResp = []client.TransactionResponse{{Hash: "h1", From: string(subscribed), BlockNumber: 7}}
+		fc.GetReceiptResp = map[string]*client.ReceiptResponse{"h1": {TransactionHash: "h1", BlockNumber: 7, Status: "0x1"}}
+		if err := p.Poll(ctx); err != nil {
+			t.Fatalf("Poll: unexpected error: %v", err)
+		}
+		if txs, _ := repo.GetTransactions(ctx, subscribed); len(txs) != 1 {
+			t.Errorf("GetTransactions(%q): expected the transaction of the retried block, got %+v", subscribed, txs)
+		}
+	})
+
+	t.Run("failed block leaves nothing behind", func(t *testing.T) {
+		repo := repository.NewMemoryStorage()
+		repo.AddAddress(ctx, subscribed)
+
+		fc := &ethereumtest.FakeClient{
+			BlockNumberResp: 7,
+			GetBlockResp:    []client.TransactionResponse{{Hash: "h1", From: string(subscribed), BlockNumber: 7}},
+			GetReceiptErr:   test.DummyErr,
+		}
+		if err := pollers.NewPoller(fc, repo, log.Default()).Poll(ctx); !errors.Is(err, test.DummyErr) {
+			t.Fatalf("Poll: expected %v, got %v", test.DummyErr, err)
+		}
+
+		if got, _ := repo.GetLastParsedBlock(ctx); got != 0 {
+			t.Errorf("GetLastParsedBlock: want 0, got %d", got)
+		}
+		if txs, _ := repo.GetTransactions(ctx, subscribed); len(txs) != 0 {
+			t.Errorf("GetTransactions(%q): expected nothing saved, got %+v", subscribed, txs)
+		}
+	})
+}
+
+// TestPoller_Replay polls a latest block served from a cassette, rerecord it from a node
+// with RPC_RECORD_URL and adjust the expectations to the new block.
+func TestPoller_Replay(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		recipient      = evm.Address("0x388c818ca8b9251b393131c08a736a67ccb19297")
+		tokenRecipient = evm.Address("0xf977814e90da44bfa03b6295a0616a897441acec")
+	)
+
+	repo := repository.NewMemoryStorage()
+	repo.AddAddress(ctx, recipient)
+	repo.AddAddress(ctx, tokenRecipient)
+
+	p := pollers.NewPoller(rpctest.NewClient(t, "testdata/latest_block.json"), repo, log.Default())
+	if err := p.Poll(ctx); err != nil {
+		t.Fatalf("Poll: unexpected error: %v", err)
+	}
+
+	txs, _ := repo.GetTransactions(ctx, recipient)
+	if len(txs) != 1 {
+		t.Fatalf("GetTransactions(%q): expected the inbound transfer, got %+v", recipient, txs)
+	}
+	if txs[0].Value.String() != "200000000000000000" || txs[0].Fee.String() != "21000000000000" || txs[0].Status != parser.TransactionStatusSuccess {
+		t.Errorf("GetTransactions(%q): unexpected transfer %+v", recipient, txs[0])
+	}
+
+	txs, _ = repo.GetTransactions(ctx, tokenRecipient)
+	if len(txs) != 1 || txs[0].Token == nil {
+		t.Fatalf("GetTransactions(%q): expected the token transfer, got %+v", tokenRecipient, txs)
+	}
+	if txs[0].Token.Amount.String() != "10000000000" || txs[0].Fee.String() != "923140000000000" {
+		t.Errorf("GetTransactions(%q): unexpected token transfer %+v", tokenRecipient, txs[0])
+	}
+
+	if got, _ := repo.GetLastParsedBlock(ctx); got != 22170158 {
+		t.Errorf("GetLastParsedBlock: want 22170158, got %d", got)
+	}
+}
@@ -7,14 +7,18 @@ import (
 )
 
 type runner struct {
-	logger   *log.Logger
-	pollRate time.Duration
+	logger      *log.Logger
+	pollRate    time.Duration
+	pollTimeout time.Duration
 }
 
-func NewRunner(logger *log.Logger, pollRate time.Duration) *runner {
+// NewRunner polls every pollRate, each poll being cancelled once it runs for pollTimeout so
+// a hung node can't block the next ones.
+func NewRunner(logger *log.Logger, pollRate, pollTimeout time.Duration) *runner {
 	return &runner{
-		logger:   logger,
-		pollRate: pollRate,
+		logger:      logger,
+		pollRate:    pollRate,
+		pollTimeout: pollTimeout,
 	}
 }
 
@@ -27,9 +31,9 @@ func (r *runner) Run(ctx context.Context, p Poller) error {
 			r.logger.Println("poller stopped")
 			return ctx.Err()
 		case <-ticker.C:
-			runnerCtx := context.Background()
-
-			err := p.Poll(runnerCtx)
+			pollCtx, cancel := context.WithTimeout(ctx, r.pollTimeout)
+			err := p.Poll(pollCtx)
+			cancel()
 			if err != nil {
 				r.logger.Printf("error polling: %v\n", err)
 			}
@@ -0,0 +1,42 @@
+package pollers_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/pollers"
+)
+
+// hungPoller blocks until its poll is cancelled, reporting why the first time.
+type hungPoller struct {
+	done chan error
+}
+
+func (p hungPoller) Poll(ctx context.Context) error {
+	<-ctx.Done()
+	select {
+	case p.done <- ctx.Err():
+	default:
+	}
+	return ctx.Err()
+}
+
+func TestRunner_PollTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := hungPoller{done: make(chan error, 1)}
+	go pollers.NewRunner(log.Default(), time.Millisecond, 10*time.Millisecond).Run(ctx, p)
+
+	select {
+	case err := <-p.done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Poll: expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Poll: expected the hung poll to time out")
+	}
+}
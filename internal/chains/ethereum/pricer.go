@@ -0,0 +1,19 @@
+package ethereum
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+var ErrNoPriceFeed = errors.New("error no price feed for asset")
+
+// NativeAsset identifies ether when pricing, following the Chainlink feed registry convention.
+const NativeAsset = evm.Address("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+
+type Pricer interface {
+	// fiat value of an amount of asset at the given block
+	Value(ctx context.Context, asset evm.Address, amount evm.Quantity, blockNumber evm.BlockNumber) (*parser.FiatValue, error)
+}
@@ -0,0 +1,56 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+// NativeFeedKey is the asset name used for ether in feed configurations.
+const NativeFeedKey = "native"
+
+// DefaultFeeds are the Chainlink mainnet USD aggregators for ether, USDC and USDT.
+const DefaultFeeds = "native=0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419," +
+	"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48=0x8fFfFfd4AfB6115b954Bd326cbe7B4BA576818f6," +
+	"0xdAC17F958D2ee523a2206206994597C13D831ec7=0x3E7d1eAB13ad0104d2750B8863b489D65364e32D"
+
+type Feed struct {
+	Asset      evm.Address
+	Aggregator evm.Address
+}
+
+// ParseFeeds parses a comma separated list of asset=aggregator pairs,
+// where the asset is a token contract address or "native" for ether.
+func ParseFeeds(feeds string) ([]Feed, error) {
+	var parsed []Feed
+	for _, entry := range strings.Split(feeds, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		asset, aggregator, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid price feed %q: expected asset=aggregator", entry)
+		}
+
+		feed := Feed{
+			Asset:      evm.Address(strings.TrimSpace(asset)),
+			Aggregator: evm.Address(strings.TrimSpace(aggregator)),
+		}
+		if feed.Asset == NativeFeedKey {
+			feed.Asset = ethereum.NativeAsset
+		}
+
+		if err := feed.Asset.Validate(); err != nil {
+			return nil, err
+		}
+		if err := feed.Aggregator.Validate(); err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, feed)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,184 @@
+package pricing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+const (
+	decimalsSelector        = "313ce567"
+	latestRoundDataSelector = "feaf968c"
+
+	nativeDecimals = 18
+	abiWordSize    = 32
+
+	// maxCachedPrices bounds the per block price cache, it is reset once full.
+	maxCachedPrices = 10_000
+)
+
+var ErrInvalidPrice = errors.New("error invalid price")
+
+type priceKey struct {
+	aggregator  evm.Address
+	blockNumber evm.BlockNumber
+}
+
+// pricer values transfers with Chainlink style aggregators, read through eth_call
+// at the block the transfer happened.
+type pricer struct {
+	ethClient client.Client
+	currency  string
+	feeds     map[evm.Address]evm.Address
+	logger    *log.Logger
+
+	mu       sync.Mutex
+	decimals map[evm.Address]uint8
+	prices   map[priceKey]*big.Int
+}
+
+func NewPricer(ethClient client.Client, currency string, feeds []Feed, logger *log.Logger) ethereum.Pricer {
+	feedsByAsset := make(map[evm.Address]evm.Address, len(feeds))
+	for _, feed := range feeds {
+		feedsByAsset[normalize(feed.Asset)] = normalize(feed.Aggregator)
+	}
+
+	return &pricer{
+		ethClient: ethClient,
+		currency:  currency,
+		feeds:     feedsByAsset,
+		logger:    logger,
+		decimals:  make(map[evm.Address]uint8),
+		prices:    make(map[priceKey]*big.Int),
+	}
+}
+
+func (p *pricer) Value(ctx context.Context, asset evm.Address, amount evm.Quantity, blockNumber evm.BlockNumber) (*parser.FiatValue, error) {
+	aggregator, ok := p.feeds[normalize(asset)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ethereum.ErrNoPriceFeed, asset)
+	}
+
+	feedDecimals, err := p.getDecimals(ctx, aggregator, blockNumber)
+	if err != nil {
+		p.logger.Printf("error retrieving feed decimals: %s: %v\n", aggregator, err)
+		return nil, err
+	}
+
+	if amount.IsZero() {
+		return &parser.FiatValue{Currency: p.currency, Amount: new(big.Rat).FloatString(int(feedDecimals))}, nil
+	}
+
+	assetDecimals := uint8(nativeDecimals)
+	if normalize(asset) != normalize(ethereum.NativeAsset) {
+		assetDecimals, err = p.getDecimals(ctx, normalize(asset), blockNumber)
+		if err != nil {
+			p.logger.Printf("error retrieving token decimals: %s: %v\n", asset, err)
+			return nil, err
+		}
+	}
+
+	price, err := p.getPrice(ctx, aggregator, blockNumber)
+	if err != nil {
+		p.logger.Printf("error retrieving price: %s at block %d: %v\n", aggregator, blockNumber, err)
+		return nil, err
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(assetDecimals)+int64(feedDecimals)), nil)
+	value := new(big.Rat).SetFrac(new(big.Int).Mul(amount.Big(), price), scale)
+
+	return &parser.FiatValue{
+		Currency: p.currency,
+		Amount:   value.FloatString(int(feedDecimals)),
+	}, nil
+}
+
+func (p *pricer) getDecimals(ctx context.Context, contract evm.Address, blockNumber evm.BlockNumber) (uint8, error) {
+	p.mu.Lock()
+	decimals, ok := p.decimals[contract]
+	p.mu.Unlock()
+	if ok {
+		return decimals, nil
+	}
+
+	resp, err := p.call(ctx, contract, decimalsSelector, blockNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	value := new(big.Int).SetBytes(resp[:abiWordSize])
+	if !value.IsUint64() || value.Uint64() > 77 {
+		return 0, fmt.Errorf("%w: decimals %s", ErrInvalidPrice, value)
+	}
+	decimals = uint8(value.Uint64())
+
+	p.mu.Lock()
+	p.decimals[contract] = decimals
+	p.mu.Unlock()
+	return decimals, nil
+}
+
+func (p *pricer) getPrice(ctx context.Context, aggregator evm.Address, blockNumber evm.BlockNumber) (*big.Int, error) {
+	key := priceKey{aggregator: aggregator, blockNumber: blockNumber}
+
+	p.mu.Lock()
+	price, ok := p.prices[key]
+	p.mu.Unlock()
+	if ok {
+		return price, nil
+	}
+
+	// latestRoundData() returns (roundId, answer, startedAt, updatedAt, answeredInRound)
+	resp, err := p.call(ctx, aggregator, latestRoundDataSelector, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 5*abiWordSize {
+		return nil, fmt.Errorf("%w: short round data response", ErrInvalidPrice)
+	}
+
+	answer := resp[abiWordSize : 2*abiWordSize]
+	price = new(big.Int).SetBytes(answer)
+	// answer is an int256, a set sign bit means a negative price
+	if answer[0]&0x80 != 0 || price.Sign() == 0 {
+		return nil, fmt.Errorf("%w: non positive answer", ErrInvalidPrice)
+	}
+
+	p.mu.Lock()
+	if len(p.prices) >= maxCachedPrices {
+		p.prices = make(map[priceKey]*big.Int)
+	}
+	p.prices[key] = price
+	p.mu.Unlock()
+	return price, nil
+}
+
+func (p *pricer) call(ctx context.Context, contract evm.Address, selector string, blockNumber evm.BlockNumber) ([]byte, error) {
+	data, err := hex.DecodeString(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.ethClient.Call(ctx, contract, data, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < abiWordSize {
+		return nil, fmt.Errorf("%w: short call response from %s", ErrInvalidPrice, contract)
+	}
+	return resp, nil
+}
+
+func normalize(address evm.Address) evm.Address {
+	return evm.Address(strings.ToLower(string(address)))
+}
@@ -0,0 +1,203 @@
+package pricing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/pricing"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+const (
+	ethUSDAggregator  = evm.Address("0x5f4ec3df9cbd43714fe2740f5e3616155c5b8419")
+	usdcUSDAggregator = evm.Address("0x8fffffd4afb6115b954bd326cbe7b4ba576818f6")
+	usdcToken         = evm.Address("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+)
+
+// fakeNode answers eth_call for decimals() and latestRoundData() with fixed values,
+// the ether price moves by one dollar per block.
+type fakeNode struct {
+	calls atomic.Int64
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.calls.Add(1)
+
+	var req struct {
+		ID     int               `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != client.EthCall {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+
+	var call struct {
+		To   string `json:"to"`
+		Data string `json:"data"`
+	}
+	var blockTag string
+	json.Unmarshal(req.Params[0], &call)
+	json.Unmarshal(req.Params[1], &blockTag)
+	blockNumber, _ := evm.ParseBlockNumber(blockTag)
+
+	var result string
+	switch to, data := evm.Address(strings.ToLower(call.To)), call.Data; {
+	case data == "0x313ce567" && to == usdcToken:
+		result = abiWords(big.NewInt(6))
+	case data == "0x313ce567":
+		result = abiWords(big.NewInt(8))
+	case data == "0xfeaf968c" && to == ethUSDAggregator:
+		answer := new(big.Int).Mul(big.NewInt(2000+int64(blockNumber)), big.NewInt(1e8))
+		result = abiWords(big.NewInt(1), answer, big.NewInt(0), big.NewInt(0), big.NewInt(1))
+	case data == "0xfeaf968c" && to == usdcUSDAggregator:
+		result = abiWords(big.NewInt(1), big.NewInt(1e8), big.NewInt(0), big.NewInt(0), big.NewInt(1))
+	default:
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"execution reverted"}}`, req.ID)
+		return
+	}
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%q}`, req.ID, result)
+}
+
+func abiWords(values ...*big.Int) string {
+	var sb strings.Builder
+	sb.WriteString("0x")
+	for _, v := range values {
+		fmt.Fprintf(&sb, "%064x", v)
+	}
+	return sb.String()
+}
+
+func newTestPricer(t *testing.T) (ethereum.Pricer, *fakeNode) {
+	t.Helper()
+
+	node := &fakeNode{}
+	ts := httptest.NewServer(node)
+	t.Cleanup(ts.Close)
+
+	feeds, err := pricing.ParseFeeds("native=" + string(ethUSDAggregator) + "," + string(usdcToken) + "=" + string(usdcUSDAggregator))
+	if err != nil {
+		t.Fatalf("ParseFeeds: unexpected error: %v", err)
+	}
+
+	logger := log.Default()
+	return pricing.NewPricer(client.NewClient([]string{ts.URL}, logger), "USD", feeds, logger), node
+}
+
+func TestPricer_Value(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("native transfer", func(t *testing.T) {
+		pricer, _ := newTestPricer(t)
+		amount, _ := evm.ParseDecimalQuantity("1500000000000000000")
+
+		got, err := pricer.Value(ctx, ethereum.NativeAsset, amount, 0)
+		if err != nil {
+			t.Fatalf("Value: unexpected error: %v", err)
+		}
+		if got.Currency != "USD" || got.Amount != "3000.00000000" {
+			t.Errorf("Value: want USD 3000.00000000, got %s %s", got.Currency, got.Amount)
+		}
+	})
+
+	t.Run("priced at the transaction block", func(t *testing.T) {
+		pricer, _ := newTestPricer(t)
+
+		got, err := pricer.Value(ctx, ethereum.NativeAsset, evm.QuantityFromUint64(1e18), 10)
+		if err != nil {
+			t.Fatalf("Value: unexpected error: %v", err)
+		}
+		if got.Amount != "2010.00000000" {
+			t.Errorf("Value: want 2010.00000000 at block 10, got %s", got.Amount)
+		}
+	})
+
+	t.Run("token transfer", func(t *testing.T) {
+		pricer, _ := newTestPricer(t)
+
+		got, err := pricer.Value(ctx, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", evm.QuantityFromUint64(2_500_000), 1)
+		if err != nil {
+			t.Fatalf("Value: unexpected error: %v", err)
+		}
+		if got.Amount != "2.50000000" {
+			t.Errorf("Value: want 2.50000000, got %s", got.Amount)
+		}
+	})
+
+	t.Run("prices are cached per block", func(t *testing.T) {
+		pricer, node := newTestPricer(t)
+
+		for range 3 {
+			if _, err := pricer.Value(ctx, ethereum.NativeAsset, evm.QuantityFromUint64(1), 5); err != nil {
+				t.Fatalf("Value: unexpected error: %v", err)
+			}
+		}
+		if calls := node.calls.Load(); calls != 2 {
+			t.Errorf("expected 2 rpc calls (decimals and price), got %d", calls)
+		}
+
+		if _, err := pricer.Value(ctx, ethereum.NativeAsset, evm.QuantityFromUint64(1), 6); err != nil {
+			t.Fatalf("Value: unexpected error: %v", err)
+		}
+		if calls := node.calls.Load(); calls != 3 {
+			t.Errorf("expected a new price call for another block, got %d calls", calls)
+		}
+	})
+
+	t.Run("unknown asset", func(t *testing.T) {
+		pricer, node := newTestPricer(t)
+
+		_, err := pricer.Value(ctx, "0x0000000000000000000000000000000000000001", evm.QuantityFromUint64(1), 1)
+		if !errors.Is(err, ethereum.ErrNoPriceFeed) {
+			t.Errorf("Value: expected %v, got %v", ethereum.ErrNoPriceFeed, err)
+		}
+		if calls := node.calls.Load(); calls != 0 {
+			t.Errorf("expected no rpc calls for unknown assets, got %d", calls)
+		}
+	})
+}
+
+func TestParseFeeds(t *testing.T) {
+	testCases := []struct {
+		name  string
+		feeds string
+		want  int
+		fails bool
+	}{
+		{name: "defaults", feeds: pricing.DefaultFeeds, want: 3},
+		{name: "empty disables pricing", feeds: "", want: 0},
+		{name: "missing aggregator", feeds: "native", fails: true},
+		{name: "invalid aggregator", feeds: "native=0x123", fails: true},
+		{name: "invalid asset", feeds: "eth=" + string(ethUSDAggregator), fails: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := pricing.ParseFeeds(tc.feeds)
+			if tc.fails {
+				if err == nil {
+					t.Errorf("ParseFeeds(%q): expected error, got none", tc.feeds)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFeeds(%q): unexpected error: %v", tc.feeds, err)
+			}
+			if len(got) != tc.want {
+				t.Errorf("ParseFeeds(%q): want %d feeds, got %d", tc.feeds, tc.want, len(got))
+			}
+		})
+	}
+}
@@ -1,14 +1,29 @@
 package ethereum
 
 import (
+	"context"
+
 	"github.com/jeronimobarea/transaction_parser/internal/parser"
 	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
 )
 
 type Repository interface {
-	GetLastParsedBlock() string
-	AddAddress(address evm.Address) error
-	HasAddress(address evm.Address) bool
-	SaveTransaction(address evm.Address, tx parser.Transaction)
-	GetTransactions(address evm.Address) []parser.Transaction
+	GetLastParsedBlock(ctx context.Context) (evm.BlockNumber, error)
+	AddAddress(ctx context.Context, address evm.Address) error
+	HasAddress(ctx context.Context, address evm.Address) (bool, error)
+	GetAddresses(ctx context.Context) ([]evm.Address, error)
+	// RemoveAddress unsubscribes the address, returning ErrAddressNotSubscribed when it
+	// isn't. Its transactions are deleted when purge is set, and kept for a later
+	// subscription otherwise.
+	RemoveAddress(ctx context.Context, address evm.Address, purge bool) error
+	// SaveBlock is the unit of work of a processed block: it saves the transactions matched
+	// for each subscribed address and moves the cursor to the block, all of it or nothing.
+	// Transactions already saved for an address are skipped, and so are the transactions of
+	// addresses unsubscribed since they were matched.
+	SaveBlock(ctx context.Context, blockNumber evm.BlockNumber, txs map[evm.Address][]parser.Transaction) error
+	// GetTransactions returns every transaction of the address ordered by parser.Position.
+	GetTransactions(ctx context.Context, address evm.Address) ([]parser.Transaction, error)
+	// QueryTransactions returns a page of the transactions of the address the query filter
+	// keeps, ordered by their parser.Position, the query being normalized.
+	QueryTransactions(ctx context.Context, address evm.Address, query parser.TransactionQuery) (parser.TransactionPage, error)
 }
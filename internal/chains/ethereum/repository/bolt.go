@@ -0,0 +1,388 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	bolterrors "go.etcd.io/bbolt/errors"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+var (
+	addressesBucket    = []byte("addresses")
+	transactionsBucket = []byte("transactions")
+	hashesBucket       = []byte("hashes")
+	metaBucket         = []byte("meta")
+
+	lastParsedBlockKey = []byte("lastParsedBlock")
+	versionKey         = []byte("version")
+)
+
+// boltVersion is the layout of the database, files of older versions being upgraded on open.
+// Version 0 keyed the transactions by insertion sequence.
+const boltVersion = 1
+
+// boltRepository keeps the subscriptions and transactions in a bbolt file. Each address has
+// its own bucket of transactions keyed by position, see positionKey, and a bucket of their
+// hashes to skip duplicates. The last parsed block is updated in the same write as the
+// transactions.
+type boltRepository struct {
+	db *bolt.DB
+}
+
+// NewBoltStorage opens, or creates, the database file at path. It must be closed once done
+// with, as the file is locked while open.
+func NewBoltStorage(path string) (ethereum.Repository, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{addressesBucket, transactionsBucket, hashesBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return upgrade(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &boltRepository{
+		db: db,
+	}, nil
+}
+
+// upgrade brings the layout of the database to boltVersion.
+func upgrade(tx *bolt.Tx) error {
+	meta := tx.Bucket(metaBucket)
+
+	var version uint64
+	if value := meta.Get(versionKey); value != nil {
+		version = binary.BigEndian.Uint64(value)
+	}
+	if version < 1 {
+		if err := rekeyTransactions(tx); err != nil {
+			return fmt.Errorf("upgrading to version 1: %w", err)
+		}
+	}
+	return meta.Put(versionKey, binary.BigEndian.AppendUint64(nil, boltVersion))
+}
+
+// rekeyTransactions moves the transactions keyed by insertion sequence to position keys.
+func rekeyTransactions(tx *bolt.Tx) error {
+	transactions := tx.Bucket(transactionsBucket)
+
+	var addresses [][]byte
+	err := transactions.ForEachBucket(func(address []byte) error {
+		addresses = append(addresses, bytes.Clone(address))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		var values [][]byte
+		err := transactions.Bucket(address).ForEach(func(_, value []byte) error {
+			values = append(values, bytes.Clone(value))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := transactions.DeleteBucket(address); err != nil {
+			return err
+		}
+		txs, err := transactions.CreateBucket(address)
+		if err != nil {
+			return err
+		}
+		hashes, err := tx.Bucket(hashesBucket).CreateBucketIfNotExists(address)
+		if err != nil {
+			return err
+		}
+
+		for _, value := range values {
+			var transaction parser.Transaction
+			if err := json.Unmarshal(value, &transaction); err != nil {
+				return err
+			}
+			key := positionKey(parser.PositionOf(transaction))
+			if err := txs.Put(key, value); err != nil {
+				return err
+			}
+			if err := hashes.Put([]byte(transaction.Hash), key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// positionKey sorts as the position does: the big endian block number and transaction index
+// followed by the hash.
+func positionKey(position parser.Position) []byte {
+	key := make([]byte, 0, 16+len(position.Hash))
+	key = binary.BigEndian.AppendUint64(key, uint64(position.BlockNumber))
+	key = binary.BigEndian.AppendUint64(key, uint64(position.TransactionIndex))
+	return append(key, position.Hash...)
+}
+
+func (r *boltRepository) Close() error {
+	return r.db.Close()
+}
+
+func (r *boltRepository) GetLastParsedBlock(_ context.Context) (evm.BlockNumber, error) {
+	var blockNumber evm.BlockNumber
+	err := r.db.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(metaBucket).Get(lastParsedBlockKey); value != nil {
+			blockNumber = evm.BlockNumber(binary.BigEndian.Uint64(value))
+		}
+		return nil
+	})
+	return blockNumber, err
+}
+
+func (r *boltRepository) AddAddress(_ context.Context, address evm.Address) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		addresses := tx.Bucket(addressesBucket)
+		if addresses.Get([]byte(address)) != nil {
+			return ethereum.ErrAddressConflict
+		}
+		return addresses.Put([]byte(address), []byte{})
+	})
+}
+
+func (r *boltRepository) HasAddress(_ context.Context, address evm.Address) (bool, error) {
+	var exists bool
+	err := r.db.View(func(tx *bolt.Tx) error {
+		exists = tx.Bucket(addressesBucket).Get([]byte(address)) != nil
+		return nil
+	})
+	return exists, err
+}
+
+func (r *boltRepository) GetAddresses(_ context.Context) ([]evm.Address, error) {
+	var addresses []evm.Address
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(addressesBucket).ForEach(func(k, _ []byte) error {
+			addresses = append(addresses, evm.Address(k))
+			return nil
+		})
+	})
+	return addresses, err
+}
+
+func (r *boltRepository) RemoveAddress(_ context.Context, address evm.Address, purge bool) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		addresses := tx.Bucket(addressesBucket)
+		if addresses.Get([]byte(address)) == nil {
+			return ethereum.ErrAddressNotSubscribed
+		}
+		if err := addresses.Delete([]byte(address)); err != nil {
+			return err
+		}
+		if !purge {
+			return nil
+		}
+
+		for _, name := range [][]byte{transactionsBucket, hashesBucket} {
+			err := tx.Bucket(name).DeleteBucket([]byte(address))
+			if err != nil && !errors.Is(err, bolterrors.ErrBucketNotFound) {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *boltRepository) SaveBlock(_ context.Context, blockNumber evm.BlockNumber, txs map[evm.Address][]parser.Transaction) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		addresses := tx.Bucket(addressesBucket)
+		for address, blockTxs := range txs {
+			if addresses.Get([]byte(address)) == nil {
+				continue
+			}
+
+			for _, transaction := range blockTxs {
+				if err := saveTransaction(tx, address, transaction); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Bucket(metaBucket).Put(lastParsedBlockKey, binary.BigEndian.AppendUint64(nil, uint64(blockNumber)))
+	})
+}
+
+func saveTransaction(tx *bolt.Tx, address evm.Address, transaction parser.Transaction) error {
+	hashes, err := tx.Bucket(hashesBucket).CreateBucketIfNotExists([]byte(address))
+	if err != nil {
+		return err
+	}
+	if hashes.Get([]byte(transaction.Hash)) != nil {
+		return nil
+	}
+
+	value, err := json.Marshal(transaction)
+	if err != nil {
+		return err
+	}
+
+	txs, err := tx.Bucket(transactionsBucket).CreateBucketIfNotExists([]byte(address))
+	if err != nil {
+		return err
+	}
+
+	key := positionKey(parser.PositionOf(transaction))
+	if err := txs.Put(key, value); err != nil {
+		return err
+	}
+	return hashes.Put([]byte(transaction.Hash), key)
+}
+
+func (r *boltRepository) GetTransactions(_ context.Context, address evm.Address) ([]parser.Transaction, error) {
+	var transactions []parser.Transaction
+	err := r.db.View(func(tx *bolt.Tx) error {
+		txs := tx.Bucket(transactionsBucket).Bucket([]byte(address))
+		if txs == nil {
+			return nil
+		}
+		return txs.ForEach(func(_, value []byte) error {
+			var transaction parser.Transaction
+			if err := json.Unmarshal(value, &transaction); err != nil {
+				return err
+			}
+			transactions = append(transactions, transaction)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// QueryTransactions seeks the position keys to the cursor or the block range of the filter,
+// decoding the transactions from there to run the rest of the filter on them.
+func (r *boltRepository) QueryTransactions(_ context.Context, address evm.Address, query parser.TransactionQuery) (parser.TransactionPage, error) {
+	var transactions []parser.Transaction
+	err := r.db.View(func(tx *bolt.Tx) error {
+		txs := tx.Bucket(transactionsBucket).Bucket([]byte(address))
+		if txs == nil {
+			return nil
+		}
+
+		c := txs.Cursor()
+		key, value, err := seek(c, query)
+		if err != nil {
+			return err
+		}
+		for ; key != nil && len(transactions) <= query.Limit; key, value = next(c, query) {
+			if outOfRange(key, query) {
+				break
+			}
+
+			var transaction parser.Transaction
+			if err := json.Unmarshal(value, &transaction); err != nil {
+				return err
+			}
+			if query.Filter.Match(address, transaction) {
+				transactions = append(transactions, transaction)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return parser.TransactionPage{}, err
+	}
+	return parser.NewTransactionPage(transactions, query.Limit), nil
+}
+
+// seek moves the cursor to the first transaction of the page, the first one past both the
+// query cursor and the start of the block range in the query order.
+func seek(c *bolt.Cursor, query parser.TransactionQuery) ([]byte, []byte, error) {
+	var cursor []byte
+	if query.Cursor != "" {
+		position, err := parser.DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		cursor = positionKey(position)
+	}
+
+	if query.Order == parser.SortDescending {
+		// the page starts before the lowest of the cursor and the block after the range
+		bound := cursor
+		if to := query.Filter.ToBlock; to != nil && *to < math.MaxUint64 {
+			end := positionKey(parser.Position{BlockNumber: *to + 1})
+			if bound == nil || bytes.Compare(end, bound) < 0 {
+				bound = end
+			}
+		}
+		if bound == nil {
+			key, value := c.Last()
+			return key, value, nil
+		}
+		if key, _ := c.Seek(bound); key == nil {
+			key, value := c.Last()
+			return key, value, nil
+		}
+		key, value := c.Prev()
+		return key, value, nil
+	}
+
+	var start []byte
+	if from := query.Filter.FromBlock; from != nil {
+		start = positionKey(parser.Position{BlockNumber: *from})
+	}
+	if cursor != nil && (start == nil || bytes.Compare(cursor, start) >= 0) {
+		key, value := c.Seek(cursor)
+		if bytes.Equal(key, cursor) {
+			key, value = c.Next()
+		}
+		return key, value, nil
+	}
+	if start == nil {
+		key, value := c.First()
+		return key, value, nil
+	}
+	key, value := c.Seek(start)
+	return key, value, nil
+}
+
+func next(c *bolt.Cursor, query parser.TransactionQuery) ([]byte, []byte) {
+	if query.Order == parser.SortDescending {
+		return c.Prev()
+	}
+	return c.Next()
+}
+
+// outOfRange tells whether the walk went past the end of the block range in the query order.
+func outOfRange(key []byte, query parser.TransactionQuery) bool {
+	blockNumber := evm.BlockNumber(binary.BigEndian.Uint64(key))
+	if query.Order == parser.SortDescending {
+		return query.Filter.FromBlock != nil && blockNumber < *query.Filter.FromBlock
+	}
+	return query.Filter.ToBlock != nil && blockNumber > *query.Filter.ToBlock
+}
@@ -0,0 +1,209 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/test/evmtest"
+)
+
+func TestRepository_NewBoltStorage(t *testing.T) {
+	var (
+		ctx = context.Background()
+
+		path = filepath.Join(t.TempDir(), "data", "ethereum.db")
+
+		tx1 = parser.Transaction{
+			Hash:        "h1",
+			From:        evmtest.EVMZeroValueAddress,
+			To:          "0xabc",
+			Value:       evm.QuantityFromUint64(10),
+			BlockNumber: 1,
+			Status:      parser.TransactionStatusSuccess,
+			Fee:         evm.QuantityFromUint64(21000),
+		}
+		tx2 = parser.Transaction{
+			Hash:        "h2",
+			From:        "0xdef",
+			To:          evmtest.EVMZeroValueAddress,
+			Value:       evm.QuantityFromUint64(20),
+			BlockNumber: 2,
+			Fee:         evm.QuantityFromUint64(21000),
+			Token: &parser.TokenTransfer{
+				Contract: "0xdef",
+				To:       evmtest.EVMZeroValueAddress,
+				Amount:   evm.QuantityFromUint64(30),
+			},
+		}
+	)
+
+	repo := openBoltStorage(t, path)
+
+	if err := repo.AddAddress(ctx, evmtest.EVMZeroValueAddress); err != nil {
+		t.Fatalf("AddAddress(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
+	}
+	if err := repo.AddAddress(ctx, evmtest.EVMZeroValueAddress); !errors.Is(err, ethereum.ErrAddressConflict) {
+		t.Errorf("second AddAddress(%q): expected error %v, got %v", evmtest.EVMZeroValueAddress, ethereum.ErrAddressConflict, err)
+	}
+
+	// saving tx1 again is a no-op, and the empty block 3 moves the cursor
+	for _, tx := range []parser.Transaction{tx1, tx2, tx1} {
+		if err := repo.SaveBlock(ctx, tx.BlockNumber, map[evm.Address][]parser.Transaction{evmtest.EVMZeroValueAddress: {tx}}); err != nil {
+			t.Fatalf("SaveBlock(%d): unexpected error: %v", tx.BlockNumber, err)
+		}
+	}
+	if err := repo.SaveBlock(ctx, 3, nil); err != nil {
+		t.Fatalf("SaveBlock(3): unexpected error: %v", err)
+	}
+
+	if err := repo.(io.Closer).Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+
+	t.Run("survives a restart", func(t *testing.T) {
+		repo := openBoltStorage(t, path)
+
+		if subscribed, err := repo.HasAddress(ctx, evmtest.EVMZeroValueAddress); err != nil || !subscribed {
+			t.Errorf("HasAddress(%q): expected true, got %t, %v", evmtest.EVMZeroValueAddress, subscribed, err)
+		}
+		if got, err := repo.GetAddresses(ctx); err != nil || !reflect.DeepEqual(got, []evm.Address{evmtest.EVMZeroValueAddress}) {
+			t.Errorf("GetAddresses(): got %v, %v", got, err)
+		}
+		if got, err := repo.GetLastParsedBlock(ctx); err != nil || got != 3 {
+			t.Errorf("GetLastParsedBlock(): expected 3, got %d, %v", got, err)
+		}
+
+		txs, err := repo.GetTransactions(ctx, evmtest.EVMZeroValueAddress)
+		if err != nil {
+			t.Fatalf("GetTransactions(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
+		}
+		want := []parser.Transaction{tx1, tx2}
+		if !reflect.DeepEqual(txs, want) {
+			t.Errorf("GetTransactions(%q):\n got %#v\nwant %#v", evmtest.EVMZeroValueAddress, txs, want)
+		}
+
+		if txs, err := repo.GetTransactions(ctx, "0xabc"); err != nil || len(txs) != 0 {
+			t.Errorf("GetTransactions(%q): expected empty slice, got %v, %v", "0xabc", txs, err)
+		}
+	})
+}
+
+func TestRepository_BoltQueryTransactions(t *testing.T) {
+	testQueryTransactions(t, openBoltStorage(t, filepath.Join(t.TempDir(), "ethereum.db")))
+}
+
+func TestRepository_BoltFilterTransactions(t *testing.T) {
+	testFilterTransactions(t, openBoltStorage(t, filepath.Join(t.TempDir(), "ethereum.db")))
+}
+
+func TestRepository_BoltRemoveAddress(t *testing.T) {
+	testRemoveAddress(t, openBoltStorage(t, filepath.Join(t.TempDir(), "ethereum.db")))
+}
+
+func TestRepository_BoltUpgrade(t *testing.T) {
+	var (
+		ctx = context.Background()
+
+		path = filepath.Join(t.TempDir(), "ethereum.db")
+
+		// saved in this order by the poller, before the transactions were keyed by position
+		legacy = []parser.Transaction{
+			{Hash: "h2", From: evmtest.EVMZeroValueAddress, To: "0xabc", BlockNumber: 5, TransactionIndex: 1},
+			{Hash: "h1", From: evmtest.EVMZeroValueAddress, To: "0xabc", BlockNumber: 5, TransactionIndex: 0},
+		}
+	)
+
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		t.Fatalf("bolt.Open: unexpected error: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		addresses, err := tx.CreateBucketIfNotExists([]byte("addresses"))
+		if err != nil {
+			return err
+		}
+		if err := addresses.Put([]byte(evmtest.EVMZeroValueAddress), []byte{}); err != nil {
+			return err
+		}
+		txs, err := tx.CreateBucketIfNotExists([]byte("transactions"))
+		if err != nil {
+			return err
+		}
+		hashes, err := tx.CreateBucketIfNotExists([]byte("hashes"))
+		if err != nil {
+			return err
+		}
+		addressTxs, err := txs.CreateBucket([]byte(evmtest.EVMZeroValueAddress))
+		if err != nil {
+			return err
+		}
+		addressHashes, err := hashes.CreateBucket([]byte(evmtest.EVMZeroValueAddress))
+		if err != nil {
+			return err
+		}
+
+		for i, transaction := range legacy {
+			value, err := json.Marshal(transaction)
+			if err != nil {
+				return err
+			}
+			key := binary.BigEndian.AppendUint64(nil, uint64(i+1))
+			if err := addressTxs.Put(key, value); err != nil {
+				return err
+			}
+			if err := addressHashes.Put([]byte(transaction.Hash), key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("writing a legacy database: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+
+	repo := openBoltStorage(t, path)
+
+	txs, err := repo.GetTransactions(ctx, evmtest.EVMZeroValueAddress)
+	if err != nil {
+		t.Fatalf("GetTransactions(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
+	}
+	if len(txs) != 2 || txs[0].Hash != "h1" || txs[1].Hash != "h2" {
+		t.Errorf("GetTransactions(%q): expected the transactions by position, got %+v", evmtest.EVMZeroValueAddress, txs)
+	}
+
+	// the hashes point at the new keys, so saving the transactions again is still a no-op
+	err = repo.SaveBlock(ctx, 5, map[evm.Address][]parser.Transaction{evmtest.EVMZeroValueAddress: legacy})
+	if err != nil {
+		t.Fatalf("SaveBlock(5): unexpected error: %v", err)
+	}
+	if txs, _ := repo.GetTransactions(ctx, evmtest.EVMZeroValueAddress); len(txs) != 2 {
+		t.Errorf("GetTransactions(%q): expected the duplicates to be skipped, got %+v", evmtest.EVMZeroValueAddress, txs)
+	}
+}
+
+// openBoltStorage opens the database at path, closed at the end of the test.
+func openBoltStorage(t *testing.T, path string) ethereum.Repository {
+	t.Helper()
+
+	repo, err := repository.NewBoltStorage(path)
+	if err != nil {
+		t.Fatalf("NewBoltStorage: unexpected error: %v", err)
+	}
+	t.Cleanup(func() { repo.(io.Closer).Close() })
+	return repo
+}
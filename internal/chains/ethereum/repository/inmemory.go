@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"slices"
+	"sort"
 	"sync"
 
 	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
@@ -8,29 +11,67 @@ import (
 	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
 )
 
+// repository keeps each transaction once, keyed by hash, however many subscribed addresses
+// it involves. The addresses, blocks and counterparties index the transactions by hash.
 type repository struct {
 	mu              sync.RWMutex
 	addresses       map[evm.Address]struct{}
-	txs             map[evm.Address][]parser.Transaction
-	lastParsedBlock string
+	indexes         map[evm.Address]*addressIndex
+	txs             map[string]parser.Transaction
+	blocks          map[evm.BlockNumber][]string
+	lastParsedBlock evm.BlockNumber
+
+	// refs counts the addresses holding each transaction, which is dropped at zero.
+	refs map[string]int
+	// size estimates the memory held by the transactions and their indexes, see retention.go.
+	size int64
+}
+
+// addressIndex lists the transactions of an address by ascending parser.Position, whose
+// hash keys the transaction.
+type addressIndex struct {
+	positions []parser.Position
+	// saved tells duplicates apart in O(1).
+	saved map[string]struct{}
+	// counterparties lists the positions of the transactions with each other address.
+	counterparties map[evm.Address][]parser.Position
+}
+
+func newAddressIndex() *addressIndex {
+	return &addressIndex{
+		saved:          make(map[string]struct{}),
+		counterparties: make(map[evm.Address][]parser.Position),
+	}
+}
+
+// insertPosition keeps positions sorted, blocks being mostly saved in order so the
+// position usually goes last.
+func insertPosition(positions []parser.Position, position parser.Position) []parser.Position {
+	if n := len(positions); n == 0 || positions[n-1].Compare(position) < 0 {
+		return append(positions, position)
+	}
+	i, _ := slices.BinarySearchFunc(positions, position, parser.Position.Compare)
+	return slices.Insert(positions, i, position)
 }
 
 func NewMemoryStorage() ethereum.Repository {
 	return &repository{
-		addresses:       make(map[evm.Address]struct{}),
-		txs:             make(map[evm.Address][]parser.Transaction),
-		lastParsedBlock: "0x0",
+		addresses: make(map[evm.Address]struct{}),
+		indexes:   make(map[evm.Address]*addressIndex),
+		txs:       make(map[string]parser.Transaction),
+		blocks:    make(map[evm.BlockNumber][]string),
+		refs:      make(map[string]int),
 	}
 }
 
-func (r *repository) GetLastParsedBlock() string {
+func (r *repository) GetLastParsedBlock(_ context.Context) (evm.BlockNumber, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	return r.lastParsedBlock
+	return r.lastParsedBlock, nil
 }
 
-func (r *repository) AddAddress(address evm.Address) error {
+func (r *repository) AddAddress(_ context.Context, address evm.Address) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -42,32 +83,185 @@ func (r *repository) AddAddress(address evm.Address) error {
 	return ethereum.ErrAddressConflict
 }
 
-func (r *repository) HasAddress(address evm.Address) bool {
+func (r *repository) HasAddress(_ context.Context, address evm.Address) (bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	_, exists := r.addresses[address]
-	return exists
+	return exists, nil
 }
 
-func (r *repository) SaveTransaction(address evm.Address, tx parser.Transaction) {
+func (r *repository) GetAddresses(_ context.Context) ([]evm.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	addresses := make([]evm.Address, 0, len(r.addresses))
+	for address := range r.addresses {
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+func (r *repository) RemoveAddress(_ context.Context, address evm.Address, purge bool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	txs := r.txs[address]
-	for _, savedTx := range txs {
-		if savedTx.Hash == tx.Hash {
-			return
+	if _, exists := r.addresses[address]; !exists {
+		return ethereum.ErrAddressNotSubscribed
+	}
+	delete(r.addresses, address)
+
+	if index, ok := r.indexes[address]; ok && purge {
+		delete(r.indexes, address)
+		for _, position := range index.positions {
+			r.release(position.Hash)
+		}
+	}
+	return nil
+}
+
+// release lets go of the transaction once removed from the index of an address, dropping
+// it when no address holds it anymore.
+func (r *repository) release(hash string) {
+	r.size -= indexEntryOverhead
+	if r.refs[hash]--; r.refs[hash] > 0 {
+		return
+	}
+	delete(r.refs, hash)
+
+	tx, ok := r.txs[hash]
+	if !ok {
+		return
+	}
+	delete(r.txs, hash)
+	r.size -= transactionSize(tx)
+
+	hashes := slices.DeleteFunc(r.blocks[tx.BlockNumber], func(h string) bool { return h == hash })
+	if len(hashes) == 0 {
+		delete(r.blocks, tx.BlockNumber)
+	} else {
+		r.blocks[tx.BlockNumber] = hashes
+	}
+}
+
+func (r *repository) SaveBlock(_ context.Context, blockNumber evm.BlockNumber, txs map[evm.Address][]parser.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for address, blockTxs := range txs {
+		if _, subscribed := r.addresses[address]; !subscribed {
+			continue
+		}
+
+		index, ok := r.indexes[address]
+		if !ok {
+			index = newAddressIndex()
+			r.indexes[address] = index
+		}
+		for _, tx := range blockTxs {
+			r.saveTransaction(index, address, tx)
 		}
 	}
+	r.lastParsedBlock = blockNumber
+	return nil
+}
 
-	r.lastParsedBlock = tx.BlockNumber
-	r.txs[address] = append(txs, tx)
+func (r *repository) saveTransaction(index *addressIndex, address evm.Address, tx parser.Transaction) {
+	if _, duplicate := index.saved[tx.Hash]; duplicate {
+		return
+	}
+
+	if _, saved := r.txs[tx.Hash]; !saved {
+		r.txs[tx.Hash] = tx
+		r.blocks[tx.BlockNumber] = append(r.blocks[tx.BlockNumber], tx.Hash)
+		r.size += transactionSize(tx)
+	}
+	r.refs[tx.Hash]++
+	r.size += indexEntryOverhead
+
+	position := parser.PositionOf(tx)
+	index.saved[tx.Hash] = struct{}{}
+	index.positions = insertPosition(index.positions, position)
+
+	counterparty := parser.Counterparty(address, tx)
+	index.counterparties[counterparty] = insertPosition(index.counterparties[counterparty], position)
 }
 
-func (r *repository) GetTransactions(address evm.Address) []parser.Transaction {
+func (r *repository) GetTransactions(_ context.Context, address evm.Address) ([]parser.Transaction, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	return r.txs[address]
+	index, ok := r.indexes[address]
+	if !ok {
+		return nil, nil
+	}
+
+	txs := make([]parser.Transaction, len(index.positions))
+	for i, position := range index.positions {
+		txs[i] = r.txs[position.Hash]
+	}
+	return txs, nil
+}
+
+func (r *repository) QueryTransactions(_ context.Context, address evm.Address, query parser.TransactionQuery) (parser.TransactionPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	index, ok := r.indexes[address]
+	if !ok {
+		return parser.TransactionPage{}, nil
+	}
+
+	positions := index.positions
+	if query.Filter.Counterparty != "" {
+		positions = index.counterparties[query.Filter.Counterparty]
+	}
+	return r.page(address, blockRange(positions, query.Filter), query)
+}
+
+// blockRange narrows positions down to the block range of the filter.
+func blockRange(positions []parser.Position, filter parser.TransactionFilter) []parser.Position {
+	if filter.ToBlock != nil {
+		end := sort.Search(len(positions), func(i int) bool { return positions[i].BlockNumber > *filter.ToBlock })
+		positions = positions[:end]
+	}
+	if filter.FromBlock != nil {
+		start := sort.Search(len(positions), func(i int) bool { return positions[i].BlockNumber >= *filter.FromBlock })
+		positions = positions[start:]
+	}
+	return positions
+}
+
+// page walks positions from the query cursor in the query order, keeping the transactions of
+// address the filter matches, up to one past the limit to tell whether there is a next page.
+func (r *repository) page(address evm.Address, positions []parser.Position, query parser.TransactionQuery) (parser.TransactionPage, error) {
+	start, step := 0, 1
+	if query.Order == parser.SortDescending {
+		start, step = len(positions)-1, -1
+	}
+
+	if query.Cursor != "" {
+		cursor, err := parser.DecodeCursor(query.Cursor)
+		if err != nil {
+			return parser.TransactionPage{}, err
+		}
+
+		i, found := slices.BinarySearchFunc(positions, cursor, parser.Position.Compare)
+		switch {
+		case query.Order == parser.SortDescending:
+			start = i - 1
+		case found:
+			start = i + 1
+		default:
+			start = i
+		}
+	}
+
+	var txs []parser.Transaction
+	for i := start; i >= 0 && i < len(positions) && len(txs) <= query.Limit; i += step {
+		if tx := r.txs[positions[i].Hash]; query.Filter.Match(address, tx) {
+			txs = append(txs, tx)
+		}
+	}
+	return parser.NewTransactionPage(txs, query.Limit), nil
 }
@@ -1,33 +1,47 @@
 package repository_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
 	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
 	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
 	"github.com/jeronimobarea/transaction_parser/internal/test/evmtest"
 )
 
 func TestRepository_NewMemoryStorage(t *testing.T) {
-	repo := repository.NewMemoryStorage()
+	var (
+		ctx = context.Background()
+
+		repo = repository.NewMemoryStorage()
+	)
 
-	if repo.HasAddress(evmtest.EVMZeroValueAddress) {
-		t.Errorf("HasAddress(%q): expected false, got true", evmtest.EVMZeroValueAddress)
+	subscribed, err := repo.HasAddress(ctx, evmtest.EVMZeroValueAddress)
+	if err != nil || subscribed {
+		t.Errorf("HasAddress(%q): expected false, got %t, %v", evmtest.EVMZeroValueAddress, subscribed, err)
 	}
 
-	txs := repo.GetTransactions(evmtest.EVMZeroValueAddress)
-	if txs != nil && len(txs) != 0 {
-		t.Errorf("GetTransactions(%q): expected empty slice, got %v", evmtest.EVMZeroValueAddress, txs)
+	txs, err := repo.GetTransactions(ctx, evmtest.EVMZeroValueAddress)
+	if err != nil || len(txs) != 0 {
+		t.Errorf("GetTransactions(%q): expected empty slice, got %v, %v", evmtest.EVMZeroValueAddress, txs, err)
 	}
 }
 
 func TestRepository_GetLastParsedBlock(t *testing.T) {
-	repo := repository.NewMemoryStorage()
+	var (
+		ctx = context.Background()
 
-	err := repo.AddAddress(evmtest.EVMZeroValueAddress)
+		repo = repository.NewMemoryStorage()
+	)
+
+	err := repo.AddAddress(ctx, evmtest.EVMZeroValueAddress)
 	if err != nil {
 		t.Fatalf("AddAddress(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
 	}
@@ -36,28 +50,45 @@ func TestRepository_GetLastParsedBlock(t *testing.T) {
 		Hash:        "h1",
 		From:        evmtest.EVMZeroValueAddress,
 		To:          "0xabc",
-		Value:       "10",
-		BlockNumber: "0x1",
+		Value:       evm.QuantityFromUint64(10),
+		BlockNumber: 1,
 	}
 
-	repo.SaveTransaction(evmtest.EVMZeroValueAddress, tx)
+	if err := repo.SaveBlock(ctx, tx.BlockNumber, map[evm.Address][]parser.Transaction{evmtest.EVMZeroValueAddress: {tx}}); err != nil {
+		t.Fatalf("SaveBlock: unexpected error: %v", err)
+	}
 
-	got := repo.GetLastParsedBlock()
+	got, err := repo.GetLastParsedBlock(ctx)
+	if err != nil {
+		t.Fatalf("GetLastParsedBlock(): unexpected error: %v", err)
+	}
 	if got != tx.BlockNumber {
-		t.Errorf("GetLastParsedBlock(): expected %s, got %s", tx.BlockNumber, got)
+		t.Errorf("GetLastParsedBlock(): expected %d, got %d", tx.BlockNumber, got)
 	}
+
+	t.Run("empty block", func(t *testing.T) {
+		if err := repo.SaveBlock(ctx, 2, nil); err != nil {
+			t.Fatalf("SaveBlock: unexpected error: %v", err)
+		}
+
+		if got, _ := repo.GetLastParsedBlock(ctx); got != 2 {
+			t.Errorf("GetLastParsedBlock(): expected 2, got %d", got)
+		}
+	})
 }
 
 func TestRepository_AddAddress(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("happy path", func(t *testing.T) {
 		repo := repository.NewMemoryStorage()
 
-		err := repo.AddAddress(evmtest.EVMZeroValueAddress)
+		err := repo.AddAddress(ctx, evmtest.EVMZeroValueAddress)
 		if err != nil {
 			t.Fatalf("AddAddress(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
 		}
 
-		if !repo.HasAddress(evmtest.EVMZeroValueAddress) {
+		if subscribed, _ := repo.HasAddress(ctx, evmtest.EVMZeroValueAddress); !subscribed {
 			t.Errorf("HasAddress(%q): expected true after AddAddress, got false", evmtest.EVMZeroValueAddress)
 		}
 	})
@@ -65,11 +96,11 @@ func TestRepository_AddAddress(t *testing.T) {
 	t.Run("trying to add an existing address", func(t *testing.T) {
 		repo := repository.NewMemoryStorage()
 
-		if err := repo.AddAddress(evmtest.EVMZeroValueAddress); err != nil {
+		if err := repo.AddAddress(ctx, evmtest.EVMZeroValueAddress); err != nil {
 			t.Fatalf("first AddAddress(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
 		}
 
-		err := repo.AddAddress(evmtest.EVMZeroValueAddress)
+		err := repo.AddAddress(ctx, evmtest.EVMZeroValueAddress)
 		if err == nil {
 			t.Fatalf("second AddAddress(%q): expected error %v, got nil", evmtest.EVMZeroValueAddress, ethereum.ErrAddressConflict)
 		}
@@ -81,30 +112,43 @@ func TestRepository_AddAddress(t *testing.T) {
 
 func TestRepository_SaveAndGetTransactions(t *testing.T) {
 	var (
+		ctx = context.Background()
+
 		repo = repository.NewMemoryStorage()
 
 		tx1 = parser.Transaction{
 			Hash:        "h1",
 			From:        evmtest.EVMZeroValueAddress,
 			To:          "0xabc",
-			Value:       "10",
-			BlockNumber: "0x1",
+			Value:       evm.QuantityFromUint64(10),
+			BlockNumber: 1,
 		}
 		tx2 = parser.Transaction{
 			Hash:        "h2",
 			From:        "0xdef",
 			To:          evmtest.EVMZeroValueAddress,
-			Value:       "20",
-			BlockNumber: "0x2",
+			Value:       evm.QuantityFromUint64(20),
+			BlockNumber: 2,
 		}
 	)
 
+	if err := repo.AddAddress(ctx, evmtest.EVMZeroValueAddress); err != nil {
+		t.Fatalf("AddAddress(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
+	}
+
 	t.Run("insert transactions", func(t *testing.T) {
-		repo.SaveTransaction(evmtest.EVMZeroValueAddress, tx1)
-		repo.SaveTransaction(evmtest.EVMZeroValueAddress, tx2)
+		// tx1 is saved twice, the second time being skipped
+		for _, tx := range []parser.Transaction{tx1, tx2, tx1} {
+			if err := repo.SaveBlock(ctx, tx.BlockNumber, map[evm.Address][]parser.Transaction{evmtest.EVMZeroValueAddress: {tx}}); err != nil {
+				t.Fatalf("SaveBlock(%d): unexpected error: %v", tx.BlockNumber, err)
+			}
+		}
 
 		t.Run("retrieve transactions", func(t *testing.T) {
-			txs := repo.GetTransactions(evmtest.EVMZeroValueAddress)
+			txs, err := repo.GetTransactions(ctx, evmtest.EVMZeroValueAddress)
+			if err != nil {
+				t.Fatalf("GetTransactions(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
+			}
 
 			want := []parser.Transaction{tx1, tx2}
 			if !reflect.DeepEqual(txs, want) {
@@ -115,24 +159,413 @@ func TestRepository_SaveAndGetTransactions(t *testing.T) {
 }
 
 func TestRepository_ConcurrentAccess(t *testing.T) {
-	repo := repository.NewMemoryStorage()
+	var (
+		ctx = context.Background()
+
+		repo = repository.NewMemoryStorage()
+	)
 
 	done := make(chan struct{})
 	go func() {
 		for i := 0; i < 1000; i++ {
-			_ = repo.AddAddress(evmtest.EVMZeroValueAddress)
-			repo.SaveTransaction(evmtest.EVMZeroValueAddress, parser.Transaction{Hash: "h"})
+			_ = repo.AddAddress(ctx, evmtest.EVMZeroValueAddress)
+			_ = repo.SaveBlock(ctx, 1, map[evm.Address][]parser.Transaction{evmtest.EVMZeroValueAddress: {{Hash: "h"}}})
 		}
 		close(done)
 	}()
 
 	for i := 0; i < 1000; i++ {
-		_ = repo.HasAddress(evmtest.EVMZeroValueAddress)
-		_ = repo.GetTransactions(evmtest.EVMZeroValueAddress)
+		_, _ = repo.HasAddress(ctx, evmtest.EVMZeroValueAddress)
+		_, _ = repo.GetTransactions(ctx, evmtest.EVMZeroValueAddress)
 	}
 	<-done
 
-	if !repo.HasAddress(evmtest.EVMZeroValueAddress) {
+	if subscribed, _ := repo.HasAddress(ctx, evmtest.EVMZeroValueAddress); !subscribed {
 		t.Errorf("HasAddress(%q): expected true after concurrent adds, got false", evmtest.EVMZeroValueAddress)
 	}
 }
+
+func TestRepository_SharedTransactions(t *testing.T) {
+	var (
+		ctx = context.Background()
+
+		repo = repository.NewMemoryStorage()
+
+		sender    = evm.Address("0x1111111111111111111111111111111111111111")
+		recipient = evm.Address("0x2222222222222222222222222222222222222222")
+
+		tx = parser.Transaction{Hash: "h1", From: sender, To: recipient, Value: evm.QuantityFromUint64(10), BlockNumber: 1}
+	)
+
+	for _, address := range []evm.Address{sender, recipient} {
+		if err := repo.AddAddress(ctx, address); err != nil {
+			t.Fatalf("AddAddress(%q): unexpected error: %v", address, err)
+		}
+	}
+
+	err := repo.SaveBlock(ctx, 1, map[evm.Address][]parser.Transaction{sender: {tx}, recipient: {tx}})
+	if err != nil {
+		t.Fatalf("SaveBlock: unexpected error: %v", err)
+	}
+
+	for _, address := range []evm.Address{sender, recipient} {
+		txs, err := repo.GetTransactions(ctx, address)
+		if err != nil || !reflect.DeepEqual(txs, []parser.Transaction{tx}) {
+			t.Errorf("GetTransactions(%q): want %+v, got %+v, %v", address, []parser.Transaction{tx}, txs, err)
+		}
+	}
+}
+
+func TestRepository_RemoveAddress(t *testing.T) {
+	testRemoveAddress(t, repository.NewMemoryStorage())
+}
+
+// testRemoveAddress unsubscribes an address sharing a transaction with another one, keeping
+// and then purging its transactions.
+func testRemoveAddress(t *testing.T, repo ethereum.Repository) {
+	t.Helper()
+
+	var (
+		ctx = context.Background()
+
+		address = evm.Address("0x1111111111111111111111111111111111111111")
+		other   = evm.Address("0x2222222222222222222222222222222222222222")
+
+		shared = parser.Transaction{Hash: "0xa", From: address, To: other, Value: evm.QuantityFromUint64(1), Fee: evm.QuantityFromUint64(1), BlockNumber: 1}
+		own    = parser.Transaction{Hash: "0xb", From: "0xabc", To: address, Value: evm.QuantityFromUint64(2), Fee: evm.QuantityFromUint64(1), BlockNumber: 2}
+	)
+
+	for _, a := range []evm.Address{address, other} {
+		if err := repo.AddAddress(ctx, a); err != nil {
+			t.Fatalf("AddAddress(%q): unexpected error: %v", a, err)
+		}
+	}
+	if err := repo.SaveBlock(ctx, 1, map[evm.Address][]parser.Transaction{address: {shared}, other: {shared}}); err != nil {
+		t.Fatalf("SaveBlock(1): unexpected error: %v", err)
+	}
+	if err := repo.SaveBlock(ctx, 2, map[evm.Address][]parser.Transaction{address: {own}}); err != nil {
+		t.Fatalf("SaveBlock(2): unexpected error: %v", err)
+	}
+
+	t.Run("not subscribed", func(t *testing.T) {
+		if err := repo.RemoveAddress(ctx, "0xabc", false); !errors.Is(err, ethereum.ErrAddressNotSubscribed) {
+			t.Errorf("RemoveAddress(%q): expected error %v, got %v", "0xabc", ethereum.ErrAddressNotSubscribed, err)
+		}
+	})
+
+	t.Run("retain", func(t *testing.T) {
+		if err := repo.RemoveAddress(ctx, address, false); err != nil {
+			t.Fatalf("RemoveAddress(%q): unexpected error: %v", address, err)
+		}
+		if subscribed, err := repo.HasAddress(ctx, address); err != nil || subscribed {
+			t.Errorf("HasAddress(%q): expected false, got %t, %v", address, subscribed, err)
+		}
+		if txs, err := repo.GetTransactions(ctx, address); err != nil || len(txs) != 2 {
+			t.Errorf("GetTransactions(%q): expected the transactions to be kept, got %+v, %v", address, txs, err)
+		}
+
+		// the transactions of a block matched before the removal are dropped
+		late := parser.Transaction{Hash: "0xc", From: address, To: "0xabc", Value: evm.QuantityFromUint64(3), Fee: evm.QuantityFromUint64(1), BlockNumber: 3}
+		if err := repo.SaveBlock(ctx, 3, map[evm.Address][]parser.Transaction{address: {late}}); err != nil {
+			t.Fatalf("SaveBlock(3): unexpected error: %v", err)
+		}
+		if txs, _ := repo.GetTransactions(ctx, address); len(txs) != 2 {
+			t.Errorf("GetTransactions(%q): expected the late transaction to be dropped, got %+v", address, txs)
+		}
+		if got, err := repo.GetLastParsedBlock(ctx); err != nil || got != 3 {
+			t.Errorf("GetLastParsedBlock(): expected 3, got %d, %v", got, err)
+		}
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		if err := repo.AddAddress(ctx, address); err != nil {
+			t.Fatalf("AddAddress(%q): unexpected error: %v", address, err)
+		}
+		if err := repo.RemoveAddress(ctx, address, true); err != nil {
+			t.Fatalf("RemoveAddress(%q): unexpected error: %v", address, err)
+		}
+		if txs, err := repo.GetTransactions(ctx, address); err != nil || len(txs) != 0 {
+			t.Errorf("GetTransactions(%q): expected no transactions, got %+v, %v", address, txs, err)
+		}
+
+		// the transactions shared with other addresses stay theirs
+		txs, err := repo.GetTransactions(ctx, other)
+		if err != nil || len(txs) != 1 || txs[0].Hash != shared.Hash {
+			t.Errorf("GetTransactions(%q): expected %q to be kept, got %+v, %v", other, shared.Hash, txs, err)
+		}
+	})
+}
+
+func TestRepository_QueryTransactions(t *testing.T) {
+	testQueryTransactions(t, repository.NewMemoryStorage())
+}
+
+// testQueryTransactions walks the pages of the transactions of an address both ways, the
+// transactions of a block being saved out of order.
+func testQueryTransactions(t *testing.T, repo ethereum.Repository) {
+	t.Helper()
+
+	var (
+		ctx = context.Background()
+
+		address = evm.Address("0x1111111111111111111111111111111111111111")
+
+		blocks = []map[evm.Address][]parser.Transaction{
+			{address: {
+				{Hash: "0xb", From: address, To: "0xabc", Value: evm.QuantityFromUint64(1), Fee: evm.QuantityFromUint64(1), BlockNumber: 1, TransactionIndex: 3},
+				{Hash: "0xa", From: address, To: "0xabc", Value: evm.QuantityFromUint64(1), Fee: evm.QuantityFromUint64(1), BlockNumber: 1, TransactionIndex: 1},
+			}},
+			{address: {
+				{Hash: "0xd", From: "0xabc", To: address, Value: evm.QuantityFromUint64(1), Fee: evm.QuantityFromUint64(1), BlockNumber: 2, TransactionIndex: 0},
+				{Hash: "0xc", From: "0xabc", To: address, Value: evm.QuantityFromUint64(1), Fee: evm.QuantityFromUint64(1), BlockNumber: 2, TransactionIndex: 0},
+			}},
+			{address: {
+				{Hash: "0xe", From: address, To: "0xabc", Value: evm.QuantityFromUint64(1), Fee: evm.QuantityFromUint64(1), BlockNumber: 3, TransactionIndex: 2},
+			}},
+		}
+	)
+
+	if err := repo.AddAddress(ctx, address); err != nil {
+		t.Fatalf("AddAddress(%q): unexpected error: %v", address, err)
+	}
+	for i, block := range blocks {
+		if err := repo.SaveBlock(ctx, evm.BlockNumber(i+1), block); err != nil {
+			t.Fatalf("SaveBlock(%d): unexpected error: %v", i+1, err)
+		}
+	}
+
+	testCases := []struct {
+		order parser.SortOrder
+		want  [][]string
+	}{
+		{order: parser.SortAscending, want: [][]string{{"0xa", "0xb"}, {"0xc", "0xd"}, {"0xe"}}},
+		{order: parser.SortDescending, want: [][]string{{"0xe", "0xd"}, {"0xc", "0xb"}, {"0xa"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.order), func(t *testing.T) {
+			var (
+				query = parser.TransactionQuery{Limit: 2, Order: tc.order}
+				got   [][]string
+			)
+			for {
+				page, err := repo.QueryTransactions(ctx, address, query)
+				if err != nil {
+					t.Fatalf("QueryTransactions(%+v): unexpected error: %v", query, err)
+				}
+
+				var hashes []string
+				for _, tx := range page.Transactions {
+					hashes = append(hashes, tx.Hash)
+				}
+				got = append(got, hashes)
+
+				if page.NextCursor == "" || len(got) > len(tc.want) {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("QueryTransactions: want pages %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("unknown address", func(t *testing.T) {
+		page, err := repo.QueryTransactions(ctx, "0xabc", parser.TransactionQuery{Limit: 2, Order: parser.SortAscending})
+		if err != nil || len(page.Transactions) != 0 || page.NextCursor != "" {
+			t.Errorf("QueryTransactions(%q): expected an empty page, got %+v, %v", "0xabc", page, err)
+		}
+	})
+}
+
+func TestRepository_FilterTransactions(t *testing.T) {
+	testFilterTransactions(t, repository.NewMemoryStorage())
+}
+
+// testFilterTransactions runs each filter paging one transaction at a time both ways, so the
+// filters are checked along with the cursors.
+func testFilterTransactions(t *testing.T, repo ethereum.Repository) {
+	t.Helper()
+
+	const (
+		address   = evm.Address("0x1111111111111111111111111111111111111111")
+		bob       = evm.Address("0x2222222222222222222222222222222222222222")
+		carol     = evm.Address("0x3333333333333333333333333333333333333333")
+		tokenAddr = evm.Address("0x4444444444444444444444444444444444444444")
+	)
+
+	var (
+		ctx = context.Background()
+
+		genesis = time.Date(2025, time.April, 26, 8, 0, 0, 0, time.UTC)
+		at      = func(blockNumber evm.BlockNumber) time.Time {
+			return genesis.Add(time.Duration(blockNumber) * 12 * time.Second)
+		}
+
+		txs = []parser.Transaction{
+			{Hash: "0xa", From: address, To: bob, Value: evm.QuantityFromUint64(10), BlockNumber: 1, Timestamp: at(1), Status: parser.TransactionStatusSuccess},
+			{Hash: "0xb", From: bob, To: address, Value: evm.QuantityFromUint64(20), BlockNumber: 1, TransactionIndex: 1, Timestamp: at(1), Status: parser.TransactionStatusFailed},
+			{Hash: "0xc", From: carol, To: address, Value: evm.QuantityFromUint64(30), BlockNumber: 2, Timestamp: at(2), Status: parser.TransactionStatusSuccess},
+			{Hash: "0xd", From: address, To: tokenAddr, BlockNumber: 3, Timestamp: at(3), Status: parser.TransactionStatusSuccess, Token: &parser.TokenTransfer{Contract: tokenAddr, To: bob, Amount: evm.QuantityFromUint64(5)}},
+			{Hash: "0xe", From: address, To: carol, Value: evm.QuantityFromUint64(40), BlockNumber: 4, Timestamp: at(4), Status: parser.TransactionStatusSuccess},
+			// a transaction of unknown time
+			{Hash: "0xf", From: bob, To: address, Value: evm.QuantityFromUint64(50), BlockNumber: 5, Status: parser.TransactionStatusSuccess},
+		}
+
+		block2, block3     = evm.BlockNumber(2), evm.BlockNumber(3)
+		minValue, maxValue = evm.QuantityFromUint64(20), evm.QuantityFromUint64(30)
+	)
+
+	if err := repo.AddAddress(ctx, address); err != nil {
+		t.Fatalf("AddAddress(%q): unexpected error: %v", address, err)
+	}
+	for _, tx := range txs {
+		if err := repo.SaveBlock(ctx, tx.BlockNumber, map[evm.Address][]parser.Transaction{address: {tx}}); err != nil {
+			t.Fatalf("SaveBlock(%d): unexpected error: %v", tx.BlockNumber, err)
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		filter parser.TransactionFilter
+		want   []string
+	}{
+		{name: "block range", filter: parser.TransactionFilter{FromBlock: &block2, ToBlock: &block3}, want: []string{"0xc", "0xd"}},
+		{name: "from block", filter: parser.TransactionFilter{FromBlock: &block3}, want: []string{"0xd", "0xe", "0xf"}},
+		{name: "to block", filter: parser.TransactionFilter{ToBlock: &block2}, want: []string{"0xa", "0xb", "0xc"}},
+		{name: "time range", filter: parser.TransactionFilter{Since: at(2), Until: at(4)}, want: []string{"0xc", "0xd"}},
+		{name: "since drops unknown times", filter: parser.TransactionFilter{Since: at(4)}, want: []string{"0xe"}},
+		{name: "until keeps unknown times", filter: parser.TransactionFilter{Until: at(2)}, want: []string{"0xa", "0xb", "0xf"}},
+		{name: "outbound", filter: parser.TransactionFilter{Direction: parser.DirectionOutbound}, want: []string{"0xa", "0xd", "0xe"}},
+		{name: "inbound", filter: parser.TransactionFilter{Direction: parser.DirectionInbound}, want: []string{"0xb", "0xc", "0xf"}},
+		{name: "counterparty", filter: parser.TransactionFilter{Counterparty: bob}, want: []string{"0xa", "0xb", "0xd", "0xf"}},
+		{name: "value range", filter: parser.TransactionFilter{MinValue: &minValue, MaxValue: &maxValue}, want: []string{"0xb", "0xc"}},
+		{name: "status", filter: parser.TransactionFilter{Status: parser.TransactionStatusFailed}, want: []string{"0xb"}},
+		{name: "combined", filter: parser.TransactionFilter{Direction: parser.DirectionOutbound, Counterparty: carol}, want: []string{"0xe"}},
+		{name: "no match", filter: parser.TransactionFilter{Counterparty: tokenAddr}},
+	}
+
+	for _, tc := range testCases {
+		for _, order := range []parser.SortOrder{parser.SortAscending, parser.SortDescending} {
+			t.Run(tc.name+" "+string(order), func(t *testing.T) {
+				want := slices.Clone(tc.want)
+				if order == parser.SortDescending {
+					slices.Reverse(want)
+				}
+
+				var (
+					query = parser.TransactionQuery{Limit: 1, Order: order, Filter: tc.filter}
+					got   []string
+				)
+				for {
+					page, err := repo.QueryTransactions(ctx, address, query)
+					if err != nil {
+						t.Fatalf("QueryTransactions(%+v): unexpected error: %v", query, err)
+					}
+					for _, tx := range page.Transactions {
+						got = append(got, tx.Hash)
+					}
+
+					if page.NextCursor == "" || len(got) > len(txs) {
+						break
+					}
+					query.Cursor = page.NextCursor
+				}
+
+				if !slices.Equal(got, want) {
+					t.Errorf("QueryTransactions: want %v, got %v", want, got)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkMemoryStorage_SaveBlock saves blocks for an address already holding millions of
+// transactions, every transaction being saved twice to exercise the duplicate check.
+func BenchmarkMemoryStorage_SaveBlock(b *testing.B) {
+	const (
+		stored   = 2_000_000
+		perBlock = 100
+	)
+
+	var (
+		ctx = context.Background()
+
+		address = evm.Address("0x1111111111111111111111111111111111111111")
+	)
+
+	for _, counterparties := range []int{1, 1000} {
+		b.Run(fmt.Sprintf("%d counterparties", counterparties), func(b *testing.B) {
+			repo := repository.NewMemoryStorage()
+			blockNumber := fillMemoryStorage(b, repo, address, stored, perBlock, counterparties)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for b.Loop() {
+				blockNumber++
+				block := newBenchmarkBlock(address, blockNumber, perBlock, counterparties)
+				for range 2 {
+					if err := repo.SaveBlock(ctx, blockNumber, block); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkMemoryStorage_GetTransactions reads back every transaction of an address holding
+// millions of them.
+func BenchmarkMemoryStorage_GetTransactions(b *testing.B) {
+	var (
+		ctx = context.Background()
+
+		address = evm.Address("0x1111111111111111111111111111111111111111")
+
+		repo = repository.NewMemoryStorage()
+	)
+	fillMemoryStorage(b, repo, address, 1_000_000, 100, 1000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for b.Loop() {
+		if _, err := repo.GetTransactions(ctx, address); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// fillMemoryStorage saves count transactions of the address in blocks of perBlock, returning
+// the last block number.
+func fillMemoryStorage(b *testing.B, repo ethereum.Repository, address evm.Address, count, perBlock, counterparties int) evm.BlockNumber {
+	b.Helper()
+
+	if err := repo.AddAddress(context.Background(), address); err != nil {
+		b.Fatal(err)
+	}
+
+	var blockNumber evm.BlockNumber
+	for saved := 0; saved < count; saved += perBlock {
+		blockNumber++
+		if err := repo.SaveBlock(context.Background(), blockNumber, newBenchmarkBlock(address, blockNumber, perBlock, counterparties)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return blockNumber
+}
+
+func newBenchmarkBlock(address evm.Address, blockNumber evm.BlockNumber, count, counterparties int) map[evm.Address][]parser.Transaction {
+	txs := make([]parser.Transaction, count)
+	for i := range txs {
+		txs[i] = parser.Transaction{
+			Hash:        fmt.Sprintf("0x%032x%032x", uint64(blockNumber), i),
+			From:        evm.Address(fmt.Sprintf("0x%040x", i%counterparties)),
+			To:          address,
+			Value:       evm.QuantityFromUint64(uint64(i)),
+			BlockNumber: blockNumber,
+		}
+	}
+	return map[evm.Address][]parser.Transaction{address: txs}
+}
@@ -0,0 +1,22 @@
+package repository
+
+import "testing"
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: unexpected error: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("loadMigrations: expected the embedded migrations, got none")
+	}
+
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %s: expected version %d, versions must follow each other", m.name, i+1)
+		}
+		if m.sql == "" {
+			t.Errorf("migration %s: empty", m.name)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+CREATE TABLE ethereum_addresses (
+    address             TEXT PRIMARY KEY,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- A transaction involving several subscribed addresses is stored once for each of them.
+CREATE TABLE ethereum_transactions (
+    id                  BIGSERIAL PRIMARY KEY,
+    address             TEXT NOT NULL,
+    hash                TEXT NOT NULL,
+    block_number        BIGINT NOT NULL,
+    from_address        TEXT NOT NULL,
+    to_address          TEXT NOT NULL,
+    value               NUMERIC(78, 0) NOT NULL,
+    nonce               BIGINT NOT NULL,
+    status              TEXT NOT NULL,
+    fee                 NUMERIC(78, 0) NOT NULL,
+    fiat_currency       TEXT,
+    fiat_amount         NUMERIC,
+    token_contract      TEXT,
+    token_to            TEXT,
+    token_amount        NUMERIC(78, 0),
+    token_fiat_currency TEXT,
+    token_fiat_amount   NUMERIC,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (address, hash)
+);
+
+CREATE INDEX ethereum_transactions_address_block_idx ON ethereum_transactions (address, block_number);
+CREATE INDEX ethereum_transactions_block_idx ON ethereum_transactions (block_number);
+CREATE INDEX ethereum_transactions_hash_idx ON ethereum_transactions (hash);
+
+-- The single row cursor holds the last block transactions were saved from.
+CREATE TABLE ethereum_cursor (
+    id                  BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    last_parsed_block   BIGINT NOT NULL
+);
@@ -0,0 +1,8 @@
+-- Transactions are listed by block, index in the block and hash, the hash being compared
+-- byte by byte so the order doesn't depend on the database locale.
+ALTER TABLE ethereum_transactions ADD COLUMN transaction_index BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE ethereum_transactions ALTER COLUMN hash TYPE TEXT COLLATE "C";
+
+DROP INDEX ethereum_transactions_address_block_idx;
+CREATE INDEX ethereum_transactions_address_position_idx
+    ON ethereum_transactions (address, block_number, transaction_index, hash);
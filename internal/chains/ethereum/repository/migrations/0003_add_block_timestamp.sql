@@ -0,0 +1,2 @@
+-- The time of the block, unknown for the transactions saved before it was recorded.
+ALTER TABLE ethereum_transactions ADD COLUMN block_timestamp TIMESTAMPTZ;
@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+// postgresRepository keeps the subscriptions and transactions in PostgreSQL, amounts being
+// stored as numerics so they can be queried directly.
+type postgresRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresStorage migrates the database to the latest schema and returns a repository on
+// it. The caller owns db and closes it.
+func NewPostgresStorage(ctx context.Context, db *sql.DB) (ethereum.Repository, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, err
+	}
+
+	return &postgresRepository{
+		db: db,
+	}, nil
+}
+
+func (r *postgresRepository) GetLastParsedBlock(ctx context.Context) (evm.BlockNumber, error) {
+	var blockNumber evm.BlockNumber
+	err := r.db.QueryRowContext(ctx, `SELECT last_parsed_block FROM ethereum_cursor`).Scan(&blockNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return blockNumber, err
+}
+
+func (r *postgresRepository) AddAddress(ctx context.Context, address evm.Address) error {
+	result, err := r.db.ExecContext(ctx, `INSERT INTO ethereum_addresses (address) VALUES ($1) ON CONFLICT DO NOTHING`, address)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return ethereum.ErrAddressConflict
+	}
+	return nil
+}
+
+func (r *postgresRepository) HasAddress(ctx context.Context, address evm.Address) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ethereum_addresses WHERE address = $1)`, address).Scan(&exists)
+	return exists, err
+}
+
+func (r *postgresRepository) GetAddresses(ctx context.Context) ([]evm.Address, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT address FROM ethereum_addresses ORDER BY created_at, address`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []evm.Address
+	for rows.Next() {
+		var address evm.Address
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
+
+// RemoveAddress deletes the subscription, and the transactions when purging, in a single
+// database transaction.
+func (r *postgresRepository) RemoveAddress(ctx context.Context, address evm.Address, purge bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM ethereum_addresses WHERE address = $1`, address)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ethereum.ErrAddressNotSubscribed
+	}
+
+	if purge {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM ethereum_transactions WHERE address = $1`, address); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveBlock inserts the transactions and moves the cursor to the block in a single database
+// transaction. The subscriptions of the addresses are locked until it commits, so an
+// unsubscription waits for the block to be saved before purging it.
+func (r *postgresRepository) SaveBlock(ctx context.Context, blockNumber evm.BlockNumber, txs map[evm.Address][]parser.Transaction) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for address, blockTxs := range txs {
+		var subscribed bool
+		err := tx.QueryRowContext(ctx, `SELECT TRUE FROM ethereum_addresses WHERE address = $1 FOR SHARE`, address).Scan(&subscribed)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("locking subscription %s: %w", address, err)
+		}
+
+		for _, transaction := range blockTxs {
+			if err := insertTransaction(ctx, tx, address, transaction); err != nil {
+				return fmt.Errorf("saving transaction %s: %w", transaction.Hash, err)
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO ethereum_cursor (last_parsed_block) VALUES ($1)
+		ON CONFLICT (id) DO UPDATE SET last_parsed_block = EXCLUDED.last_parsed_block`,
+		int64(blockNumber),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertTransaction inserts the transaction of the address unless it was already saved.
+func insertTransaction(ctx context.Context, tx *sql.Tx, address evm.Address, transaction parser.Transaction) error {
+	row := newTransactionRow(address, transaction)
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ethereum_transactions (
+			address, hash, block_number, transaction_index, block_timestamp, from_address, to_address, value, nonce, status, fee,
+			fiat_currency, fiat_amount,
+			token_contract, token_to, token_amount, token_fiat_currency, token_fiat_amount
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		ON CONFLICT (address, hash) DO NOTHING`,
+		row.address, row.hash, row.blockNumber, row.transactionIndex, row.blockTimestamp, row.from, row.to, row.value, row.nonce, row.status, row.fee,
+		row.fiatCurrency, row.fiatAmount,
+		row.tokenContract, row.tokenTo, row.tokenAmount, row.tokenFiatCurrency, row.tokenFiatAmount,
+	)
+	return err
+}
+
+// transactionColumns are the columns scanned by scanTransactions.
+const transactionColumns = `hash, block_number, transaction_index, block_timestamp, from_address, to_address, value, nonce, status, fee,
+	fiat_currency, fiat_amount,
+	token_contract, token_to, token_amount, token_fiat_currency, token_fiat_amount`
+
+func (r *postgresRepository) GetTransactions(ctx context.Context, address evm.Address) ([]parser.Transaction, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+transactionColumns+`
+		FROM ethereum_transactions
+		WHERE address = $1
+		ORDER BY block_number, transaction_index, hash`,
+		address,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows)
+}
+
+// QueryTransactions seeks past the cursor on the (address, block_number, transaction_index,
+// hash) index, the filter being added to the conditions, reading one row past the limit to
+// tell whether there is a next page.
+func (r *postgresRepository) QueryTransactions(ctx context.Context, address evm.Address, query parser.TransactionQuery) (parser.TransactionPage, error) {
+	conditions, args := filterConditions(address, query.Filter)
+
+	comparison, order := ">", "ASC"
+	if query.Order == parser.SortDescending {
+		comparison, order = "<", "DESC"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := parser.DecodeCursor(query.Cursor)
+		if err != nil {
+			return parser.TransactionPage{}, err
+		}
+		args = append(args, int64(cursor.BlockNumber), int64(cursor.TransactionIndex), cursor.Hash)
+		conditions = append(conditions, fmt.Sprintf(
+			"(block_number, transaction_index, hash) %s ($%d, $%d, $%d)",
+			comparison, len(args)-2, len(args)-1, len(args),
+		))
+	}
+
+	args = append(args, query.Limit+1)
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT `+transactionColumns+`
+		FROM ethereum_transactions
+		WHERE %s
+		ORDER BY block_number %[2]s, transaction_index %[2]s, hash %[2]s
+		LIMIT $%[3]d`,
+		strings.Join(conditions, " AND "), order, len(args),
+	), args...)
+	if err != nil {
+		return parser.TransactionPage{}, err
+	}
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return parser.TransactionPage{}, err
+	}
+	return parser.NewTransactionPage(transactions, query.Limit), nil
+}
+
+// filterConditions returns the conditions selecting the transactions of address the filter
+// keeps, along with their arguments.
+func filterConditions(address evm.Address, filter parser.TransactionFilter) ([]string, []any) {
+	var (
+		conditions = []string{"address = $1"}
+		args       = []any{address}
+	)
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.FromBlock != nil {
+		add("block_number >= $%d", int64(*filter.FromBlock))
+	}
+	if filter.ToBlock != nil {
+		add("block_number <= $%d", int64(*filter.ToBlock))
+	}
+	if !filter.Since.IsZero() {
+		add("block_timestamp >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		// the transactions of unknown time are kept, as TransactionFilter.Match does
+		add("(block_timestamp < $%d OR block_timestamp IS NULL)", filter.Until)
+	}
+	switch filter.Direction {
+	case parser.DirectionOutbound:
+		conditions = append(conditions, "from_address = address")
+	case parser.DirectionInbound:
+		conditions = append(conditions, "(to_address = address OR token_to = address)")
+	}
+	if filter.Counterparty != "" {
+		// the counterparty as parser.Counterparty tells it
+		add(`CASE
+			WHEN from_address <> address THEN from_address
+			WHEN token_contract IS NOT NULL THEN token_to
+			ELSE to_address
+		END = $%d`, filter.Counterparty)
+	}
+	if filter.MinValue != nil {
+		add("value >= $%d", filter.MinValue.String())
+	}
+	if filter.MaxValue != nil {
+		add("value <= $%d", filter.MaxValue.String())
+	}
+	if filter.Status != parser.TransactionStatusUnknown {
+		add("status = $%d", string(filter.Status))
+	}
+	return conditions, args
+}
+
+// scanTransactions reads and closes rows of transactionColumns.
+func scanTransactions(rows *sql.Rows) ([]parser.Transaction, error) {
+	defer rows.Close()
+
+	var transactions []parser.Transaction
+	for rows.Next() {
+		var row transactionRow
+		err := rows.Scan(
+			&row.hash, &row.blockNumber, &row.transactionIndex, &row.blockTimestamp, &row.from, &row.to, &row.value, &row.nonce, &row.status, &row.fee,
+			&row.fiatCurrency, &row.fiatAmount,
+			&row.tokenContract, &row.tokenTo, &row.tokenAmount, &row.tokenFiatCurrency, &row.tokenFiatAmount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		transaction, err := row.transaction()
+		if err != nil {
+			return nil, fmt.Errorf("reading transaction %s: %w", row.hash, err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, rows.Err()
+}
+
+// transactionRow is a transaction as stored in the ethereum_transactions table, with the
+// amounts as base 10 numerics and the optional token transfer and fiat values nullable.
+type transactionRow struct {
+	address           evm.Address
+	hash              string
+	blockNumber       int64
+	transactionIndex  int64
+	blockTimestamp    sql.NullTime
+	from              string
+	to                string
+	value             string
+	nonce             int64
+	status            string
+	fee               string
+	fiatCurrency      sql.NullString
+	fiatAmount        sql.NullString
+	tokenContract     sql.NullString
+	tokenTo           sql.NullString
+	tokenAmount       sql.NullString
+	tokenFiatCurrency sql.NullString
+	tokenFiatAmount   sql.NullString
+}
+
+func newTransactionRow(address evm.Address, tx parser.Transaction) transactionRow {
+	row := transactionRow{
+		address:          address,
+		hash:             tx.Hash,
+		blockNumber:      int64(tx.BlockNumber),
+		transactionIndex: int64(tx.TransactionIndex),
+		from:             string(tx.From),
+		to:               string(tx.To),
+		value:            tx.Value.String(),
+		nonce:            int64(tx.Nonce),
+		status:           string(tx.Status),
+		fee:              tx.Fee.String(),
+	}
+	if !tx.Timestamp.IsZero() {
+		row.blockTimestamp = sql.NullTime{Time: tx.Timestamp, Valid: true}
+	}
+	if tx.Fiat != nil {
+		row.fiatCurrency = nullString(tx.Fiat.Currency)
+		row.fiatAmount = nullString(tx.Fiat.Amount)
+	}
+	if tx.Token != nil {
+		row.tokenContract = nullString(string(tx.Token.Contract))
+		row.tokenTo = nullString(string(tx.Token.To))
+		row.tokenAmount = nullString(tx.Token.Amount.String())
+		if tx.Token.Fiat != nil {
+			row.tokenFiatCurrency = nullString(tx.Token.Fiat.Currency)
+			row.tokenFiatAmount = nullString(tx.Token.Fiat.Amount)
+		}
+	}
+	return row
+}
+
+func (row transactionRow) transaction() (parser.Transaction, error) {
+	value, err := evm.ParseDecimalQuantity(row.value)
+	if err != nil {
+		return parser.Transaction{}, err
+	}
+	fee, err := evm.ParseDecimalQuantity(row.fee)
+	if err != nil {
+		return parser.Transaction{}, err
+	}
+
+	tx := parser.Transaction{
+		Hash:             row.hash,
+		From:             evm.Address(row.from),
+		To:               evm.Address(row.to),
+		Value:            value,
+		BlockNumber:      evm.BlockNumber(row.blockNumber),
+		TransactionIndex: evm.TransactionIndex(row.transactionIndex),
+		Nonce:            evm.Nonce(row.nonce),
+		Status:           parser.TransactionStatus(row.status),
+		Fee:              fee,
+	}
+	if row.blockTimestamp.Valid {
+		tx.Timestamp = row.blockTimestamp.Time.UTC()
+	}
+	if row.fiatCurrency.Valid {
+		tx.Fiat = &parser.FiatValue{Currency: row.fiatCurrency.String, Amount: row.fiatAmount.String}
+	}
+	if row.tokenContract.Valid {
+		amount, err := evm.ParseDecimalQuantity(row.tokenAmount.String)
+		if err != nil {
+			return parser.Transaction{}, err
+		}
+		tx.Token = &parser.TokenTransfer{
+			Contract: evm.Address(row.tokenContract.String),
+			To:       evm.Address(row.tokenTo.String),
+			Amount:   amount,
+		}
+		if row.tokenFiatCurrency.Valid {
+			tx.Token.Fiat = &parser.FiatValue{Currency: row.tokenFiatCurrency.String, Amount: row.tokenFiatAmount.String}
+		}
+	}
+	return tx, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
@@ -0,0 +1,189 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/test/evmtest"
+)
+
+// postgresDSNEnv is the database the integration tests run against, they are skipped when unset.
+const postgresDSNEnv = "POSTGRES_TEST_DSN"
+
+func TestRepository_NewPostgresStorage(t *testing.T) {
+	db := openPostgres(t)
+
+	var (
+		ctx = context.Background()
+
+		tx1 = parser.Transaction{
+			Hash:        "h1",
+			From:        evmtest.EVMZeroValueAddress,
+			To:          "0xabc",
+			Value:       evm.QuantityFromUint64(10),
+			BlockNumber: 1,
+			Nonce:       3,
+			Status:      parser.TransactionStatusSuccess,
+			Fee:         evm.QuantityFromUint64(21000),
+			Fiat:        &parser.FiatValue{Currency: "USD", Amount: "1834.21"},
+		}
+		tx2 = parser.Transaction{
+			Hash:        "h2",
+			From:        "0xdef",
+			To:          evmtest.EVMZeroValueAddress,
+			Value:       evm.QuantityFromUint64(20),
+			BlockNumber: 2,
+			Fee:         evm.QuantityFromUint64(21000),
+			Token: &parser.TokenTransfer{
+				Contract: "0xdef",
+				To:       evmtest.EVMZeroValueAddress,
+				Amount:   evm.QuantityFromUint64(30),
+			},
+		}
+	)
+
+	repo, err := repository.NewPostgresStorage(ctx, db)
+	if err != nil {
+		t.Fatalf("NewPostgresStorage: unexpected error: %v", err)
+	}
+
+	t.Run("addresses", func(t *testing.T) {
+		if err := repo.AddAddress(ctx, evmtest.EVMZeroValueAddress); err != nil {
+			t.Fatalf("AddAddress(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
+		}
+		if err := repo.AddAddress(ctx, evmtest.EVMZeroValueAddress); !errors.Is(err, ethereum.ErrAddressConflict) {
+			t.Errorf("second AddAddress(%q): expected error %v, got %v", evmtest.EVMZeroValueAddress, ethereum.ErrAddressConflict, err)
+		}
+		subscribed, err := repo.HasAddress(ctx, evmtest.EVMZeroValueAddress)
+		if err != nil || !subscribed {
+			t.Errorf("HasAddress(%q): expected true, got %t, %v", evmtest.EVMZeroValueAddress, subscribed, err)
+		}
+		subscribed, err = repo.HasAddress(ctx, "0xabc")
+		if err != nil || subscribed {
+			t.Errorf("HasAddress(%q): expected false, got %t, %v", "0xabc", subscribed, err)
+		}
+		if got, err := repo.GetAddresses(ctx); err != nil || !reflect.DeepEqual(got, []evm.Address{evmtest.EVMZeroValueAddress}) {
+			t.Errorf("GetAddresses(): got %v, %v", got, err)
+		}
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		// saving tx1 again is a no-op, and the empty block 3 moves the cursor
+		for _, tx := range []parser.Transaction{tx1, tx2, tx1} {
+			if err := repo.SaveBlock(ctx, tx.BlockNumber, map[evm.Address][]parser.Transaction{evmtest.EVMZeroValueAddress: {tx}}); err != nil {
+				t.Fatalf("SaveBlock(%d): unexpected error: %v", tx.BlockNumber, err)
+			}
+		}
+		if err := repo.SaveBlock(ctx, 3, nil); err != nil {
+			t.Fatalf("SaveBlock(3): unexpected error: %v", err)
+		}
+
+		txs, err := repo.GetTransactions(ctx, evmtest.EVMZeroValueAddress)
+		if err != nil {
+			t.Fatalf("GetTransactions(%q): unexpected error: %v", evmtest.EVMZeroValueAddress, err)
+		}
+		want := []parser.Transaction{tx1, tx2}
+		// amounts read back from numerics hold equal but differently allocated big ints
+		if gotJSON, wantJSON := mustJSON(t, txs), mustJSON(t, want); gotJSON != wantJSON {
+			t.Errorf("GetTransactions(%q):\n got %+v\nwant %+v", evmtest.EVMZeroValueAddress, txs, want)
+		}
+		if got, err := repo.GetLastParsedBlock(ctx); err != nil || got != 3 {
+			t.Errorf("GetLastParsedBlock(): expected 3, got %d, %v", got, err)
+		}
+	})
+
+	t.Run("migrations run once", func(t *testing.T) {
+		if _, err := repository.NewPostgresStorage(ctx, db); err != nil {
+			t.Fatalf("NewPostgresStorage: unexpected error on an up to date schema: %v", err)
+		}
+		if txs, err := repo.GetTransactions(ctx, evmtest.EVMZeroValueAddress); err != nil || len(txs) != 2 {
+			t.Errorf("GetTransactions(%q): expected the data to be kept, got %v, %v", evmtest.EVMZeroValueAddress, txs, err)
+		}
+	})
+}
+
+func TestRepository_PostgresQueryTransactions(t *testing.T) {
+	repo, err := repository.NewPostgresStorage(context.Background(), openPostgres(t))
+	if err != nil {
+		t.Fatalf("NewPostgresStorage: unexpected error: %v", err)
+	}
+	testQueryTransactions(t, repo)
+}
+
+func TestRepository_PostgresFilterTransactions(t *testing.T) {
+	repo, err := repository.NewPostgresStorage(context.Background(), openPostgres(t))
+	if err != nil {
+		t.Fatalf("NewPostgresStorage: unexpected error: %v", err)
+	}
+	testFilterTransactions(t, repo)
+}
+
+func TestRepository_PostgresRemoveAddress(t *testing.T) {
+	repo, err := repository.NewPostgresStorage(context.Background(), openPostgres(t))
+	if err != nil {
+		t.Fatalf("NewPostgresStorage: unexpected error: %v", err)
+	}
+	testRemoveAddress(t, repo)
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("encoding %v: %v", v, err)
+	}
+	return string(data)
+}
+
+// openPostgres connects to the test database in a schema of its own, dropped at the end
+// of the test, or skips the test when no database is configured.
+func openPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set, skipping the PostgreSQL integration tests", postgresDSNEnv)
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("opening %s: %v", postgresDSNEnv, err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("creating schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() { admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	// lib/pq passes unknown settings on to the server as run-time parameters
+	separator := " "
+	if strings.Contains(dsn, "://") {
+		separator = "&"
+		if !strings.Contains(dsn, "?") {
+			separator = "?"
+		}
+	}
+	db, err := sql.Open("postgres", dsn+separator+"search_path="+schema)
+	if err != nil {
+		t.Fatalf("opening %s: %v", postgresDSNEnv, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+// The memory held by the memory storage is estimated from the length of the strings of the
+// transactions, the structs, amounts and map entries around them being approximated by fixed
+// overheads.
+const (
+	// transactionOverhead covers a parser.Transaction with its amounts and its txs, blocks and
+	// refs entries.
+	transactionOverhead = 384
+	// tokenOverhead and fiatOverhead cover the optional parts of a transaction.
+	tokenOverhead = 96
+	fiatOverhead  = 48
+	// indexEntryOverhead covers a transaction in the index of an address: its positions and
+	// counterparties entries and its saved entry.
+	indexEntryOverhead = 160
+)
+
+func transactionSize(tx parser.Transaction) int64 {
+	size := transactionOverhead + len(tx.Hash) + len(tx.From) + len(tx.To) + fiatSize(tx.Fiat)
+	if tx.Token != nil {
+		size += tokenOverhead + len(tx.Token.Contract) + len(tx.Token.To) + fiatSize(tx.Token.Fiat)
+	}
+	return int64(size)
+}
+
+func fiatSize(fiat *parser.FiatValue) int {
+	if fiat == nil {
+		return 0
+	}
+	return fiatOverhead + len(fiat.Currency) + len(fiat.Amount)
+}
+
+// holding is a transaction in the index of an address.
+type holding struct {
+	address evm.Address
+	hash    string
+}
+
+// Evictions applies the age and per address bounds to the index of each address, and then
+// evicts the oldest blocks from every address until the memory left fits in the policy.
+func (r *repository) Evictions(_ context.Context, policy ethereum.RetentionPolicy, now time.Time) ([]ethereum.Eviction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var (
+		evictions []ethereum.Eviction
+		evicted   = make(map[holding]struct{})
+		// refs counts the addresses left holding the transactions once evicted
+		refs = make(map[string]int)
+		size = r.size
+	)
+	evict := func(address evm.Address, hash string, reason ethereum.EvictionReason) {
+		tx := r.txs[hash]
+		evictions = append(evictions, ethereum.Eviction{Address: address, Transaction: tx, Reason: reason})
+		evicted[holding{address: address, hash: hash}] = struct{}{}
+
+		left, ok := refs[hash]
+		if !ok {
+			left = r.refs[hash]
+		}
+		refs[hash] = left - 1
+
+		size -= indexEntryOverhead
+		if left == 1 {
+			size -= transactionSize(tx)
+		}
+	}
+
+	cutoff := now.Add(-policy.MaxAge)
+	for address, index := range r.indexes {
+		over := len(index.positions) - policy.MaxTransactionsPerAddress
+		if policy.MaxTransactionsPerAddress <= 0 {
+			over = 0
+		}
+
+	positions:
+		for i, position := range index.positions {
+			tx := r.txs[position.Hash]
+			switch {
+			case policy.MaxAge > 0 && !tx.Timestamp.IsZero() && tx.Timestamp.Before(cutoff):
+				evict(address, position.Hash, ethereum.EvictedByAge)
+			case i < over:
+				evict(address, position.Hash, ethereum.EvictedByAddressCap)
+			case policy.MaxAge <= 0 || !tx.Timestamp.IsZero():
+				// the next transactions are more recent
+				break positions
+			}
+		}
+	}
+
+	if policy.MaxBytes > 0 && size > policy.MaxBytes {
+	blocks:
+		for _, blockNumber := range slices.Sorted(maps.Keys(r.blocks)) {
+			for _, hash := range r.blocks[blockNumber] {
+				if size <= policy.MaxBytes {
+					break blocks
+				}
+				for address, index := range r.indexes {
+					_, held := index.saved[hash]
+					if _, gone := evicted[holding{address: address, hash: hash}]; held && !gone {
+						evict(address, hash, ethereum.EvictedByMemory)
+					}
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(evictions, func(a, b ethereum.Eviction) int {
+		if c := parser.PositionOf(a.Transaction).Compare(parser.PositionOf(b.Transaction)); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Address), string(b.Address))
+	})
+	return evictions, nil
+}
+
+func (r *repository) Evict(_ context.Context, evictions []ethereum.Eviction) ([]ethereum.Eviction, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the evicted positions are filtered out of each index in a single pass
+	var removed []ethereum.Eviction
+	hashes := make(map[evm.Address]map[string]struct{})
+	for _, eviction := range evictions {
+		index, ok := r.indexes[eviction.Address]
+		if !ok {
+			continue
+		}
+		hash := eviction.Transaction.Hash
+		if _, saved := index.saved[hash]; !saved {
+			continue
+		}
+		delete(index.saved, hash)
+		removed = append(removed, eviction)
+
+		if hashes[eviction.Address] == nil {
+			hashes[eviction.Address] = make(map[string]struct{})
+		}
+		hashes[eviction.Address][hash] = struct{}{}
+	}
+
+	size := r.size
+	for address, evicted := range hashes {
+		isEvicted := func(position parser.Position) bool {
+			_, ok := evicted[position.Hash]
+			return ok
+		}
+
+		index := r.indexes[address]
+		index.positions = slices.DeleteFunc(index.positions, isEvicted)
+		for counterparty, positions := range index.counterparties {
+			if positions = slices.DeleteFunc(positions, isEvicted); len(positions) > 0 {
+				index.counterparties[counterparty] = positions
+			} else {
+				delete(index.counterparties, counterparty)
+			}
+		}
+
+		for hash := range evicted {
+			r.release(hash)
+		}
+	}
+	return removed, size - r.size, nil
+}
+
+func (r *repository) Size(_ context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.size, nil
+}
@@ -0,0 +1,180 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+func TestRepository_Evictions(t *testing.T) {
+	const (
+		alice = evm.Address("0x1111111111111111111111111111111111111111")
+		bob   = evm.Address("0x2222222222222222222222222222222222222222")
+	)
+
+	now := time.Date(2025, time.April, 26, 8, 0, 0, 0, time.UTC)
+
+	// alice sends bob a transaction an hour, the first one of unknown time
+	newRepo := func(t *testing.T) ethereum.Repository {
+		t.Helper()
+
+		ctx := context.Background()
+		repo := repository.NewMemoryStorage()
+		for _, address := range []evm.Address{alice, bob} {
+			if err := repo.AddAddress(ctx, address); err != nil {
+				t.Fatalf("AddAddress(%q): unexpected error: %v", address, err)
+			}
+		}
+
+		for i := 1; i <= 5; i++ {
+			tx := parser.Transaction{
+				Hash:        fmt.Sprintf("0x%d", i),
+				From:        alice,
+				To:          bob,
+				Value:       evm.QuantityFromUint64(uint64(i)),
+				BlockNumber: evm.BlockNumber(i),
+			}
+			if i > 1 {
+				tx.Timestamp = now.Add(time.Duration(i-6) * time.Hour)
+			}
+			if err := repo.SaveBlock(ctx, tx.BlockNumber, map[evm.Address][]parser.Transaction{alice: {tx}, bob: {tx}}); err != nil {
+				t.Fatalf("SaveBlock(%d): unexpected error: %v", tx.BlockNumber, err)
+			}
+		}
+		return repo
+	}
+
+	size := func(t *testing.T, repo ethereum.Repository) int64 {
+		t.Helper()
+
+		size, err := repo.(ethereum.Evicter).Size(context.Background())
+		if err != nil {
+			t.Fatalf("Size: unexpected error: %v", err)
+		}
+		return size
+	}
+
+	testCases := []struct {
+		name   string
+		policy func(full int64) ethereum.RetentionPolicy
+		// want lists the evicted transactions, each of them evicted from both addresses
+		want   []string
+		reason ethereum.EvictionReason
+	}{
+		{
+			name:   "no bound",
+			policy: func(int64) ethereum.RetentionPolicy { return ethereum.RetentionPolicy{} },
+		},
+		{
+			name:   "max age keeps the transactions of unknown time",
+			policy: func(int64) ethereum.RetentionPolicy { return ethereum.RetentionPolicy{MaxAge: 150 * time.Minute} },
+			want:   []string{"0x2", "0x3"},
+			reason: ethereum.EvictedByAge,
+		},
+		{
+			name:   "max transactions per address",
+			policy: func(int64) ethereum.RetentionPolicy { return ethereum.RetentionPolicy{MaxTransactionsPerAddress: 2} },
+			want:   []string{"0x1", "0x2", "0x3"},
+			reason: ethereum.EvictedByAddressCap,
+		},
+		{
+			name:   "max bytes evicts the oldest blocks",
+			policy: func(full int64) ethereum.RetentionPolicy { return ethereum.RetentionPolicy{MaxBytes: full * 3 / 5} },
+			want:   []string{"0x1", "0x2"},
+			reason: ethereum.EvictedByMemory,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				ctx = context.Background()
+
+				repo    = newRepo(t)
+				evicter = repo.(ethereum.Evicter)
+				full    = size(t, repo)
+				policy  = tc.policy(full)
+			)
+
+			evictions, err := evicter.Evictions(ctx, policy, now)
+			if err != nil {
+				t.Fatalf("Evictions: unexpected error: %v", err)
+			}
+			if len(evictions) != 2*len(tc.want) {
+				t.Fatalf("Evictions(%+v): expected %v from both addresses, got %+v", policy, tc.want, evictions)
+			}
+			for i, eviction := range evictions {
+				want := ethereum.Eviction{Address: []evm.Address{alice, bob}[i%2], Reason: tc.reason}
+				if eviction.Transaction.Hash != tc.want[i/2] || eviction.Address != want.Address || eviction.Reason != want.Reason {
+					t.Errorf("Evictions(%+v)[%d]: expected %s of %s by %s, got %+v", policy, i, tc.want[i/2], want.Address, want.Reason, eviction)
+				}
+			}
+
+			removed, freed, err := evicter.Evict(ctx, evictions)
+			if err != nil {
+				t.Fatalf("Evict: unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(removed, evictions) {
+				t.Errorf("Evict: expected every eviction to be removed, got %+v", removed)
+			}
+			if left := size(t, repo); left != full-freed || (policy.MaxBytes > 0 && left > policy.MaxBytes) {
+				t.Errorf("Evict: expected %d bytes left out of %d, got %d", full-freed, full, left)
+			}
+
+			for _, address := range []evm.Address{alice, bob} {
+				txs, err := repo.GetTransactions(ctx, address)
+				if err != nil || len(txs) != 5-len(tc.want) {
+					t.Errorf("GetTransactions(%q): expected %d transactions, got %+v, %v", address, 5-len(tc.want), txs, err)
+				}
+			}
+
+			// the counterparty index drops the evicted transactions too
+			page, err := repo.QueryTransactions(ctx, alice, parser.TransactionQuery{Limit: 10, Filter: parser.TransactionFilter{Counterparty: bob}})
+			if err != nil || len(page.Transactions) != 5-len(tc.want) {
+				t.Errorf("QueryTransactions(%q): expected %d transactions with %q, got %+v, %v", alice, 5-len(tc.want), bob, page.Transactions, err)
+			}
+		})
+	}
+
+	t.Run("shared transactions are freed with their last holder", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			repo    = newRepo(t)
+			evicter = repo.(ethereum.Evicter)
+			full    = size(t, repo)
+		)
+
+		evictions, err := evicter.Evictions(ctx, ethereum.RetentionPolicy{MaxTransactionsPerAddress: 4}, now)
+		if err != nil || len(evictions) != 2 {
+			t.Fatalf("Evictions: expected 0x1 from both addresses, got %+v, %v", evictions, err)
+		}
+
+		_, aliceFreed, err := evicter.Evict(ctx, evictions[:1])
+		if err != nil {
+			t.Fatalf("Evict: unexpected error: %v", err)
+		}
+		_, bobFreed, err := evicter.Evict(ctx, evictions[1:])
+		if err != nil {
+			t.Fatalf("Evict: unexpected error: %v", err)
+		}
+		if bobFreed <= aliceFreed {
+			t.Errorf("Evict: expected the last holder to free the transaction, freed %d then %d", aliceFreed, bobFreed)
+		}
+
+		// evicting again is a no-op
+		if removed, freed, err := evicter.Evict(ctx, evictions); err != nil || len(removed) != 0 || freed != 0 {
+			t.Errorf("Evict: expected nothing removed nor freed, got %+v, %d, %v", removed, freed, err)
+		}
+		if left := size(t, repo); left != full-aliceFreed-bobFreed {
+			t.Errorf("Size: expected %d, got %d", full-aliceFreed-bobFreed, left)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/svcerrors"
+)
+
+var ErrRetentionDisabled = fmt.Errorf("%w: error no retention policy configured", svcerrors.ErrNotFound)
+
+// RetentionPolicy bounds the transactions kept by a repository, a zero bound being unset.
+type RetentionPolicy struct {
+	// MaxAge evicts the transactions of older blocks, the ones of unknown time being kept.
+	MaxAge time.Duration
+	// MaxTransactionsPerAddress keeps the latest transactions of each address.
+	MaxTransactionsPerAddress int
+	// MaxBytes bounds the estimated memory held by the transactions, evicting the oldest blocks first.
+	MaxBytes int64
+}
+
+// Enabled tells whether any bound is set.
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxTransactionsPerAddress > 0 || p.MaxBytes > 0
+}
+
+// EvictionReason is the bound of the retention policy evicting a transaction.
+type EvictionReason string
+
+const (
+	EvictedByAge        EvictionReason = "age"
+	EvictedByAddressCap EvictionReason = "address_cap"
+	EvictedByMemory     EvictionReason = "memory"
+)
+
+// Eviction is a transaction removed from an address by the retention policy, the transaction
+// itself being dropped once no address holds it anymore.
+type Eviction struct {
+	Address     evm.Address
+	Transaction parser.Transaction
+	Reason      EvictionReason
+}
+
+// Evicter is implemented by the repositories a retention policy can be enforced on.
+type Evicter interface {
+	// Evictions lists what the policy evicts at now, ordered by parser.Position.
+	Evictions(ctx context.Context, policy RetentionPolicy, now time.Time) ([]Eviction, error)
+	// Evict removes the transactions from their addresses, skipping the ones already gone, and
+	// returns the evictions it removed along with the estimated bytes freed.
+	Evict(ctx context.Context, evictions []Eviction) ([]Eviction, int64, error)
+	// Size is the estimated memory held by the transactions.
+	Size(ctx context.Context) (int64, error)
+}
+
+type Archiver interface {
+	// keep the evictions before they are removed
+	Archive(ctx context.Context, evictions []Eviction) error
+}
+
+type Compactor interface {
+	// evict what the retention policy no longer keeps, archiving it first when an archiver is set
+	Compact(ctx context.Context) error
+
+	// what the compactions pruned so far
+	Metrics() parser.CompactionMetrics
+}
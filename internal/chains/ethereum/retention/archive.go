@@ -0,0 +1,70 @@
+package retention
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+// archivedTransaction is a line of the archive file.
+type archivedTransaction struct {
+	Address     evm.Address             `json:"address"`
+	Reason      ethereum.EvictionReason `json:"reason"`
+	EvictedAt   time.Time               `json:"evictedAt"`
+	Transaction parser.Transaction      `json:"transaction"`
+}
+
+type fileArchiver struct {
+	path string
+}
+
+// NewFileArchiver appends the evictions to the file at path as JSON lines, one per evicted
+// transaction and address, creating it along with its directory when missing.
+func NewFileArchiver(path string) (ethereum.Archiver, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return &fileArchiver{
+		path: path,
+	}, nil
+}
+
+// Archive returns once the evictions are synced to disk, a failed archive possibly leaving
+// part of them in the file to be archived again by the next compaction.
+func (a *fileArchiver) Archive(_ context.Context, evictions []ethereum.Eviction) error {
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	evictedAt := time.Now().UTC()
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for _, eviction := range evictions {
+		err := encoder.Encode(archivedTransaction{
+			Address:     eviction.Address,
+			Reason:      eviction.Reason,
+			EvictedAt:   evictedAt,
+			Transaction: eviction.Transaction,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,129 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+type compactor struct {
+	repo     ethereum.Evicter
+	ledger   ethereum.Ledger
+	archiver ethereum.Archiver
+	policy   ethereum.RetentionPolicy
+	logger   *log.Logger
+
+	mu      sync.Mutex
+	metrics parser.CompactionMetrics
+}
+
+// NewCompactor enforces the policy on the repository, archiver is optional and evicted
+// transactions are discarded when it is nil.
+func NewCompactor(
+	repo ethereum.Evicter,
+	ledger ethereum.Ledger,
+	archiver ethereum.Archiver,
+	policy ethereum.RetentionPolicy,
+	logger *log.Logger,
+) ethereum.Compactor {
+	return &compactor{
+		repo:     repo,
+		ledger:   ledger,
+		archiver: archiver,
+		policy:   policy,
+		logger:   logger,
+	}
+}
+
+// Compact removes nothing unless it was archived first. The running balances of the addresses
+// losing transactions are forgotten beforehand, so they reopen past the evicted transactions
+// instead of missing them.
+func (c *compactor) Compact(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	evictions, err := c.repo.Evictions(ctx, c.policy, now)
+	if err != nil {
+		c.logger.Printf("error listing evictions: %v\n", err)
+		c.fail(now)
+		return err
+	}
+
+	if len(evictions) > 0 && c.archiver != nil {
+		if err := c.archiver.Archive(ctx, evictions); err != nil {
+			c.logger.Printf("error archiving %d evictions: %v\n", len(evictions), err)
+			c.fail(now)
+			return err
+		}
+	}
+
+	forgotten := make(map[evm.Address]struct{})
+	for _, eviction := range evictions {
+		if _, ok := forgotten[eviction.Address]; !ok {
+			forgotten[eviction.Address] = struct{}{}
+			c.ledger.Forget(eviction.Address)
+		}
+	}
+
+	// the transactions gone since they were listed, unsubscribed meanwhile, aren't counted
+	removed, freed, err := c.repo.Evict(ctx, evictions)
+	if err != nil {
+		c.logger.Printf("error evicting %d transactions: %v\n", len(evictions), err)
+		c.fail(now)
+		return err
+	}
+
+	retained, err := c.repo.Size(ctx)
+	if err != nil {
+		c.logger.Printf("error estimating the retained size: %v\n", err)
+		c.fail(now)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.metrics.Runs++
+	c.metrics.LastRun = now
+	c.metrics.FreedBytes += freed
+	c.metrics.RetainedBytes = retained
+	if c.archiver != nil {
+		c.metrics.Archived += len(evictions)
+	}
+	for _, eviction := range removed {
+		switch eviction.Reason {
+		case ethereum.EvictedByAge:
+			c.metrics.EvictedByAge++
+		case ethereum.EvictedByAddressCap:
+			c.metrics.EvictedByAddressCap++
+		case ethereum.EvictedByMemory:
+			c.metrics.EvictedByMemory++
+		}
+	}
+
+	c.logger.Printf(
+		"[INFO] compaction evicted %d transactions freeing ~%d bytes, ~%d bytes retained; totals: %d by age, %d by address cap, %d by memory, %d archived\n",
+		len(removed), freed, retained, c.metrics.EvictedByAge, c.metrics.EvictedByAddressCap, c.metrics.EvictedByMemory, c.metrics.Archived,
+	)
+	return nil
+}
+
+func (c *compactor) fail(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.metrics.Runs++
+	c.metrics.Failures++
+	c.metrics.LastRun = now
+}
+
+func (c *compactor) Metrics() parser.CompactionMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.metrics
+}
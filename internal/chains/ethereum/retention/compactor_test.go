@@ -0,0 +1,225 @@
+package retention_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/retention"
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/test"
+	"github.com/jeronimobarea/transaction_parser/internal/test/ethereumtest"
+)
+
+const (
+	alice = evm.Address("0x1111111111111111111111111111111111111111")
+	bob   = evm.Address("0x2222222222222222222222222222222222222222")
+)
+
+type failingArchiver struct{}
+
+func (failingArchiver) Archive(_ context.Context, _ []ethereum.Eviction) error {
+	return test.DummyErr
+}
+
+// unsubscribingArchiver purges the address while its evictions are archived, as an
+// unsubscribe racing the compaction would.
+type unsubscribingArchiver struct {
+	repo    ethereum.Repository
+	address evm.Address
+}
+
+func (a unsubscribingArchiver) Archive(ctx context.Context, _ []ethereum.Eviction) error {
+	return a.repo.RemoveAddress(ctx, a.address, true)
+}
+
+// newRepository saves three transactions from alice to bob, alice being the only subscribed address.
+func newRepository(t *testing.T) ethereum.Repository {
+	t.Helper()
+
+	ctx := context.Background()
+	repo := repository.NewMemoryStorage()
+	if err := repo.AddAddress(ctx, alice); err != nil {
+		t.Fatalf("AddAddress(%q): unexpected error: %v", alice, err)
+	}
+
+	for _, hash := range []string{"0xa", "0xb", "0xc"} {
+		blockNumber := evm.BlockNumber(hash[2] - 'a' + 1)
+		tx := parser.Transaction{Hash: hash, From: alice, To: bob, Value: evm.QuantityFromUint64(1), BlockNumber: blockNumber}
+		if err := repo.SaveBlock(ctx, blockNumber, map[evm.Address][]parser.Transaction{alice: {tx}}); err != nil {
+			t.Fatalf("SaveBlock(%d): unexpected error: %v", blockNumber, err)
+		}
+	}
+	return repo
+}
+
+func TestCompactor_Compact(t *testing.T) {
+	var (
+		logger = log.New(io.Discard, "", 0)
+
+		policy = ethereum.RetentionPolicy{MaxTransactionsPerAddress: 1}
+	)
+
+	t.Run("archives and evicts", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			repo   = newRepository(t)
+			ledger = &ethereumtest.FakeLedger{}
+			path   = filepath.Join(t.TempDir(), "archive", "evicted.jsonl")
+		)
+
+		archiver, err := retention.NewFileArchiver(path)
+		if err != nil {
+			t.Fatalf("NewFileArchiver: unexpected error: %v", err)
+		}
+		compactor := retention.NewCompactor(repo.(ethereum.Evicter), ledger, archiver, policy, logger)
+
+		if err := compactor.Compact(ctx); err != nil {
+			t.Fatalf("Compact: unexpected error: %v", err)
+		}
+
+		txs, err := repo.GetTransactions(ctx, alice)
+		if err != nil || len(txs) != 1 || txs[0].Hash != "0xc" {
+			t.Errorf("GetTransactions(%q): expected only 0xc to be kept, got %+v, %v", alice, txs, err)
+		}
+		if !reflect.DeepEqual(ledger.Forgotten, []evm.Address{alice}) {
+			t.Errorf("Compact: expected the balance of %q to be forgotten once, got %v", alice, ledger.Forgotten)
+		}
+
+		archived := readArchive(t, path)
+		if len(archived) != 2 || archived[0].Transaction.Hash != "0xa" || archived[1].Transaction.Hash != "0xb" {
+			t.Fatalf("archive: expected 0xa and 0xb, got %+v", archived)
+		}
+		if archived[0].Address != alice || archived[0].Reason != ethereum.EvictedByAddressCap || archived[0].Transaction.To != bob {
+			t.Errorf("archive: unexpected line %+v", archived[0])
+		}
+
+		metrics := compactor.Metrics()
+		if metrics.Runs != 1 || metrics.Failures != 0 || metrics.EvictedByAddressCap != 2 || metrics.Archived != 2 {
+			t.Errorf("Metrics: expected 2 transactions evicted and archived in a run, got %+v", metrics)
+		}
+		if size, _ := repo.(ethereum.Evicter).Size(ctx); metrics.FreedBytes <= 0 || metrics.RetainedBytes != size {
+			t.Errorf("Metrics: expected the freed bytes and %d retained, got %+v", size, metrics)
+		}
+
+		// nothing left to evict, the archive is kept as it is
+		if err := compactor.Compact(ctx); err != nil {
+			t.Fatalf("second Compact: unexpected error: %v", err)
+		}
+		if archived := readArchive(t, path); len(archived) != 2 {
+			t.Errorf("archive: expected the 2 lines to be kept, got %+v", archived)
+		}
+		if metrics := compactor.Metrics(); metrics.Runs != 2 || metrics.EvictedByAddressCap != 2 {
+			t.Errorf("Metrics: expected a second run evicting nothing, got %+v", metrics)
+		}
+	})
+
+	t.Run("discards without an archiver", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			repo      = newRepository(t)
+			compactor = retention.NewCompactor(repo.(ethereum.Evicter), &ethereumtest.FakeLedger{}, nil, policy, logger)
+		)
+
+		if err := compactor.Compact(ctx); err != nil {
+			t.Fatalf("Compact: unexpected error: %v", err)
+		}
+		if txs, _ := repo.GetTransactions(ctx, alice); len(txs) != 1 {
+			t.Errorf("GetTransactions(%q): expected 1 transaction, got %+v", alice, txs)
+		}
+		if metrics := compactor.Metrics(); metrics.EvictedByAddressCap != 2 || metrics.Archived != 0 {
+			t.Errorf("Metrics: expected 2 transactions evicted and none archived, got %+v", metrics)
+		}
+	})
+
+	t.Run("keeps what failed to archive", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			repo      = newRepository(t)
+			ledger    = &ethereumtest.FakeLedger{}
+			compactor = retention.NewCompactor(repo.(ethereum.Evicter), ledger, failingArchiver{}, policy, logger)
+		)
+
+		if err := compactor.Compact(ctx); !errors.Is(err, test.DummyErr) {
+			t.Fatalf("Compact: expected %v, got %v", test.DummyErr, err)
+		}
+		if txs, _ := repo.GetTransactions(ctx, alice); len(txs) != 3 {
+			t.Errorf("GetTransactions(%q): expected every transaction to be kept, got %+v", alice, txs)
+		}
+		if len(ledger.Forgotten) != 0 {
+			t.Errorf("Compact: expected no balance to be forgotten, got %v", ledger.Forgotten)
+		}
+		metrics := compactor.Metrics()
+		if metrics.Runs != 1 || metrics.Failures != 1 || metrics.EvictedByAddressCap != 0 || metrics.Archived != 0 {
+			t.Errorf("Metrics: expected a failed run, got %+v", metrics)
+		}
+		if metrics.FreedBytes != 0 || metrics.RetainedBytes != 0 {
+			t.Errorf("Metrics: expected no bytes counted as freed, got %+v", metrics)
+		}
+	})
+
+	t.Run("counts only what was removed", func(t *testing.T) {
+		var (
+			ctx = context.Background()
+
+			repo      = newRepository(t)
+			archiver  = unsubscribingArchiver{repo: repo, address: alice}
+			compactor = retention.NewCompactor(repo.(ethereum.Evicter), &ethereumtest.FakeLedger{}, archiver, policy, logger)
+		)
+
+		if err := compactor.Compact(ctx); err != nil {
+			t.Fatalf("Compact: unexpected error: %v", err)
+		}
+
+		metrics := compactor.Metrics()
+		if metrics.Runs != 1 || metrics.Archived != 2 {
+			t.Errorf("Metrics: expected the 2 evictions archived in a run, got %+v", metrics)
+		}
+		if metrics.EvictedByAddressCap != 0 || metrics.FreedBytes != 0 {
+			t.Errorf("Metrics: expected the purged transactions not to count as evicted, got %+v", metrics)
+		}
+	})
+}
+
+type archivedTransaction struct {
+	Address     evm.Address             `json:"address"`
+	Reason      ethereum.EvictionReason `json:"reason"`
+	Transaction parser.Transaction      `json:"transaction"`
+}
+
+func readArchive(t *testing.T, path string) []archivedTransaction {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("opening the archive: %v", err)
+	}
+	defer f.Close()
+
+	var archived []archivedTransaction
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line archivedTransaction
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("decoding %q: %v", scanner.Text(), err)
+		}
+		archived = append(archived, line)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading the archive: %v", err)
+	}
+	return archived
+}
@@ -0,0 +1,23 @@
+package retention
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/pollers"
+)
+
+type compactionPoller struct {
+	compactor ethereum.Compactor
+}
+
+// NewCompactionPoller adapts the compaction to a poller so it can be scheduled with a runner.
+func NewCompactionPoller(compactor ethereum.Compactor) pollers.Poller {
+	return &compactionPoller{
+		compactor: compactor,
+	}
+}
+
+func (p *compactionPoller) Poll(ctx context.Context) error {
+	return p.compactor.Compact(ctx)
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/svcerrors"
+)
+
+var ErrInvalidFilter = fmt.Errorf("%w: error invalid filter", svcerrors.ErrBadRequest)
+
+// maxBlockNumber bounds the block numbers of a filter to what every repository can store,
+// postgres keeping them as signed integers.
+const maxBlockNumber = evm.BlockNumber(math.MaxInt64)
+
+func (f TransactionFilter) validate() error {
+	switch {
+	case f.FromBlock != nil && *f.FromBlock > maxBlockNumber:
+		return fmt.Errorf("%w: from block %d above %d", ErrInvalidFilter, *f.FromBlock, maxBlockNumber)
+	case f.ToBlock != nil && *f.ToBlock > maxBlockNumber:
+		return fmt.Errorf("%w: to block %d above %d", ErrInvalidFilter, *f.ToBlock, maxBlockNumber)
+	case f.FromBlock != nil && f.ToBlock != nil && *f.FromBlock > *f.ToBlock:
+		return fmt.Errorf("%w: block range %d to %d", ErrInvalidFilter, *f.FromBlock, *f.ToBlock)
+	case !f.Since.IsZero() && !f.Until.IsZero() && !f.Since.Before(f.Until):
+		return fmt.Errorf("%w: time range %s to %s", ErrInvalidFilter, f.Since, f.Until)
+	case f.MinValue != nil && f.MaxValue != nil && f.MinValue.Cmp(*f.MaxValue) > 0:
+		return fmt.Errorf("%w: value range %s to %s", ErrInvalidFilter, f.MinValue, f.MaxValue)
+	}
+
+	switch f.Direction {
+	case DirectionAny, DirectionInbound, DirectionOutbound:
+	default:
+		return fmt.Errorf("%w: direction %q, expected in or out", ErrInvalidFilter, f.Direction)
+	}
+
+	switch f.Status {
+	case TransactionStatusUnknown, TransactionStatusSuccess, TransactionStatusFailed:
+	default:
+		return fmt.Errorf("%w: status %q, expected success or failed", ErrInvalidFilter, f.Status)
+	}
+
+	if f.Counterparty != "" {
+		if err := f.Counterparty.Validate(); err != nil {
+			return fmt.Errorf("%w: counterparty: %w", ErrInvalidFilter, err)
+		}
+	}
+	return nil
+}
+
+// Match tells whether the filter keeps tx, a transaction of address. Repositories that can't
+// run the filter in their queries use it on the transactions they read.
+func (f TransactionFilter) Match(address evm.Address, tx Transaction) bool {
+	switch {
+	case f.FromBlock != nil && tx.BlockNumber < *f.FromBlock,
+		f.ToBlock != nil && tx.BlockNumber > *f.ToBlock,
+		!f.Since.IsZero() && tx.Timestamp.Before(f.Since),
+		!f.Until.IsZero() && !tx.Timestamp.Before(f.Until),
+		f.Direction == DirectionOutbound && tx.From != address,
+		f.Direction == DirectionInbound && tx.To != address && (tx.Token == nil || tx.Token.To != address),
+		f.Counterparty != "" && Counterparty(address, tx) != f.Counterparty,
+		f.MinValue != nil && tx.Value.Cmp(*f.MinValue) < 0,
+		f.MaxValue != nil && tx.Value.Cmp(*f.MaxValue) > 0,
+		f.Status != TransactionStatusUnknown && tx.Status != f.Status:
+		return false
+	}
+	return true
+}
+
+// Counterparty is the other end of the transaction for address: the recipient of what it
+// sent, or the sender of what it received.
+func Counterparty(address evm.Address, tx Transaction) evm.Address {
+	if tx.From != address {
+		return tx.From
+	}
+	if tx.Token != nil {
+		return tx.Token.To
+	}
+	return tx.To
+}
@@ -0,0 +1,118 @@
+package parser_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+func TestTransactionFilter_Validate(t *testing.T) {
+	var (
+		block1 = evm.BlockNumber(1)
+		block2 = evm.BlockNumber(2)
+		// overflow is the highest block number, out of postgres range
+		overflow = evm.BlockNumber(math.MaxUint64)
+		one      = evm.QuantityFromUint64(1)
+		two      = evm.QuantityFromUint64(2)
+		now      = time.Now()
+	)
+
+	testCases := []struct {
+		name   string
+		filter parser.TransactionFilter
+		fails  bool
+	}{
+		{name: "empty", filter: parser.TransactionFilter{}},
+		{name: "every bound", filter: parser.TransactionFilter{
+			FromBlock:    &block1,
+			ToBlock:      &block2,
+			Since:        now.Add(-time.Hour),
+			Until:        now,
+			Direction:    parser.DirectionInbound,
+			Counterparty: "0x1111111111111111111111111111111111111111",
+			MinValue:     &one,
+			MaxValue:     &two,
+			Status:       parser.TransactionStatusFailed,
+		}},
+		{name: "single block", filter: parser.TransactionFilter{FromBlock: &block1, ToBlock: &block1}},
+		{name: "reversed blocks", filter: parser.TransactionFilter{FromBlock: &block2, ToBlock: &block1}, fails: true},
+		{name: "from block above int64", filter: parser.TransactionFilter{FromBlock: &overflow}, fails: true},
+		{name: "to block above int64", filter: parser.TransactionFilter{ToBlock: &overflow}, fails: true},
+		{name: "empty time range", filter: parser.TransactionFilter{Since: now, Until: now}, fails: true},
+		{name: "reversed values", filter: parser.TransactionFilter{MinValue: &two, MaxValue: &one}, fails: true},
+		{name: "unknown direction", filter: parser.TransactionFilter{Direction: "sideways"}, fails: true},
+		{name: "unknown status", filter: parser.TransactionFilter{Status: "pending"}, fails: true},
+		{name: "invalid counterparty", filter: parser.TransactionFilter{Counterparty: "0xabc"}, fails: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parser.TransactionQuery{Filter: tc.filter}.Normalize()
+			if tc.fails != errors.Is(err, parser.ErrInvalidFilter) {
+				t.Errorf("Normalize(%+v): expected failure %t, got %v", tc.filter, tc.fails, err)
+			}
+		})
+	}
+}
+
+func TestTransactionFilter_Match(t *testing.T) {
+	const (
+		address = evm.Address("0x1111111111111111111111111111111111111111")
+		other   = evm.Address("0x2222222222222222222222222222222222222222")
+		token   = evm.Address("0x3333333333333333333333333333333333333333")
+	)
+
+	var (
+		block = evm.BlockNumber(10)
+		value = evm.QuantityFromUint64(100)
+		at    = time.Date(2025, time.April, 26, 8, 0, 0, 0, time.UTC)
+
+		outbound = parser.Transaction{From: address, To: other, Value: value, BlockNumber: block, Timestamp: at, Status: parser.TransactionStatusSuccess}
+		inbound  = parser.Transaction{From: other, To: address, Value: value, BlockNumber: block, Timestamp: at, Status: parser.TransactionStatusFailed}
+		transfer = parser.Transaction{From: other, To: token, BlockNumber: block, Timestamp: at, Token: &parser.TokenTransfer{Contract: token, To: address}}
+	)
+
+	testCases := []struct {
+		name   string
+		filter parser.TransactionFilter
+		tx     parser.Transaction
+		want   bool
+	}{
+		{name: "empty filter", tx: outbound, want: true},
+		{name: "block in range", filter: parser.TransactionFilter{FromBlock: &block, ToBlock: &block}, tx: outbound, want: true},
+		{name: "block before range", filter: parser.TransactionFilter{FromBlock: ptr(block + 1)}, tx: outbound},
+		{name: "block after range", filter: parser.TransactionFilter{ToBlock: ptr(block - 1)}, tx: outbound},
+		{name: "since is inclusive", filter: parser.TransactionFilter{Since: at}, tx: outbound, want: true},
+		{name: "until is exclusive", filter: parser.TransactionFilter{Until: at}, tx: outbound},
+		{name: "unknown time", filter: parser.TransactionFilter{Since: at}, tx: parser.Transaction{From: address}},
+		{name: "outbound", filter: parser.TransactionFilter{Direction: parser.DirectionOutbound}, tx: outbound, want: true},
+		{name: "inbound is not outbound", filter: parser.TransactionFilter{Direction: parser.DirectionOutbound}, tx: inbound},
+		{name: "inbound", filter: parser.TransactionFilter{Direction: parser.DirectionInbound}, tx: inbound, want: true},
+		{name: "inbound token transfer", filter: parser.TransactionFilter{Direction: parser.DirectionInbound}, tx: transfer, want: true},
+		{name: "outbound is not inbound", filter: parser.TransactionFilter{Direction: parser.DirectionInbound}, tx: outbound},
+		{name: "recipient counterparty", filter: parser.TransactionFilter{Counterparty: other}, tx: outbound, want: true},
+		{name: "sender counterparty", filter: parser.TransactionFilter{Counterparty: other}, tx: transfer, want: true},
+		{name: "token contract is no counterparty", filter: parser.TransactionFilter{Counterparty: token}, tx: transfer},
+		{name: "value in range", filter: parser.TransactionFilter{MinValue: &value, MaxValue: &value}, tx: outbound, want: true},
+		{name: "value under the minimum", filter: parser.TransactionFilter{MinValue: ptr(value.Add(value))}, tx: outbound},
+		{name: "value over the maximum", filter: parser.TransactionFilter{MaxValue: ptr(evm.QuantityFromUint64(1))}, tx: outbound},
+		{name: "status", filter: parser.TransactionFilter{Status: parser.TransactionStatusFailed}, tx: inbound, want: true},
+		{name: "other status", filter: parser.TransactionFilter{Status: parser.TransactionStatusFailed}, tx: outbound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.Match(address, tc.tx); got != tc.want {
+				t.Errorf("Match(%+v): want %t, got %t", tc.tx, tc.want, got)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/svcerrors"
+)
+
+const FormatQueryKey = "format"
+
+var ErrInvalidFormat = fmt.Errorf("%w: error invalid format", svcerrors.ErrBadRequest)
+
+// valueFormat selects how quantities are rendered in responses.
+// Hex keeps the raw JSON-RPC encoding, every other format is base 10.
+type valueFormat string
+
+const (
+	formatHex   valueFormat = "hex"
+	formatWei   valueFormat = "wei"
+	formatGwei  valueFormat = "gwei"
+	formatEther valueFormat = "ether"
+)
+
+func parseValueFormat(format string) (valueFormat, error) {
+	switch f := valueFormat(format); f {
+	case "":
+		return formatHex, nil
+	case formatHex, formatWei, formatGwei, formatEther:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+	}
+}
+
+func (f valueFormat) quantity(q evm.Quantity) string {
+	switch f {
+	case formatWei:
+		return q.FormatUnit(evm.Wei)
+	case formatGwei:
+		return q.FormatUnit(evm.Gwei)
+	case formatEther:
+		return q.FormatUnit(evm.Ether)
+	default:
+		return q.Hex()
+	}
+}
+
+// tokenAmount renders raw token amounts, ether units don't apply to tokens
+// so they are rendered in base 10 unless hex was requested.
+func (f valueFormat) tokenAmount(q evm.Quantity) string {
+	if f == formatHex {
+		return q.Hex()
+	}
+	return q.String()
+}
+
+// parseQuantity parses a quantity rendered in the format.
+func (f valueFormat) parseQuantity(value string) (evm.Quantity, error) {
+	switch f {
+	case formatWei:
+		return evm.ParseUnit(value, evm.Wei)
+	case formatGwei:
+		return evm.ParseUnit(value, evm.Gwei)
+	case formatEther:
+		return evm.ParseUnit(value, evm.Ether)
+	default:
+		return evm.ParseQuantity(value)
+	}
+}
+
+func (f valueFormat) blockNumber(b evm.BlockNumber) string {
+	if f == formatHex {
+		return b.Hex()
+	}
+	return b.String()
+}
@@ -1,10 +1,29 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 )
 
-const AddressQueryKey = "address"
+const (
+	AddressQueryKey = "address"
+	HashQueryKey    = "hash"
+	PurgeQueryKey   = "purge"
+	CursorQueryKey  = "cursor"
+	LimitQueryKey   = "limit"
+	OrderQueryKey   = "order"
+
+	FromBlockQueryKey    = "fromBlock"
+	ToBlockQueryKey      = "toBlock"
+	SinceQueryKey        = "since"
+	UntilQueryKey        = "until"
+	DirectionQueryKey    = "direction"
+	CounterpartyQueryKey = "counterparty"
+	MinValueQueryKey     = "minValue"
+	MaxValueQueryKey     = "maxValue"
+	StatusQueryKey       = "status"
+)
 
 func (h Handler) getCurrentBlock(w http.ResponseWriter, r *http.Request) {
 	blockNumber, err := h.parserSvc.GetCurrentBlock(r.Context())
@@ -31,10 +50,42 @@ func (h Handler) subscribeAddress(w http.ResponseWriter, r *http.Request) {
 	h.OK(w, struct{}{})
 }
 
+func (h Handler) unsubscribeAddress(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get(AddressQueryKey)
+
+	purge, err := parsePurge(r.URL.Query().Get(PurgeQueryKey))
+	if err != nil {
+		h.HandleError(w, err)
+		return
+	}
+
+	err = h.parserSvc.Unsubscribe(r.Context(), address, purge)
+	if err != nil {
+		h.logger.Printf("error unsubscribing address: %s: %v", address, err)
+
+		h.HandleError(w, err)
+		return
+	}
+
+	h.OK(w, struct{}{})
+}
+
 func (h Handler) getTransactions(w http.ResponseWriter, r *http.Request) {
 	address := r.URL.Query().Get(AddressQueryKey)
 
-	txs, err := h.parserSvc.GetTransactions(r.Context(), address)
+	format, err := parseValueFormat(r.URL.Query().Get(FormatQueryKey))
+	if err != nil {
+		h.HandleError(w, err)
+		return
+	}
+
+	query, err := parseTransactionQuery(r.URL.Query(), format)
+	if err != nil {
+		h.HandleError(w, err)
+		return
+	}
+
+	page, err := h.parserSvc.GetTransactions(r.Context(), address, query)
 	if err != nil {
 		h.logger.Printf("error retrieving transactions for address: %s: %v", address, err)
 
@@ -42,5 +93,96 @@ func (h Handler) getTransactions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.OK(w, newTransactionsResponse(txs))
+	h.OK(w, newTransactionPageResponse(page, format))
+}
+
+func (h Handler) getBalance(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get(AddressQueryKey)
+
+	format, err := parseValueFormat(r.URL.Query().Get(FormatQueryKey))
+	if err != nil {
+		h.HandleError(w, err)
+		return
+	}
+
+	balance, err := h.parserSvc.GetBalance(r.Context(), address)
+	if err != nil {
+		h.logger.Printf("error retrieving balance for address: %s: %v", address, err)
+
+		h.HandleError(w, err)
+		return
+	}
+
+	h.OK(w, newBalanceResponse(balance, format))
+}
+
+func (h Handler) getNonces(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get(AddressQueryKey)
+
+	report, err := h.parserSvc.GetNonces(r.Context(), address)
+	if err != nil {
+		h.logger.Printf("error retrieving nonces for address: %s: %v", address, err)
+
+		h.HandleError(w, err)
+		return
+	}
+
+	h.OK(w, newNonceReportResponse(report))
+}
+
+func (h Handler) broadcastTransaction(w http.ResponseWriter, r *http.Request) {
+	var req broadcastRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req)
+	if err != nil {
+		h.HandleError(w, fmt.Errorf("%w: %v", ErrInvalidBody, err))
+		return
+	}
+
+	tx, err := h.parserSvc.Broadcast(r.Context(), req.RawTransaction)
+	if err != nil {
+		h.logger.Printf("error broadcasting transaction: %v", err)
+
+		h.HandleError(w, err)
+		return
+	}
+
+	h.OK(w, newBroadcastResponse(tx))
+}
+
+func (h Handler) getBroadcast(w http.ResponseWriter, r *http.Request) {
+	hash := r.URL.Query().Get(HashQueryKey)
+
+	tx, err := h.parserSvc.GetBroadcast(r.Context(), hash)
+	if err != nil {
+		h.logger.Printf("error retrieving broadcast transaction: %s: %v", hash, err)
+
+		h.HandleError(w, err)
+		return
+	}
+
+	h.OK(w, newBroadcastResponse(tx))
+}
+
+func (h Handler) getCompactionMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics, err := h.parserSvc.GetCompactionMetrics(r.Context())
+	if err != nil {
+		h.logger.Printf("error retrieving compaction metrics: %v", err)
+
+		h.HandleError(w, err)
+		return
+	}
+
+	h.OK(w, newCompactionMetricsResponse(metrics))
+}
+
+func (h Handler) getNodeUsage(w http.ResponseWriter, r *http.Request) {
+	usages, err := h.parserSvc.GetNodeUsage(r.Context())
+	if err != nil {
+		h.logger.Printf("error retrieving node usage: %v", err)
+
+		h.HandleError(w, err)
+		return
+	}
+
+	h.OK(w, newNodeUsageResponse(usages))
 }
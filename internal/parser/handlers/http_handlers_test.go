@@ -3,12 +3,19 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/svcerrors"
 	"github.com/jeronimobarea/transaction_parser/internal/test/evmtest"
 	"github.com/jeronimobarea/transaction_parser/internal/test/parsertest"
 )
@@ -104,12 +111,46 @@ func TestHandler_SubscribeAddress(t *testing.T) {
 	})
 }
 
+func TestHandler_UnsubscribeAddress(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		err      error
+		wantCode int
+	}{
+		{name: "happy path", wantCode: http.StatusOK},
+		{name: "purge", query: "&" + PurgeQueryKey + "=true", wantCode: http.StatusOK},
+		{name: "invalid purge", query: "&" + PurgeQueryKey + "=all", wantCode: http.StatusBadRequest},
+		{name: "not subscribed", err: fmt.Errorf("%w: not subscribed", svcerrors.ErrNotFound), wantCode: http.StatusNotFound},
+		{name: "parser error", err: errors.New("unsubscribe fail"), wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := Handler{
+				parserSvc: &parsertest.FakeParserSvc{UnsubscribeErr: tc.err},
+				logger:    log.Default(),
+			}
+
+			url := "/subscribe?" + AddressQueryKey + "=0xabcdefabcdefabcdefabcdefabcdefabcdefabcd" + tc.query
+			req := httptest.NewRequest("DELETE", url, nil)
+			rec := httptest.NewRecorder()
+
+			h.unsubscribeAddress(rec, req)
+
+			if rec.Code != tc.wantCode {
+				t.Errorf("expected status %d, got %d", tc.wantCode, rec.Code)
+			}
+		})
+	}
+}
+
 func TestHandler_GetTransactions(t *testing.T) {
 	t.Run("happy path", func(t *testing.T) {
 		wantTxs := []parser.Transaction{
-			{Hash: "h1", From: evmtest.EVMZeroValueAddress, To: evmtest.EVMZeroValueAddress, Value: "10", BlockNumber: "0x1"},
+			{Hash: "h1", From: evmtest.EVMZeroValueAddress, To: evmtest.EVMZeroValueAddress, Value: evm.QuantityFromUint64(10), BlockNumber: 1},
 		}
-		fake := &parsertest.FakeParserSvc{GetTransactionsResp: wantTxs}
+		fake := &parsertest.FakeParserSvc{GetTransactionsResp: parser.TransactionPage{Transactions: wantTxs, NextCursor: "next"}}
 		h := Handler{
 			parserSvc: fake,
 			logger:    log.Default(),
@@ -128,16 +169,137 @@ func TestHandler_GetTransactions(t *testing.T) {
 			t.Errorf("expected Content-Type application/json, got %q", ct)
 		}
 
-		var resp []*transactionResponse
-		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		var page transactionPageResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
 			t.Fatalf("unmarshal body: %v", err)
 		}
+		if page.NextCursor != "next" {
+			t.Errorf("expected next cursor %q, got %q", "next", page.NextCursor)
+		}
+		resp := page.Transactions
 		if len(resp) != len(wantTxs) {
 			t.Errorf("exepceted equal transactions length %d but got %d", len(resp), len(wantTxs))
 		}
-		if wantTxs[0].BlockNumber != resp[0].BlockNumber {
+		if wantTxs[0].BlockNumber.Hex() != resp[0].BlockNumber {
 			t.Errorf("expected transactions %+v, got %+v", wantTxs, resp)
 		}
+		if resp[0].Value != "0xa" {
+			t.Errorf("expected hex value 0xa by default, got %s", resp[0].Value)
+		}
+	})
+
+	t.Run("formatted values", func(t *testing.T) {
+		value, _ := evm.ParseDecimalQuantity("1500000000000000000")
+		fake := &parsertest.FakeParserSvc{GetTransactionsResp: parser.TransactionPage{Transactions: []parser.Transaction{
+			{Hash: "h1", From: evmtest.EVMZeroValueAddress, To: evmtest.EVMZeroValueAddress, Value: value, BlockNumber: 22347829},
+		}}}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		testCases := []struct {
+			format      string
+			value       string
+			blockNumber string
+		}{
+			{format: "hex", value: "0x14d1120d7b160000", blockNumber: "0x1550035"},
+			{format: "wei", value: "1500000000000000000", blockNumber: "22347829"},
+			{format: "gwei", value: "1500000000", blockNumber: "22347829"},
+			{format: "ether", value: "1.5", blockNumber: "22347829"},
+		}
+
+		for _, tc := range testCases {
+			url := "/transactions?" + AddressQueryKey + "=" + evmtest.EVMZeroValueAddress.String() + "&" + FormatQueryKey + "=" + tc.format
+			req := httptest.NewRequest("GET", url, nil)
+			rec := httptest.NewRecorder()
+
+			h.getTransactions(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("format %s: expected status %d, got %d", tc.format, http.StatusOK, rec.Code)
+			}
+
+			var page transactionPageResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatalf("format %s: unmarshal body: %v", tc.format, err)
+			}
+			resp := page.Transactions
+			if resp[0].Value != tc.value {
+				t.Errorf("format %s: expected value %s, got %s", tc.format, tc.value, resp[0].Value)
+			}
+			if resp[0].BlockNumber != tc.blockNumber {
+				t.Errorf("format %s: expected block number %s, got %s", tc.format, tc.blockNumber, resp[0].BlockNumber)
+			}
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		h := Handler{
+			parserSvc: &parsertest.FakeParserSvc{},
+			logger:    log.Default(),
+		}
+
+		url := "/transactions?" + AddressQueryKey + "=" + evmtest.EVMZeroValueAddress.String() + "&" + FormatQueryKey + "=btc"
+		req := httptest.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+
+		h.getTransactions(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d on invalid format, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		h := Handler{
+			parserSvc: &parsertest.FakeParserSvc{},
+			logger:    log.Default(),
+		}
+
+		url := "/transactions?" + AddressQueryKey + "=" + evmtest.EVMZeroValueAddress.String() + "&" + LimitQueryKey + "=ten"
+		req := httptest.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+
+		h.getTransactions(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d on invalid limit, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		h := Handler{
+			parserSvc: &parsertest.FakeParserSvc{},
+			logger:    log.Default(),
+		}
+
+		url := "/transactions?" + AddressQueryKey + "=" + evmtest.EVMZeroValueAddress.String() + "&" + SinceQueryKey + "=yesterday"
+		req := httptest.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+
+		h.getTransactions(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d on invalid filter, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		h := Handler{
+			parserSvc: &parsertest.FakeParserSvc{GetTransactionsErr: parser.ErrInvalidCursor},
+			logger:    log.Default(),
+		}
+
+		url := "/transactions?" + AddressQueryKey + "=" + evmtest.EVMZeroValueAddress.String() + "&" + CursorQueryKey + "=bogus"
+		req := httptest.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+
+		h.getTransactions(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d on invalid cursor, got %d", http.StatusBadRequest, rec.Code)
+		}
 	})
 
 	t.Run("parser error", func(t *testing.T) {
@@ -158,3 +320,355 @@ func TestHandler_GetTransactions(t *testing.T) {
 		}
 	})
 }
+
+func TestParseTransactionQuery(t *testing.T) {
+	t.Run("every parameter", func(t *testing.T) {
+		values, _ := url.ParseQuery("cursor=c&limit=10&order=desc&fromBlock=0x10&toBlock=20&since=2025-04-26T08:00:00Z&until=2025-04-27T08:00:00Z" +
+			"&direction=in&counterparty=0x1111111111111111111111111111111111111111&minValue=0.5&maxValue=1.5&status=failed")
+
+		got, err := parseTransactionQuery(values, formatEther)
+		if err != nil {
+			t.Fatalf("parseTransactionQuery: unexpected error: %v", err)
+		}
+
+		var (
+			fromBlock, toBlock = evm.BlockNumber(16), evm.BlockNumber(20)
+			minValue, _        = evm.ParseDecimalQuantity("500000000000000000")
+			maxValue, _        = evm.ParseDecimalQuantity("1500000000000000000")
+		)
+		want := parser.TransactionQuery{
+			Cursor: "c",
+			Limit:  10,
+			Order:  parser.SortDescending,
+			Filter: parser.TransactionFilter{
+				FromBlock:    &fromBlock,
+				ToBlock:      &toBlock,
+				Since:        time.Date(2025, time.April, 26, 8, 0, 0, 0, time.UTC),
+				Until:        time.Date(2025, time.April, 27, 8, 0, 0, 0, time.UTC),
+				Direction:    parser.DirectionInbound,
+				Counterparty: "0x1111111111111111111111111111111111111111",
+				MinValue:     &minValue,
+				MaxValue:     &maxValue,
+				Status:       parser.TransactionStatusFailed,
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parseTransactionQuery:\n got %+v\nwant %+v", got, want)
+		}
+	})
+
+	t.Run("values in the format", func(t *testing.T) {
+		values := url.Values{MinValueQueryKey: {"1.5"}}
+
+		if _, err := parseTransactionQuery(values, formatEther); err != nil {
+			t.Errorf("parseTransactionQuery(ether): unexpected error: %v", err)
+		}
+		if _, err := parseTransactionQuery(values, formatWei); !errors.Is(err, parser.ErrInvalidFilter) {
+			t.Errorf("parseTransactionQuery(wei): expected %v, got %v", parser.ErrInvalidFilter, err)
+		}
+	})
+
+	t.Run("invalid block", func(t *testing.T) {
+		values := url.Values{FromBlockQueryKey: {"latest"}}
+
+		if _, err := parseTransactionQuery(values, formatHex); !errors.Is(err, parser.ErrInvalidFilter) {
+			t.Errorf("parseTransactionQuery: expected %v, got %v", parser.ErrInvalidFilter, err)
+		}
+	})
+}
+
+func TestHandler_GetBalance(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{GetBalanceResp: parser.Balance{
+			Address:     evmtest.EVMZeroValueAddress,
+			Balance:     evm.QuantityFromUint64(2_000_000_000),
+			BlockNumber: 16,
+			Reconciliation: &parser.Reconciliation{
+				BlockNumber: 16,
+				Expected:    evm.QuantityFromUint64(2_000_000_000),
+				OnChain:     evm.QuantityFromUint64(1_000_000_000),
+				Discrepancy: evm.Quantity{}.Sub(evm.QuantityFromUint64(1_000_000_000)),
+			},
+		}}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		url := "/balance?" + AddressQueryKey + "=" + evmtest.EVMZeroValueAddress.String() + "&" + FormatQueryKey + "=gwei"
+		req := httptest.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+
+		h.getBalance(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var resp balanceResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal body: %v", err)
+		}
+		if resp.Balance != "2" || resp.BlockNumber != "16" {
+			t.Errorf("expected balance 2 gwei at block 16, got %+v", resp)
+		}
+		if resp.Reconciliation == nil || !resp.Reconciliation.HasDiscrepancy || resp.Reconciliation.Discrepancy != "-1" {
+			t.Errorf("expected a -1 gwei discrepancy, got %+v", resp.Reconciliation)
+		}
+	})
+
+	t.Run("parser error", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{GetBalanceErr: errors.New("balance fail")}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		url := "/balance?" + AddressQueryKey + "=" + evmtest.EVMZeroValueAddress.String()
+		req := httptest.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+
+		h.getBalance(rec, req)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d on service error, got %d", http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestHandler_GetNonces(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{GetNoncesResp: parser.NonceReport{
+			Address:        evmtest.EVMZeroValueAddress,
+			ConfirmedNonce: 2,
+			PendingNonce:   3,
+			Gaps:           []evm.Nonce{1},
+			Transactions: []parser.NonceTransaction{
+				{Nonce: 0, Hash: "h0", Status: parser.NonceStatusMined, BlockNumber: 10},
+				{Nonce: 2, Hash: "h2", Status: parser.NonceStatusStuck, FirstSeen: time.Now()},
+			},
+		}}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		url := "/nonces?" + AddressQueryKey + "=" + evmtest.EVMZeroValueAddress.String()
+		req := httptest.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+
+		h.getNonces(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var resp nonceReportResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal body: %v", err)
+		}
+		if !resp.HasStuck || len(resp.Gaps) != 1 || resp.Gaps[0] != 1 {
+			t.Errorf("expected a stuck transaction and gap at nonce 1, got %+v", resp)
+		}
+		if len(resp.Transactions) != 2 || resp.Transactions[0].FirstSeen != nil || resp.Transactions[1].FirstSeen == nil {
+			t.Errorf("expected first seen only on pending transactions, got %+v", resp.Transactions)
+		}
+	})
+
+	t.Run("parser error", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{GetNoncesErr: errors.New("nonces fail")}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		url := "/nonces?" + AddressQueryKey + "=" + evmtest.EVMZeroValueAddress.String()
+		req := httptest.NewRequest("GET", url, nil)
+		rec := httptest.NewRecorder()
+
+		h.getNonces(rec, req)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d on service error, got %d", http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
+
+func TestHandler_BroadcastTransaction(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{BroadcastResp: parser.BroadcastTransaction{
+			Hash:   "h1",
+			From:   evmtest.EVMZeroValueAddress,
+			Nonce:  3,
+			Status: parser.BroadcastStatusPending,
+		}}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		req := httptest.NewRequest("POST", "/transactions/broadcast", strings.NewReader(`{"rawTransaction":"0x02f8"}`))
+		rec := httptest.NewRecorder()
+
+		h.broadcastTransaction(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var resp broadcastResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal body: %v", err)
+		}
+		if resp.Hash != "h1" || resp.Nonce != 3 || resp.Status != "pending" {
+			t.Errorf("unexpected broadcast response %+v", resp)
+		}
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		h := Handler{
+			parserSvc: &parsertest.FakeParserSvc{},
+			logger:    log.Default(),
+		}
+
+		req := httptest.NewRequest("POST", "/transactions/broadcast", strings.NewReader(`0x02f8`))
+		rec := httptest.NewRecorder()
+
+		h.broadcastTransaction(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d on invalid body, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestHandler_GetBroadcast(t *testing.T) {
+	t.Run("not found", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{GetBroadcastErr: fmt.Errorf("%w: unknown", svcerrors.ErrNotFound)}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		req := httptest.NewRequest("GET", "/transactions/broadcast?"+HashQueryKey+"=0x01", nil)
+		rec := httptest.NewRecorder()
+
+		h.getBroadcast(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+}
+
+func TestHandler_GetCompactionMetrics(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{GetCompactionResp: parser.CompactionMetrics{
+			Runs:                3,
+			EvictedByAge:        1,
+			EvictedByAddressCap: 2,
+			FreedBytes:          512,
+			RetainedBytes:       1024,
+			LastRun:             time.Now(),
+		}}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		req := httptest.NewRequest("GET", "/retention", nil)
+		rec := httptest.NewRecorder()
+
+		h.getCompactionMetrics(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var resp compactionMetricsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal body: %v", err)
+		}
+		if resp.Runs != 3 || resp.Evicted.Age != 1 || resp.Evicted.AddressCap != 2 || resp.RetainedBytes != 1024 || resp.LastRun == nil {
+			t.Errorf("unexpected compaction metrics response %+v", resp)
+		}
+	})
+
+	t.Run("no retention policy", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{GetCompactionErr: fmt.Errorf("%w: no policy", svcerrors.ErrNotFound)}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		req := httptest.NewRequest("GET", "/retention", nil)
+		rec := httptest.NewRecorder()
+
+		h.getCompactionMetrics(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+		}
+	})
+}
+
+func TestHandler_GetNodeUsage(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{GetNodeUsageResp: []parser.NodeUsage{{
+			Endpoint:  "node-1",
+			Rate:      100,
+			Burst:     200,
+			Available: 150,
+			Consumed:  1250,
+			Requests:  80,
+			Throttled: 1500 * time.Millisecond,
+		}}}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		req := httptest.NewRequest("GET", "/nodes/usage", nil)
+		rec := httptest.NewRecorder()
+
+		h.getNodeUsage(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+
+		var resp nodeUsageResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal body: %v", err)
+		}
+		want := &endpointUsageResponse{
+			Endpoint:  "node-1",
+			Rate:      100,
+			Burst:     200,
+			Available: 150,
+			Consumed:  1250,
+			Requests:  80,
+			Throttled: "1.5s",
+		}
+		if len(resp.Endpoints) != 1 || !reflect.DeepEqual(resp.Endpoints[0], want) {
+			t.Errorf("unexpected node usage response %+v", resp)
+		}
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		fake := &parsertest.FakeParserSvc{GetNodeUsageErr: errors.New("boom")}
+		h := Handler{
+			parserSvc: fake,
+			logger:    log.Default(),
+		}
+
+		req := httptest.NewRequest("GET", "/nodes/usage", nil)
+		rec := httptest.NewRecorder()
+
+		h.getNodeUsage(rec, req)
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+		}
+	})
+}
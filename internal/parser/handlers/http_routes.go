@@ -26,5 +26,12 @@ func RegisterRoutes(
 
 	router.Handle("GET", "/blocks/current", handlers.getCurrentBlock)
 	router.Handle("POST", "/subscribe", handlers.subscribeAddress)
+	router.Handle("DELETE", "/subscribe", handlers.unsubscribeAddress)
 	router.Handle("GET", "/transactions", handlers.getTransactions)
+	router.Handle("GET", "/balance", handlers.getBalance)
+	router.Handle("GET", "/nonces", handlers.getNonces)
+	router.Handle("POST", "/transactions/broadcast", handlers.broadcastTransaction)
+	router.Handle("GET", "/transactions/broadcast", handlers.getBroadcast)
+	router.Handle("GET", "/retention", handlers.getCompactionMetrics)
+	router.Handle("GET", "/nodes/usage", handlers.getNodeUsage)
 }
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/svcerrors"
+)
+
+// maxBodySize bounds request bodies, the largest ones are raw transactions carrying blobs of calldata.
+const maxBodySize = 1 << 20
+
+var (
+	ErrInvalidBody  = fmt.Errorf("%w: error invalid body", svcerrors.ErrBadRequest)
+	ErrInvalidPurge = fmt.Errorf("%w: error invalid purge, expected true or false", svcerrors.ErrBadRequest)
+)
+
+// parsePurge reads whether the transactions of an unsubscribed address are deleted, they are
+// kept by default.
+func parsePurge(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+
+	purge, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrInvalidPurge, value)
+	}
+	return purge, nil
+}
+
+// parseTransactionQuery reads the pagination and filter parameters, the parser validating
+// their values. Values are read in the format they are rendered in.
+func parseTransactionQuery(values url.Values, format valueFormat) (parser.TransactionQuery, error) {
+	query := parser.TransactionQuery{
+		Cursor: values.Get(CursorQueryKey),
+		Order:  parser.SortOrder(values.Get(OrderQueryKey)),
+		Filter: parser.TransactionFilter{
+			Direction:    parser.Direction(values.Get(DirectionQueryKey)),
+			Counterparty: evm.Address(values.Get(CounterpartyQueryKey)),
+			Status:       parser.TransactionStatus(values.Get(StatusQueryKey)),
+		},
+	}
+
+	if limit := values.Get(LimitQueryKey); limit != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return parser.TransactionQuery{}, fmt.Errorf("%w: %s", parser.ErrInvalidPageSize, limit)
+		}
+	}
+
+	var err error
+	filter := &query.Filter
+	if filter.FromBlock, err = parseOptional(values, FromBlockQueryKey, parseBlockNumber); err != nil {
+		return parser.TransactionQuery{}, err
+	}
+	if filter.ToBlock, err = parseOptional(values, ToBlockQueryKey, parseBlockNumber); err != nil {
+		return parser.TransactionQuery{}, err
+	}
+	if filter.MinValue, err = parseOptional(values, MinValueQueryKey, format.parseQuantity); err != nil {
+		return parser.TransactionQuery{}, err
+	}
+	if filter.MaxValue, err = parseOptional(values, MaxValueQueryKey, format.parseQuantity); err != nil {
+		return parser.TransactionQuery{}, err
+	}
+
+	since, err := parseOptional(values, SinceQueryKey, parseTime)
+	if err != nil {
+		return parser.TransactionQuery{}, err
+	}
+	until, err := parseOptional(values, UntilQueryKey, parseTime)
+	if err != nil {
+		return parser.TransactionQuery{}, err
+	}
+	if since != nil {
+		filter.Since = *since
+	}
+	if until != nil {
+		filter.Until = *until
+	}
+	return query, nil
+}
+
+// parseOptional parses the parameter when it is set, nil otherwise.
+func parseOptional[T any](values url.Values, key string, parse func(string) (T, error)) (*T, error) {
+	value := values.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", parser.ErrInvalidFilter, key, err)
+	}
+	return &parsed, nil
+}
+
+// parseBlockNumber parses a block number in base 10, or in hex when 0x-prefixed.
+func parseBlockNumber(value string) (evm.BlockNumber, error) {
+	if strings.HasPrefix(value, "0x") {
+		return evm.ParseBlockNumber(value)
+	}
+	blockNumber, err := strconv.ParseUint(value, 10, 64)
+	return evm.BlockNumber(blockNumber), err
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339, value)
+}
+
+type broadcastRequest struct {
+	RawTransaction string `json:"rawTransaction"`
+}
@@ -1,6 +1,10 @@
 package handlers
 
-import "github.com/jeronimobarea/transaction_parser/internal/parser"
+import (
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+)
 
 type currentBlockResponse struct {
 	BlockNumber int64 `json:"block_number"`
@@ -13,27 +17,249 @@ func newCurrentBlockResponse(blockNumber int64) *currentBlockResponse {
 }
 
 type transactionResponse struct {
-	Hash        string `json:"hash"`
-	From        string `json:"from"`
-	To          string `json:"to"`
-	Value       string `json:"value"`
-	BlockNumber string `json:"blockNumber"`
+	Hash          string                 `json:"hash"`
+	From          string                 `json:"from"`
+	To            string                 `json:"to"`
+	Value         string                 `json:"value"`
+	BlockNumber   string                 `json:"blockNumber"`
+	Timestamp     *time.Time             `json:"timestamp,omitempty"`
+	FiatValue     *fiatValueResponse     `json:"fiatValue,omitempty"`
+	Status        string                 `json:"status,omitempty"`
+	Fee           string                 `json:"fee"`
+	TokenTransfer *tokenTransferResponse `json:"tokenTransfer,omitempty"`
+}
+
+type tokenTransferResponse struct {
+	Contract  string             `json:"contract"`
+	To        string             `json:"to"`
+	Amount    string             `json:"amount"`
+	FiatValue *fiatValueResponse `json:"fiatValue,omitempty"`
 }
 
-func newTransactionResponse(tx parser.Transaction) *transactionResponse {
-	return &transactionResponse{
+type fiatValueResponse struct {
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+}
+
+func newTransactionResponse(tx parser.Transaction, format valueFormat) *transactionResponse {
+	resp := &transactionResponse{
 		Hash:        tx.Hash,
 		From:        string(tx.From),
 		To:          string(tx.To),
-		Value:       tx.Value,
-		BlockNumber: tx.BlockNumber,
+		Value:       format.quantity(tx.Value),
+		BlockNumber: format.blockNumber(tx.BlockNumber),
+		FiatValue:   newFiatValueResponse(tx.Fiat),
+		Status:      string(tx.Status),
+		Fee:         format.quantity(tx.Fee),
+	}
+	if !tx.Timestamp.IsZero() {
+		resp.Timestamp = &tx.Timestamp
+	}
+
+	if tx.Token != nil {
+		resp.TokenTransfer = &tokenTransferResponse{
+			Contract:  string(tx.Token.Contract),
+			To:        string(tx.Token.To),
+			Amount:    format.tokenAmount(tx.Token.Amount),
+			FiatValue: newFiatValueResponse(tx.Token.Fiat),
+		}
+	}
+	return resp
+}
+
+func newFiatValueResponse(fiat *parser.FiatValue) *fiatValueResponse {
+	if fiat == nil {
+		return nil
+	}
+	return &fiatValueResponse{
+		Currency: fiat.Currency,
+		Amount:   fiat.Amount,
+	}
+}
+
+type transactionPageResponse struct {
+	Transactions []*transactionResponse `json:"transactions"`
+	NextCursor   string                 `json:"nextCursor,omitempty"`
+}
+
+func newTransactionPageResponse(page parser.TransactionPage, format valueFormat) *transactionPageResponse {
+	txsView := make([]*transactionResponse, len(page.Transactions))
+	for i := range page.Transactions {
+		txsView[i] = newTransactionResponse(page.Transactions[i], format)
+	}
+	return &transactionPageResponse{
+		Transactions: txsView,
+		NextCursor:   page.NextCursor,
+	}
+}
+
+type balanceResponse struct {
+	Address        string                  `json:"address"`
+	Balance        string                  `json:"balance"`
+	BlockNumber    string                  `json:"blockNumber"`
+	Reconciliation *reconciliationResponse `json:"reconciliation,omitempty"`
+}
+
+type reconciliationResponse struct {
+	BlockNumber    string    `json:"blockNumber"`
+	Expected       string    `json:"expected"`
+	OnChain        string    `json:"onChain"`
+	Discrepancy    string    `json:"discrepancy"`
+	HasDiscrepancy bool      `json:"hasDiscrepancy"`
+	ReconciledAt   time.Time `json:"reconciledAt"`
+}
+
+func newBalanceResponse(balance parser.Balance, format valueFormat) *balanceResponse {
+	resp := &balanceResponse{
+		Address:     string(balance.Address),
+		Balance:     format.quantity(balance.Balance),
+		BlockNumber: format.blockNumber(balance.BlockNumber),
+	}
+
+	if rec := balance.Reconciliation; rec != nil {
+		resp.Reconciliation = &reconciliationResponse{
+			BlockNumber:    format.blockNumber(rec.BlockNumber),
+			Expected:       format.quantity(rec.Expected),
+			OnChain:        format.quantity(rec.OnChain),
+			Discrepancy:    format.quantity(rec.Discrepancy),
+			HasDiscrepancy: rec.HasDiscrepancy(),
+			ReconciledAt:   rec.ReconciledAt,
+		}
 	}
+	return resp
 }
 
-func newTransactionsResponse(txs []parser.Transaction) []*transactionResponse {
-	txsView := make([]*transactionResponse, len(txs))
-	for i := range txs {
-		txsView[i] = newTransactionResponse(txs[i])
+type nonceReportResponse struct {
+	Address        string                      `json:"address"`
+	ConfirmedNonce uint64                      `json:"confirmedNonce"`
+	PendingNonce   uint64                      `json:"pendingNonce"`
+	Gaps           []uint64                    `json:"gaps"`
+	HasStuck       bool                        `json:"hasStuck"`
+	Transactions   []*nonceTransactionResponse `json:"transactions"`
+}
+
+type nonceTransactionResponse struct {
+	Nonce       uint64     `json:"nonce"`
+	Hash        string     `json:"hash"`
+	Status      string     `json:"status"`
+	BlockNumber uint64     `json:"blockNumber,omitempty"`
+	ReplacedBy  string     `json:"replacedBy,omitempty"`
+	FirstSeen   *time.Time `json:"firstSeen,omitempty"`
+}
+
+func newNonceReportResponse(report parser.NonceReport) *nonceReportResponse {
+	resp := &nonceReportResponse{
+		Address:        string(report.Address),
+		ConfirmedNonce: uint64(report.ConfirmedNonce),
+		PendingNonce:   uint64(report.PendingNonce),
+		Gaps:           make([]uint64, len(report.Gaps)),
+		HasStuck:       report.HasStuck(),
+		Transactions:   make([]*nonceTransactionResponse, len(report.Transactions)),
+	}
+
+	for i, nonce := range report.Gaps {
+		resp.Gaps[i] = uint64(nonce)
+	}
+
+	for i, tx := range report.Transactions {
+		txView := &nonceTransactionResponse{
+			Nonce:       uint64(tx.Nonce),
+			Hash:        tx.Hash,
+			Status:      string(tx.Status),
+			BlockNumber: uint64(tx.BlockNumber),
+			ReplacedBy:  tx.ReplacedBy,
+		}
+		if !tx.FirstSeen.IsZero() {
+			txView.FirstSeen = &tx.FirstSeen
+		}
+		resp.Transactions[i] = txView
+	}
+	return resp
+}
+
+type broadcastResponse struct {
+	Hash          string    `json:"hash"`
+	From          string    `json:"from"`
+	Nonce         uint64    `json:"nonce"`
+	Status        string    `json:"status"`
+	BlockNumber   uint64    `json:"blockNumber,omitempty"`
+	Confirmations uint64    `json:"confirmations"`
+	SubmittedAt   time.Time `json:"submittedAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func newBroadcastResponse(tx parser.BroadcastTransaction) *broadcastResponse {
+	return &broadcastResponse{
+		Hash:          tx.Hash,
+		From:          string(tx.From),
+		Nonce:         uint64(tx.Nonce),
+		Status:        string(tx.Status),
+		BlockNumber:   uint64(tx.BlockNumber),
+		Confirmations: tx.Confirmations,
+		SubmittedAt:   tx.SubmittedAt,
+		UpdatedAt:     tx.UpdatedAt,
+	}
+}
+
+type compactionMetricsResponse struct {
+	Runs     int `json:"runs"`
+	Failures int `json:"failures"`
+	Evicted  struct {
+		Age        int `json:"age"`
+		AddressCap int `json:"addressCap"`
+		Memory     int `json:"memory"`
+	} `json:"evicted"`
+	Archived      int        `json:"archived"`
+	FreedBytes    int64      `json:"freedBytes"`
+	RetainedBytes int64      `json:"retainedBytes"`
+	LastRun       *time.Time `json:"lastRun,omitempty"`
+}
+
+func newCompactionMetricsResponse(metrics parser.CompactionMetrics) *compactionMetricsResponse {
+	resp := &compactionMetricsResponse{
+		Runs:          metrics.Runs,
+		Failures:      metrics.Failures,
+		Archived:      metrics.Archived,
+		FreedBytes:    metrics.FreedBytes,
+		RetainedBytes: metrics.RetainedBytes,
+	}
+	resp.Evicted.Age = metrics.EvictedByAge
+	resp.Evicted.AddressCap = metrics.EvictedByAddressCap
+	resp.Evicted.Memory = metrics.EvictedByMemory
+	if !metrics.LastRun.IsZero() {
+		resp.LastRun = &metrics.LastRun
+	}
+	return resp
+}
+
+type nodeUsageResponse struct {
+	Endpoints []*endpointUsageResponse `json:"endpoints"`
+}
+
+type endpointUsageResponse struct {
+	Endpoint  string  `json:"endpoint"`
+	Rate      float64 `json:"rate"`
+	Burst     float64 `json:"burst"`
+	Available float64 `json:"available"`
+	Consumed  float64 `json:"consumed"`
+	Requests  uint64  `json:"requests"`
+	Throttled string  `json:"throttled"`
+}
+
+func newNodeUsageResponse(usages []parser.NodeUsage) *nodeUsageResponse {
+	resp := &nodeUsageResponse{
+		Endpoints: make([]*endpointUsageResponse, len(usages)),
+	}
+	for i, usage := range usages {
+		resp.Endpoints[i] = &endpointUsageResponse{
+			Endpoint:  usage.Endpoint,
+			Rate:      usage.Rate,
+			Burst:     usage.Burst,
+			Available: usage.Available,
+			Consumed:  usage.Consumed,
+			Requests:  usage.Requests,
+			Throttled: usage.Throttled.String(),
+		}
 	}
-	return txsView
+	return resp
 }
@@ -1,11 +1,221 @@
 package parser
 
-import "github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+import (
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+type TransactionStatus string
+
+const (
+	TransactionStatusUnknown TransactionStatus = ""
+	TransactionStatusSuccess TransactionStatus = "success"
+	TransactionStatusFailed  TransactionStatus = "failed"
+)
 
 type Transaction struct {
 	Hash        string
 	From        evm.Address
 	To          evm.Address
-	Value       string
-	BlockNumber string
+	Value       evm.Quantity
+	BlockNumber evm.BlockNumber
+	// TransactionIndex is the position of the transaction in its block.
+	TransactionIndex evm.TransactionIndex
+	// Timestamp is the time of the block, zero for transactions saved before it was recorded.
+	Timestamp time.Time
+	Nonce     evm.Nonce
+	Status    TransactionStatus
+	// Fee is the gas paid by the sender, gas used times the effective gas price.
+	Fee evm.Quantity
+	// Token is set when the transaction is an ERC-20 transfer call.
+	Token *TokenTransfer
+	// Fiat is the value of the native transfer at BlockNumber, when a price is available.
+	Fiat *FiatValue
+}
+
+type SortOrder string
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// TransactionQuery selects a page of the transactions of an address, ordered by block and
+// position in the block.
+type TransactionQuery struct {
+	// Cursor resumes after the last transaction of the previous page, empty for the first one.
+	Cursor string
+	// Limit is the page size, DefaultPageSize when zero and at most MaxPageSize.
+	Limit int
+	// Order is SortAscending when empty.
+	Order  SortOrder
+	Filter TransactionFilter
+}
+
+// Direction is the side of the transactions an address is on.
+type Direction string
+
+const (
+	DirectionAny      Direction = ""
+	DirectionInbound  Direction = "in"
+	DirectionOutbound Direction = "out"
+)
+
+// TransactionFilter narrows down the transactions of an address, the zero value keeping
+// all of them. Every bound is inclusive but Until.
+type TransactionFilter struct {
+	FromBlock *evm.BlockNumber
+	ToBlock   *evm.BlockNumber
+	// Since and Until bound the block time, the transactions of unknown time being before
+	// any of them: Since drops them and Until keeps them.
+	Since     time.Time
+	Until     time.Time
+	Direction Direction
+	// Counterparty is the other end of the transactions, see Counterparty.
+	Counterparty evm.Address
+	// MinValue and MaxValue bound the native value, token amounts are not compared.
+	MinValue *evm.Quantity
+	MaxValue *evm.Quantity
+	// Status keeps the transactions of the status, any status when unknown.
+	Status TransactionStatus
+}
+
+type TransactionPage struct {
+	Transactions []Transaction
+	// NextCursor is the cursor of the next page, empty on the last one.
+	NextCursor string
+}
+
+type TokenTransfer struct {
+	Contract evm.Address
+	To       evm.Address
+	Amount   evm.Quantity
+	Fiat     *FiatValue
+}
+
+type FiatValue struct {
+	Currency string
+	// Amount is a base 10 decimal, e.g. "1834.21".
+	Amount string
+}
+
+type Balance struct {
+	Address evm.Address
+	// Balance is the opening on-chain balance plus the stored transfers and fees since,
+	// computed at BlockNumber.
+	Balance     evm.Quantity
+	BlockNumber evm.BlockNumber
+	// Reconciliation is the last comparison against the node, nil until the first one runs.
+	Reconciliation *Reconciliation
+}
+
+type Reconciliation struct {
+	BlockNumber evm.BlockNumber
+	Expected    evm.Quantity
+	OnChain     evm.Quantity
+	// Discrepancy is OnChain minus Expected, non zero values reveal missed blocks
+	// or untracked internal transfers.
+	Discrepancy  evm.Quantity
+	ReconciledAt time.Time
+}
+
+func (r Reconciliation) HasDiscrepancy() bool {
+	return !r.Discrepancy.IsZero()
+}
+
+type NonceStatus string
+
+const (
+	NonceStatusMined    NonceStatus = "mined"
+	NonceStatusPending  NonceStatus = "pending"
+	NonceStatusStuck    NonceStatus = "stuck"
+	NonceStatusReplaced NonceStatus = "replaced"
+)
+
+type NonceReport struct {
+	Address evm.Address
+	// ConfirmedNonce is the next nonce to be mined, the transaction count at the latest block.
+	ConfirmedNonce evm.Nonce
+	// PendingNonce is the next nonce including the transactions in the node mempool.
+	PendingNonce evm.Nonce
+	// Gaps are the nonces missing from the confirmed nonce up to the highest known one.
+	Gaps         []evm.Nonce
+	Transactions []NonceTransaction
+}
+
+type NonceTransaction struct {
+	Nonce       evm.Nonce
+	Hash        string
+	Status      NonceStatus
+	BlockNumber evm.BlockNumber
+	// ReplacedBy is the hash that took the nonce, empty when it is unknown.
+	ReplacedBy string
+	// FirstSeen is when the transaction was first seen pending, zero for mined only transactions.
+	FirstSeen time.Time
+}
+
+func (r NonceReport) HasStuck() bool {
+	for _, tx := range r.Transactions {
+		if tx.Status == NonceStatusStuck {
+			return true
+		}
+	}
+	return false
+}
+
+type BroadcastStatus string
+
+const (
+	BroadcastStatusPending   BroadcastStatus = "pending"
+	BroadcastStatusIncluded  BroadcastStatus = "included"
+	BroadcastStatusConfirmed BroadcastStatus = "confirmed"
+	BroadcastStatusDropped   BroadcastStatus = "dropped"
+	BroadcastStatusReplaced  BroadcastStatus = "replaced"
+)
+
+// IsFinal reports whether the status can't change anymore.
+func (s BroadcastStatus) IsFinal() bool {
+	return s == BroadcastStatusConfirmed || s == BroadcastStatusReplaced
+}
+
+type BroadcastTransaction struct {
+	Hash   string
+	From   evm.Address
+	Nonce  evm.Nonce
+	Status BroadcastStatus
+	// BlockNumber is the block the transaction was included in, zero until then.
+	BlockNumber   evm.BlockNumber
+	Confirmations uint64
+	SubmittedAt   time.Time
+	UpdatedAt     time.Time
+}
+
+// CompactionMetrics totals what the retention compactions pruned since the start.
+type CompactionMetrics struct {
+	Runs     int
+	Failures int
+	// EvictedByAge, EvictedByAddressCap and EvictedByMemory count the transactions removed
+	// from an address by each bound of the policy.
+	EvictedByAge        int
+	EvictedByAddressCap int
+	EvictedByMemory     int
+	Archived            int
+	FreedBytes          int64
+	// RetainedBytes is the estimated memory held by the transactions after the last run.
+	RetainedBytes int64
+	LastRun       time.Time
+}
+
+// NodeUsage is the rate limit budget of a node endpoint.
+type NodeUsage struct {
+	Endpoint string
+	// Rate is the budget refilled per second, zero when unlimited.
+	Rate      float64
+	Burst     float64
+	Available float64
+	// Consumed, Requests and Throttled are totals since the start.
+	Consumed  float64
+	Requests  uint64
+	Throttled time.Duration
 }
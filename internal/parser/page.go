@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/svcerrors"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+var (
+	ErrInvalidCursor    = fmt.Errorf("%w: error invalid cursor", svcerrors.ErrBadRequest)
+	ErrInvalidPageSize  = fmt.Errorf("%w: error invalid page size, expected 1 to %d", svcerrors.ErrBadRequest, MaxPageSize)
+	ErrInvalidSortOrder = fmt.Errorf("%w: error invalid sort order, expected asc or desc", svcerrors.ErrBadRequest)
+)
+
+// Normalize validates the query and its filter, and fills in the default page size and order.
+func (q TransactionQuery) Normalize() (TransactionQuery, error) {
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultPageSize
+	case q.Limit < 0 || q.Limit > MaxPageSize:
+		return TransactionQuery{}, ErrInvalidPageSize
+	}
+
+	switch q.Order {
+	case "":
+		q.Order = SortAscending
+	case SortAscending, SortDescending:
+	default:
+		return TransactionQuery{}, fmt.Errorf("%w: %q", ErrInvalidSortOrder, q.Order)
+	}
+
+	if q.Cursor != "" {
+		if _, err := DecodeCursor(q.Cursor); err != nil {
+			return TransactionQuery{}, err
+		}
+	}
+
+	if err := q.Filter.validate(); err != nil {
+		return TransactionQuery{}, err
+	}
+	return q, nil
+}
+
+// Position is the place of a transaction in the pages: its block, then its index in the
+// block, the hash breaking the ties of transactions missing an index.
+type Position struct {
+	BlockNumber      evm.BlockNumber
+	TransactionIndex evm.TransactionIndex
+	Hash             string
+}
+
+func PositionOf(tx Transaction) Position {
+	return Position{
+		BlockNumber:      tx.BlockNumber,
+		TransactionIndex: tx.TransactionIndex,
+		Hash:             tx.Hash,
+	}
+}
+
+// Compare orders the positions ascending, returning -1, 0 or +1 like cmp.Compare.
+func (p Position) Compare(other Position) int {
+	if c := cmp.Compare(p.BlockNumber, other.BlockNumber); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(p.TransactionIndex, other.TransactionIndex); c != 0 {
+		return c
+	}
+	return strings.Compare(p.Hash, other.Hash)
+}
+
+// EncodeCursor returns the opaque cursor resuming after the position.
+func EncodeCursor(p Position) string {
+	raw := strconv.FormatUint(uint64(p.BlockNumber), 10) + "." + strconv.FormatUint(uint64(p.TransactionIndex), 10) + "." + p.Hash
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (Position, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Position{}, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ".", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return Position{}, ErrInvalidCursor
+	}
+	// positions fit in signed integers, as the filters bound the block numbers
+	blockNumber, err := strconv.ParseUint(parts[0], 10, 63)
+	if err != nil {
+		return Position{}, ErrInvalidCursor
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 63)
+	if err != nil {
+		return Position{}, ErrInvalidCursor
+	}
+
+	return Position{
+		BlockNumber:      evm.BlockNumber(blockNumber),
+		TransactionIndex: evm.TransactionIndex(index),
+		Hash:             parts[2],
+	}, nil
+}
+
+// NewTransactionPage returns the first limit transactions as a page, txs holding one more
+// when there is a next page.
+func NewTransactionPage(txs []Transaction, limit int) TransactionPage {
+	if len(txs) <= limit {
+		return TransactionPage{Transactions: txs}
+	}
+
+	txs = txs[:limit]
+	return TransactionPage{
+		Transactions: txs,
+		NextCursor:   EncodeCursor(PositionOf(txs[limit-1])),
+	}
+}
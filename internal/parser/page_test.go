@@ -0,0 +1,118 @@
+package parser_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+)
+
+func TestTransactionQuery_Normalize(t *testing.T) {
+	cursor := parser.EncodeCursor(parser.Position{BlockNumber: 1, TransactionIndex: 2, Hash: "0xa"})
+
+	testCases := []struct {
+		name    string
+		query   parser.TransactionQuery
+		want    parser.TransactionQuery
+		wantErr error
+	}{
+		{
+			name:  "defaults",
+			query: parser.TransactionQuery{},
+			want:  parser.TransactionQuery{Limit: parser.DefaultPageSize, Order: parser.SortAscending},
+		},
+		{
+			name:  "kept values",
+			query: parser.TransactionQuery{Cursor: cursor, Limit: 10, Order: parser.SortDescending},
+			want:  parser.TransactionQuery{Cursor: cursor, Limit: 10, Order: parser.SortDescending},
+		},
+		{
+			name:    "negative limit",
+			query:   parser.TransactionQuery{Limit: -1},
+			wantErr: parser.ErrInvalidPageSize,
+		},
+		{
+			name:    "limit over the maximum",
+			query:   parser.TransactionQuery{Limit: parser.MaxPageSize + 1},
+			wantErr: parser.ErrInvalidPageSize,
+		},
+		{
+			name:    "unknown order",
+			query:   parser.TransactionQuery{Order: "newest"},
+			wantErr: parser.ErrInvalidSortOrder,
+		},
+		{
+			name:    "invalid cursor",
+			query:   parser.TransactionQuery{Cursor: "bogus"},
+			wantErr: parser.ErrInvalidCursor,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.query.Normalize()
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Normalize(%+v): expected error %v, got %v", tc.query, tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("Normalize(%+v): want %+v, got %+v", tc.query, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		want := parser.Position{BlockNumber: 22347829, TransactionIndex: 17, Hash: "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"}
+
+		got, err := parser.DecodeCursor(parser.EncodeCursor(want))
+		if err != nil {
+			t.Fatalf("DecodeCursor: unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("DecodeCursor: want %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		// the positions out of postgres range
+		overflows := []string{
+			parser.EncodeCursor(parser.Position{BlockNumber: math.MaxUint64, Hash: "0xa"}),
+			parser.EncodeCursor(parser.Position{BlockNumber: 1, TransactionIndex: math.MaxInt64 + 1, Hash: "0xa"}),
+		}
+		for _, cursor := range append([]string{"not base64!", "MS4y", "YS4yLjB4YQ", "MS4yLg"}, overflows...) {
+			if _, err := parser.DecodeCursor(cursor); !errors.Is(err, parser.ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q): expected %v, got %v", cursor, parser.ErrInvalidCursor, err)
+			}
+		}
+	})
+}
+
+func TestNewTransactionPage(t *testing.T) {
+	txs := []parser.Transaction{
+		{Hash: "0xa", BlockNumber: 1},
+		{Hash: "0xb", BlockNumber: 2, TransactionIndex: 4},
+		{Hash: "0xc", BlockNumber: 3},
+	}
+
+	t.Run("last page", func(t *testing.T) {
+		page := parser.NewTransactionPage(txs, 3)
+		if !reflect.DeepEqual(page.Transactions, txs) || page.NextCursor != "" {
+			t.Errorf("NewTransactionPage: expected every transaction and no cursor, got %+v", page)
+		}
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		page := parser.NewTransactionPage(txs, 2)
+		if !reflect.DeepEqual(page.Transactions, txs[:2]) {
+			t.Errorf("NewTransactionPage: want %+v, got %+v", txs[:2], page.Transactions)
+		}
+
+		position, err := parser.DecodeCursor(page.NextCursor)
+		if err != nil || position != parser.PositionOf(txs[1]) {
+			t.Errorf("NewTransactionPage: expected the cursor of %+v, got %+v, %v", txs[1], position, err)
+		}
+	})
+}
@@ -11,6 +11,27 @@ type Parser interface {
 	// add address to observer
 	Subscribe(ctx context.Context, address string) error
 
-	// list of inbound or outbound transactions for an address
-	GetTransactions(ctx context.Context, address string) ([]Transaction, error)
+	// remove address from observer, deleting its stored transactions when purge is set
+	Unsubscribe(ctx context.Context, address string, purge bool) error
+
+	// page of the inbound or outbound transactions for an address
+	GetTransactions(ctx context.Context, address string, query TransactionQuery) (TransactionPage, error)
+
+	// running native balance of a subscribed address
+	GetBalance(ctx context.Context, address string) (Balance, error)
+
+	// nonce usage and stuck or replaced outbound transactions of an address
+	GetNonces(ctx context.Context, address string) (NonceReport, error)
+
+	// submit a signed raw transaction and track it, subscribing its sender
+	Broadcast(ctx context.Context, rawTx string) (BroadcastTransaction, error)
+
+	// lifecycle of a broadcast transaction
+	GetBroadcast(ctx context.Context, hash string) (BroadcastTransaction, error)
+
+	// what the retention policy pruned from the storage so far
+	GetCompactionMetrics(ctx context.Context) (CompactionMetrics, error)
+
+	// rate limit budget and usage of the node endpoints
+	GetNodeUsage(ctx context.Context) ([]NodeUsage, error)
 }
@@ -63,13 +63,13 @@ func (svc *service) GetCurrentBlock(ctx context.Context) (int64, error) {
 	return parser.GetCurrentBlock(ctx)
 }
 
-func (svc *service) GetTransactions(ctx context.Context, address string) ([]Transaction, error) {
+func (svc *service) GetTransactions(ctx context.Context, address string, query TransactionQuery) (TransactionPage, error) {
 	parser, err := svc.getParser(EthereumChainID)
 	if err != nil {
-		return nil, err
+		return TransactionPage{}, err
 	}
 
-	return parser.GetTransactions(ctx, address)
+	return parser.GetTransactions(ctx, address, query)
 }
 
 func (svc *service) Subscribe(ctx context.Context, address string) error {
@@ -80,3 +80,66 @@ func (svc *service) Subscribe(ctx context.Context, address string) error {
 
 	return parser.Subscribe(ctx, address)
 }
+
+func (svc *service) Unsubscribe(ctx context.Context, address string, purge bool) error {
+	parser, err := svc.getParser(EthereumChainID)
+	if err != nil {
+		return err
+	}
+
+	return parser.Unsubscribe(ctx, address, purge)
+}
+
+func (svc *service) GetBalance(ctx context.Context, address string) (Balance, error) {
+	parser, err := svc.getParser(EthereumChainID)
+	if err != nil {
+		return Balance{}, err
+	}
+
+	return parser.GetBalance(ctx, address)
+}
+
+func (svc *service) GetNonces(ctx context.Context, address string) (NonceReport, error) {
+	parser, err := svc.getParser(EthereumChainID)
+	if err != nil {
+		return NonceReport{}, err
+	}
+
+	return parser.GetNonces(ctx, address)
+}
+
+func (svc *service) Broadcast(ctx context.Context, rawTx string) (BroadcastTransaction, error) {
+	parser, err := svc.getParser(EthereumChainID)
+	if err != nil {
+		return BroadcastTransaction{}, err
+	}
+
+	return parser.Broadcast(ctx, rawTx)
+}
+
+func (svc *service) GetBroadcast(ctx context.Context, hash string) (BroadcastTransaction, error) {
+	parser, err := svc.getParser(EthereumChainID)
+	if err != nil {
+		return BroadcastTransaction{}, err
+	}
+
+	return parser.GetBroadcast(ctx, hash)
+}
+
+func (svc *service) GetCompactionMetrics(ctx context.Context) (CompactionMetrics, error) {
+	parser, err := svc.getParser(EthereumChainID)
+	if err != nil {
+		return CompactionMetrics{}, err
+	}
+
+	return parser.GetCompactionMetrics(ctx)
+}
+
+func (svc *service) GetNodeUsage(ctx context.Context) ([]NodeUsage, error) {
+	parser, err := svc.getParser(EthereumChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	return parser.GetNodeUsage(ctx)
+}
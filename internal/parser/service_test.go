@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
 	"github.com/jeronimobarea/transaction_parser/internal/test"
 	"github.com/jeronimobarea/transaction_parser/internal/test/evmtest"
 	"github.com/jeronimobarea/transaction_parser/internal/test/parsertest"
@@ -44,14 +45,16 @@ func TestService_GetTransactions(t *testing.T) {
 
 	t.Run("happy path", func(t *testing.T) {
 		var (
-			expected = []parser.Transaction{{Hash: "h1", From: evmtest.EVMZeroValueAddress, To: evmtest.EVMZeroValueAddress, Value: "10", BlockNumber: "0x1"}}
+			expected = parser.TransactionPage{
+				Transactions: []parser.Transaction{{Hash: "h1", From: evmtest.EVMZeroValueAddress, To: evmtest.EVMZeroValueAddress, Value: evm.QuantityFromUint64(10), BlockNumber: 1}},
+			}
 
 			svc = parser.NewService(logger)
 		)
 
 		svc.Register(1, &parsertest.FakeParserSvc{GetTransactionsResp: expected})
 
-		got, err := svc.GetTransactions(context.Background(), evmtest.EVMZeroValueAddress.String())
+		got, err := svc.GetTransactions(context.Background(), evmtest.EVMZeroValueAddress.String(), parser.TransactionQuery{})
 		if err != nil {
 			t.Fatalf("GetTransactions success: unexpected error %v", err)
 		}
@@ -63,7 +66,7 @@ func TestService_GetTransactions(t *testing.T) {
 	t.Run("no parser", func(t *testing.T) {
 		svc := parser.NewService(logger)
 
-		_, err := svc.GetTransactions(context.Background(), evmtest.EVMZeroValueAddress.String())
+		_, err := svc.GetTransactions(context.Background(), evmtest.EVMZeroValueAddress.String(), parser.TransactionQuery{})
 		if !errors.Is(err, parser.ErrLoadingParser) {
 			t.Errorf("GetTransactions no parser: expected %v, got %v", parser.ErrLoadingParser, err)
 		}
@@ -76,7 +79,7 @@ func TestService_GetTransactions(t *testing.T) {
 			GetTransactionsErr: test.DummyErr,
 		})
 
-		_, err := svc.GetTransactions(context.Background(), evmtest.EVMZeroValueAddress.String())
+		_, err := svc.GetTransactions(context.Background(), evmtest.EVMZeroValueAddress.String(), parser.TransactionQuery{})
 		if !errors.Is(err, test.DummyErr) {
 			t.Errorf("GetTransactions not subscribed: expected %v, got %v", test.DummyErr, err)
 		}
@@ -111,3 +114,27 @@ func TestService_Subscribe(t *testing.T) {
 		}
 	})
 }
+
+func TestService_Unsubscribe(t *testing.T) {
+	logger := log.Default()
+
+	t.Run("happy path", func(t *testing.T) {
+		svc := parser.NewService(logger)
+		svc.Register(1, &parsertest.FakeParserSvc{})
+
+		err := svc.Unsubscribe(context.Background(), evmtest.EVMZeroValueAddress.String(), true)
+		if err != nil {
+			t.Fatalf("Unsubscribe: unexpected error: %v", err)
+		}
+	})
+
+	t.Run("parser error", func(t *testing.T) {
+		svc := parser.NewService(logger)
+		svc.Register(1, &parsertest.FakeParserSvc{UnsubscribeErr: test.DummyErr})
+
+		err := svc.Unsubscribe(context.Background(), evmtest.EVMZeroValueAddress.String(), false)
+		if !errors.Is(err, test.DummyErr) {
+			t.Errorf("Unsubscribe: expected %v, got %v", test.DummyErr, err)
+		}
+	})
+}
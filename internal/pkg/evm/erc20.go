@@ -0,0 +1,36 @@
+package evm
+
+import (
+	"math/big"
+	"strings"
+)
+
+// ERC20TransferSelector is the 4 byte selector of transfer(address,uint256).
+const ERC20TransferSelector = "a9059cbb"
+
+const abiWordHexLen = 64
+
+// DecodeERC20Transfer decodes the calldata of an ERC-20 transfer(address,uint256) call,
+// returning the token recipient and the raw token amount.
+func DecodeERC20Transfer(input string) (Address, Quantity, bool) {
+	data, ok := strings.CutPrefix(input, "0x"+ERC20TransferSelector)
+	if !ok || len(data) != 2*abiWordHexLen {
+		return "", Quantity{}, false
+	}
+
+	recipientWord, amountWord := data[:abiWordHexLen], data[abiWordHexLen:]
+	if strings.TrimLeft(recipientWord[:abiWordHexLen-40], "0") != "" {
+		return "", Quantity{}, false
+	}
+
+	recipient := Address("0x" + recipientWord[abiWordHexLen-40:])
+	if recipient.Validate() != nil {
+		return "", Quantity{}, false
+	}
+
+	amount, ok := new(big.Int).SetString(amountWord, 16)
+	if !ok {
+		return "", Quantity{}, false
+	}
+	return recipient, Quantity{v: amount}, true
+}
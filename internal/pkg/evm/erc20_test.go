@@ -0,0 +1,46 @@
+package evm_test
+
+import (
+	"testing"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+func TestDecodeERC20Transfer(t *testing.T) {
+	const (
+		recipientWord = "000000000000000000000000abcdefabcdefabcdefabcdefabcdefabcdefabcd"
+		amountWord    = "00000000000000000000000000000000000000000000000000000000000f4240"
+	)
+
+	testCases := []struct {
+		name      string
+		input     string
+		recipient evm.Address
+		amount    string
+		ok        bool
+	}{
+		{name: "happy path", input: "0xa9059cbb" + recipientWord + amountWord, recipient: "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd", amount: "1000000", ok: true},
+		{name: "plain transfer", input: "0x", ok: false},
+		{name: "other selector", input: "0x095ea7b3" + recipientWord + amountWord, ok: false},
+		{name: "truncated calldata", input: "0xa9059cbb" + recipientWord, ok: false},
+		{name: "dirty address padding", input: "0xa9059cbb" + "1" + recipientWord[1:] + amountWord, ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipient, amount, ok := evm.DecodeERC20Transfer(tc.input)
+			if ok != tc.ok {
+				t.Fatalf("DecodeERC20Transfer(%q): want ok=%v, got %v", tc.input, tc.ok, ok)
+			}
+			if !tc.ok {
+				return
+			}
+			if recipient != tc.recipient {
+				t.Errorf("DecodeERC20Transfer(%q): want recipient %s, got %s", tc.input, tc.recipient, recipient)
+			}
+			if amount.String() != tc.amount {
+				t.Errorf("DecodeERC20Transfer(%q): want amount %s, got %s", tc.input, tc.amount, amount)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package evm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidQuantity = errors.New("error invalid quantity")
+
+// Unit is the number of decimals a denomination is shifted from wei.
+type Unit uint
+
+const (
+	Wei   Unit = 0
+	Gwei  Unit = 9
+	Ether Unit = 18
+)
+
+// Quantity is an arbitrary-precision integer encoded on the wire as a 0x-prefixed
+// hex string. The zero value is 0. Quantities parsed from the wire are never negative,
+// negative values only come from arithmetic, e.g. balance discrepancies.
+// Quantities are immutable, every operation returns a new value.
+type Quantity struct {
+	v *big.Int
+}
+
+func NewQuantity(v *big.Int) Quantity {
+	if v == nil {
+		return Quantity{}
+	}
+	return Quantity{v: new(big.Int).Set(v)}
+}
+
+func QuantityFromUint64(v uint64) Quantity {
+	return Quantity{v: new(big.Int).SetUint64(v)}
+}
+
+func ParseQuantity(hexValue string) (Quantity, error) {
+	digits, ok := strings.CutPrefix(hexValue, "0x")
+	if !ok || digits == "" {
+		return Quantity{}, fmt.Errorf("%w: %q", ErrInvalidQuantity, hexValue)
+	}
+
+	v, ok := new(big.Int).SetString(digits, 16)
+	if !ok || v.Sign() < 0 {
+		return Quantity{}, fmt.Errorf("%w: %q", ErrInvalidQuantity, hexValue)
+	}
+	return Quantity{v: v}, nil
+}
+
+// ParseDecimalQuantity parses a base 10 amount of wei.
+func ParseDecimalQuantity(value string) (Quantity, error) {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok || v.Sign() < 0 {
+		return Quantity{}, fmt.Errorf("%w: %q", ErrInvalidQuantity, value)
+	}
+	return Quantity{v: v}, nil
+}
+
+// ParseUnit parses a decimal number of the given unit, e.g. "1.5" Ether is 1500000000000000000
+// wei. Amounts finer than a wei are invalid.
+func ParseUnit(value string, unit Unit) (Quantity, error) {
+	whole, frac, _ := strings.Cut(value, ".")
+	if whole == "" || len(frac) > int(unit) || strings.HasPrefix(frac, "+") || strings.HasPrefix(frac, "-") {
+		return Quantity{}, fmt.Errorf("%w: %q", ErrInvalidQuantity, value)
+	}
+	return ParseDecimalQuantity(whole + frac + strings.Repeat("0", int(unit)-len(frac)))
+}
+
+func (q Quantity) Big() *big.Int {
+	if q.v == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(q.v)
+}
+
+func (q Quantity) IsZero() bool {
+	return q.v == nil || q.v.Sign() == 0
+}
+
+func (q Quantity) Sign() int {
+	if q.v == nil {
+		return 0
+	}
+	return q.v.Sign()
+}
+
+func (q Quantity) Cmp(other Quantity) int {
+	return q.Big().Cmp(other.Big())
+}
+
+func (q Quantity) Equal(other Quantity) bool {
+	return q.Cmp(other) == 0
+}
+
+func (q Quantity) Add(other Quantity) Quantity {
+	return Quantity{v: new(big.Int).Add(q.Big(), other.Big())}
+}
+
+func (q Quantity) Sub(other Quantity) Quantity {
+	return Quantity{v: new(big.Int).Sub(q.Big(), other.Big())}
+}
+
+func (q Quantity) Mul(other Quantity) Quantity {
+	return Quantity{v: new(big.Int).Mul(q.Big(), other.Big())}
+}
+
+func (q Quantity) Hex() string {
+	if q.Sign() < 0 {
+		return "-0x" + new(big.Int).Abs(q.Big()).Text(16)
+	}
+	return "0x" + q.Big().Text(16)
+}
+
+// String returns the quantity in base 10.
+func (q Quantity) String() string {
+	return q.Big().String()
+}
+
+// FormatUnit renders the quantity as a decimal number of the given unit,
+// without trailing fractional zeros, e.g. 1500000000000000000 wei in Ether is "1.5".
+func (q Quantity) FormatUnit(unit Unit) string {
+	if unit == Wei {
+		return q.String()
+	}
+
+	sign := ""
+	if q.Sign() < 0 {
+		sign = "-"
+	}
+
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(unit)), nil)
+	whole, frac := new(big.Int).QuoRem(new(big.Int).Abs(q.Big()), divisor, new(big.Int))
+	if frac.Sign() == 0 {
+		return sign + whole.String()
+	}
+
+	fracDigits := frac.String()
+	fracDigits = strings.Repeat("0", int(unit)-len(fracDigits)) + fracDigits
+	return sign + whole.String() + "." + strings.TrimRight(fracDigits, "0")
+}
+
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.Hex())
+}
+
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	var hexValue string
+	if err := json.Unmarshal(data, &hexValue); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidQuantity, data)
+	}
+
+	parsed, err := ParseQuantity(hexValue)
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+// BlockNumber is a block height, encoded on the wire as a 0x-prefixed hex string and null
+// for pending transactions.
+type BlockNumber uint64
+
+func ParseBlockNumber(hexValue string) (BlockNumber, error) {
+	value, err := parseHexUint64(hexValue)
+	return BlockNumber(value), err
+}
+
+func (b BlockNumber) Hex() string {
+	return "0x" + strconv.FormatUint(uint64(b), 16)
+}
+
+// String returns the block number in base 10.
+func (b BlockNumber) String() string {
+	return strconv.FormatUint(uint64(b), 10)
+}
+
+func (b BlockNumber) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.Hex())
+}
+
+func (b *BlockNumber) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	value, err := unmarshalHexUint64(data)
+	if err != nil {
+		return err
+	}
+	*b = BlockNumber(value)
+	return nil
+}
+
+// Nonce is an account transaction counter, encoded on the wire as a 0x-prefixed hex string,
+// null decoding as zero.
+type Nonce uint64
+
+func ParseNonce(hexValue string) (Nonce, error) {
+	value, err := parseHexUint64(hexValue)
+	return Nonce(value), err
+}
+
+func (n Nonce) Hex() string {
+	return "0x" + strconv.FormatUint(uint64(n), 16)
+}
+
+// String returns the nonce in base 10.
+func (n Nonce) String() string {
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+func (n Nonce) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Hex())
+}
+
+func (n *Nonce) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	value, err := unmarshalHexUint64(data)
+	if err != nil {
+		return err
+	}
+	*n = Nonce(value)
+	return nil
+}
+
+// TransactionIndex is the position of a transaction in its block, encoded on the wire as a
+// 0x-prefixed hex string and null for pending transactions.
+type TransactionIndex uint64
+
+func (i TransactionIndex) Hex() string {
+	return "0x" + strconv.FormatUint(uint64(i), 16)
+}
+
+// String returns the index in base 10.
+func (i TransactionIndex) String() string {
+	return strconv.FormatUint(uint64(i), 10)
+}
+
+func (i TransactionIndex) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.Hex())
+}
+
+func (i *TransactionIndex) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	value, err := unmarshalHexUint64(data)
+	if err != nil {
+		return err
+	}
+	*i = TransactionIndex(value)
+	return nil
+}
+
+// Timestamp is a block time in seconds since the Unix epoch, encoded on the wire as a
+// 0x-prefixed hex string.
+type Timestamp uint64
+
+func (t Timestamp) Hex() string {
+	return "0x" + strconv.FormatUint(uint64(t), 16)
+}
+
+func (t Timestamp) Time() time.Time {
+	return time.Unix(int64(t), 0).UTC()
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Hex())
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalHexUint64(data)
+	if err != nil {
+		return err
+	}
+	*t = Timestamp(value)
+	return nil
+}
+
+func parseHexUint64(hexValue string) (uint64, error) {
+	digits, ok := strings.CutPrefix(hexValue, "0x")
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidQuantity, hexValue)
+	}
+
+	value, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q: %v", ErrInvalidQuantity, hexValue, err)
+	}
+	return value, nil
+}
+
+func unmarshalHexUint64(data []byte) (uint64, error) {
+	var hexValue string
+	if err := json.Unmarshal(data, &hexValue); err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidQuantity, data)
+	}
+	return parseHexUint64(hexValue)
+}
@@ -0,0 +1,217 @@
+package evm_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+func TestParseQuantity(t *testing.T) {
+	testCases := []struct {
+		name     string
+		hexValue string
+		want     string
+		fails    bool
+	}{
+		{name: "happy path", hexValue: "0x4b7", want: "1207"},
+		{name: "happy path zero value", hexValue: "0x0", want: "0"},
+		{name: "beyond int64", hexValue: "0xffffffffffffffffffffffff", want: "79228162514264337593543950335"},
+		{name: "missing 0x prefix", hexValue: "4b7", fails: true},
+		{name: "proper prefix wrong format", hexValue: "0xZZZ", fails: true},
+		{name: "prefix only", hexValue: "0x", fails: true},
+		{name: "negative", hexValue: "0x-1", fails: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := evm.ParseQuantity(tc.hexValue)
+			if tc.fails {
+				if !errors.Is(err, evm.ErrInvalidQuantity) {
+					t.Errorf("ParseQuantity(%q): expected %v, got %v", tc.hexValue, evm.ErrInvalidQuantity, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuantity(%q): unexpected error: %v", tc.hexValue, err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("ParseQuantity(%q): want %s, got %s", tc.hexValue, tc.want, got)
+			}
+			if got.Hex() != tc.hexValue {
+				t.Errorf("ParseQuantity(%q).Hex(): got %s", tc.hexValue, got.Hex())
+			}
+		})
+	}
+}
+
+func TestQuantity_FormatUnit(t *testing.T) {
+	oneAndHalfEther, _ := evm.ParseDecimalQuantity("1500000000000000000")
+
+	testCases := []struct {
+		name  string
+		value evm.Quantity
+		unit  evm.Unit
+		want  string
+	}{
+		{name: "wei", value: oneAndHalfEther, unit: evm.Wei, want: "1500000000000000000"},
+		{name: "gwei", value: oneAndHalfEther, unit: evm.Gwei, want: "1500000000"},
+		{name: "ether", value: oneAndHalfEther, unit: evm.Ether, want: "1.5"},
+		{name: "one wei in ether", value: evm.QuantityFromUint64(1), unit: evm.Ether, want: "0.000000000000000001"},
+		{name: "zero value", value: evm.Quantity{}, unit: evm.Ether, want: "0"},
+		{name: "negative", value: evm.Quantity{}.Sub(oneAndHalfEther), unit: evm.Ether, want: "-1.5"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.value.FormatUnit(tc.unit); got != tc.want {
+				t.Errorf("FormatUnit(%d): want %s, got %s", tc.unit, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseUnit(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+		unit  evm.Unit
+		want  string
+		fails bool
+	}{
+		{name: "wei", value: "1500000000000000000", unit: evm.Wei, want: "1500000000000000000"},
+		{name: "gwei", value: "1500000000", unit: evm.Gwei, want: "1500000000000000000"},
+		{name: "ether", value: "1.5", unit: evm.Ether, want: "1500000000000000000"},
+		{name: "one wei in ether", value: "0.000000000000000001", unit: evm.Ether, want: "1"},
+		{name: "finer than a wei", value: "0.5", unit: evm.Wei, fails: true},
+		{name: "negative", value: "-1.5", unit: evm.Ether, fails: true},
+		{name: "missing whole part", value: ".5", unit: evm.Ether, fails: true},
+		{name: "signed fraction", value: "1.-5", unit: evm.Ether, fails: true},
+		{name: "not a number", value: "ten", unit: evm.Ether, fails: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := evm.ParseUnit(tc.value, tc.unit)
+			if tc.fails {
+				if !errors.Is(err, evm.ErrInvalidQuantity) {
+					t.Errorf("ParseUnit(%q, %d): expected %v, got %v", tc.value, tc.unit, evm.ErrInvalidQuantity, err)
+				}
+				return
+			}
+			if err != nil || got.String() != tc.want {
+				t.Errorf("ParseUnit(%q, %d): want %s, got %s, %v", tc.value, tc.unit, tc.want, got, err)
+			}
+		})
+	}
+}
+
+func TestQuantity_JSON(t *testing.T) {
+	var got struct {
+		Value       evm.Quantity    `json:"value"`
+		BlockNumber evm.BlockNumber `json:"blockNumber"`
+	}
+
+	err := json.Unmarshal([]byte(`{"value":"0x2bf5fe4aff5181","blockNumber":"0x1550035"}`), &got)
+	if err != nil {
+		t.Fatalf("unmarshal: unexpected error: %v", err)
+	}
+	if got.Value.String() != "12373896527303041" {
+		t.Errorf("unmarshal value: got %s", got.Value)
+	}
+	if got.BlockNumber != 22347829 {
+		t.Errorf("unmarshal block number: got %d", got.BlockNumber)
+	}
+
+	out, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("marshal: unexpected error: %v", err)
+	}
+	if want := `{"value":"0x2bf5fe4aff5181","blockNumber":"0x1550035"}`; string(out) != want {
+		t.Errorf("marshal: want %s, got %s", want, out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"value":12}`), &got); !errors.Is(err, evm.ErrInvalidQuantity) {
+		t.Errorf("unmarshal non string value: expected %v, got %v", evm.ErrInvalidQuantity, err)
+	}
+}
+
+func TestTransactionIndex_JSON(t *testing.T) {
+	var got struct {
+		Mined   evm.TransactionIndex `json:"mined"`
+		Pending evm.TransactionIndex `json:"pending"`
+	}
+
+	err := json.Unmarshal([]byte(`{"mined":"0x1a","pending":null}`), &got)
+	if err != nil {
+		t.Fatalf("unmarshal: unexpected error: %v", err)
+	}
+	if got.Mined != 26 || got.Pending != 0 {
+		t.Errorf("unmarshal: want 26 and 0, got %d and %d", got.Mined, got.Pending)
+	}
+
+	if err := json.Unmarshal([]byte(`{"mined":"26"}`), &got); !errors.Is(err, evm.ErrInvalidQuantity) {
+		t.Errorf("unmarshal base 10 index: expected %v, got %v", evm.ErrInvalidQuantity, err)
+	}
+}
+
+func TestBlockNumber_JSONNull(t *testing.T) {
+	var got struct {
+		BlockNumber evm.BlockNumber `json:"blockNumber"`
+		Nonce       evm.Nonce       `json:"nonce"`
+	}
+
+	// a pending transaction isn't in a block yet
+	err := json.Unmarshal([]byte(`{"blockNumber":null,"nonce":null}`), &got)
+	if err != nil {
+		t.Fatalf("unmarshal: unexpected error: %v", err)
+	}
+	if got.BlockNumber != 0 || got.Nonce != 0 {
+		t.Errorf("unmarshal: want 0 and 0, got %d and %d", got.BlockNumber, got.Nonce)
+	}
+}
+
+func TestTimestamp_JSON(t *testing.T) {
+	var got evm.Timestamp
+	if err := json.Unmarshal([]byte(`"0x6553f100"`), &got); err != nil {
+		t.Fatalf("unmarshal: unexpected error: %v", err)
+	}
+	if want := time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC); !got.Time().Equal(want) {
+		t.Errorf("Time(): want %s, got %s", want, got.Time())
+	}
+}
+
+func TestParseBlockNumber(t *testing.T) {
+	testCases := []struct {
+		name     string
+		hexValue string
+		want     evm.BlockNumber
+		fails    bool
+	}{
+		{name: "happy path", hexValue: "0x4b7", want: 1207},
+		{name: "happy path zero value", hexValue: "0x0", want: 0},
+		{name: "missing 0x prefix", hexValue: "4b7", fails: true},
+		{name: "proper prefix wrong format", hexValue: "0xZZZ", fails: true},
+		{name: "prefix only", hexValue: "0x", fails: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := evm.ParseBlockNumber(tc.hexValue)
+			if tc.fails {
+				if !errors.Is(err, evm.ErrInvalidQuantity) {
+					t.Errorf("ParseBlockNumber(%q): expected %v, got %v", tc.hexValue, evm.ErrInvalidQuantity, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBlockNumber(%q): unexpected error: %v", tc.hexValue, err)
+			}
+			if got != tc.want {
+				t.Errorf("ParseBlockNumber(%q): want %d, got %d", tc.hexValue, tc.want, got)
+			}
+		})
+	}
+}
@@ -2,14 +2,25 @@ package platform
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	_ "github.com/lib/pq"
+
 	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/broadcast"
 	ethereumClient "github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/ledger"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/nonces"
 	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/pollers"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/pricing"
 	ethereumRepository "github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/repository"
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/retention"
 	"github.com/jeronimobarea/transaction_parser/internal/parser"
 	parserHandlers "github.com/jeronimobarea/transaction_parser/internal/parser/handlers"
 	"github.com/jeronimobarea/transaction_parser/pkg/httpx"
@@ -19,22 +30,284 @@ import (
 func Run(ctx context.Context) {
 	logger := log.Default()
 
+	router := NewRouter(ctx, logger)
+
+	httpServerPort := osx.GetEnvFallback("HTTP_SERVER_PORT", ":3000")
+	logger.Printf("Server listening on: %s \n", httpServerPort)
+	http.ListenAndServe(httpServerPort, router)
+}
+
+// NewRouter wires the parsers configured in the environment, runs their background
+// jobs until ctx is done and registers their routes.
+func NewRouter(ctx context.Context, logger *log.Logger) *httpx.Router {
 	var ethereumParser parser.Parser
 	{
+		// bounds each run of the background jobs, so a hung node can't block them
+		pollTimeout, err := osx.GetEnvDurationFallback("ETHEREUM_POLL_TIMEOUT", time.Minute)
+		if err != nil {
+			logger.Fatal(err)
+		}
 
-		ethereumNodePRCUrl := osx.GetEnvFallback("ETHEREUM_NODE_RPC_URL", "https://ethereum-rpc.publicnode.com")
-		ethClient := ethereumClient.NewClient(ethereumNodePRCUrl, logger)
-		ethereumRepo := ethereumRepository.NewMemoryStorage()
+		var ethClient ethereumClient.Client
+		{
+			ethereumNodeRPCUrls := osx.GetEnvListFallback("ETHEREUM_NODE_RPC_URL", []string{"https://ethereum-rpc.publicnode.com"})
+			if err := ethereumClient.ValidateURLs(ethereumNodeRPCUrls); err != nil {
+				logger.Fatal(err)
+			}
 
-		ethereumParser = ethereum.NewEthereumParser(ethereumRepo, logger)
+			rateLimit, err := strconv.ParseFloat(osx.GetEnvFallback("ETHEREUM_NODE_RATE_LIMIT", "0"), 64)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			rateBurst, err := strconv.ParseFloat(osx.GetEnvFallback("ETHEREUM_NODE_RATE_BURST", "0"), 64)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			methodCosts, err := ethereumClient.ParseMethodCosts(osx.GetEnvFallback("ETHEREUM_NODE_METHOD_COSTS", ""))
+			if err != nil {
+				logger.Fatal(err)
+			}
+			auths, err := ethereumClient.ParseAuth(osx.GetEnvFallback("ETHEREUM_NODE_RPC_AUTH", ""))
+			if err != nil {
+				logger.Fatal(err)
+			}
+			nodeTimeout, err := osx.GetEnvDurationFallback("ETHEREUM_NODE_TIMEOUT", 30*time.Second)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			maxResponseSize, err := strconv.ParseInt(osx.GetEnvFallback("ETHEREUM_NODE_MAX_RESPONSE_SIZE", "67108864"), 10, 64)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			chainID, err := strconv.ParseUint(osx.GetEnvFallback("ETHEREUM_CHAIN_ID", "1"), 10, 64)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			networkID, err := strconv.ParseUint(osx.GetEnvFallback("ETHEREUM_NETWORK_ID", strconv.FormatUint(chainID, 10)), 10, 64)
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			ethClient = ethereumClient.NewClient(
+				ethereumNodeRPCUrls,
+				logger,
+				ethereumClient.WithRateLimit(rateLimit, rateBurst, methodCosts),
+				ethereumClient.WithAuth(auths),
+				ethereumClient.WithMaxResponseSize(maxResponseSize),
+				ethereumClient.WithTimeout(nodeTimeout),
+				ethereumClient.WithChainID(chainID, networkID),
+			)
+
+			// refuse to start against nodes on another chain or still syncing, an unreachable or hung
+			// node is left to the health checks
+			healthCtx, cancel := context.WithTimeout(ctx, pollTimeout)
+			err = ethClient.CheckHealth(healthCtx)
+			cancel()
+			if errors.Is(err, ethereumClient.ErrWrongChain) || errors.Is(err, ethereumClient.ErrNodeSyncing) {
+				logger.Fatal(err)
+			}
+
+			cacheSize, err := strconv.Atoi(osx.GetEnvFallback("ETHEREUM_CACHE_SIZE", "10000"))
+			if err != nil {
+				logger.Fatal(err)
+			}
+			cacheDir := osx.GetEnvFallback("ETHEREUM_CACHE_DIR", "")
+
+			if cacheSize > 0 || cacheDir != "" {
+				finality, err := strconv.ParseUint(osx.GetEnvFallback("ETHEREUM_CACHE_FINALITY", "64"), 10, 64)
+				if err != nil {
+					logger.Fatal(err)
+				}
+
+				ethClient, err = ethereumClient.NewCachingClient(ethClient, finality, cacheSize, cacheDir, logger)
+				if err != nil {
+					logger.Fatal(err)
+				}
+			}
+		}
+
+		var ethereumRepo ethereum.Repository
+		{
+			switch storage := osx.GetEnvFallback("ETHEREUM_STORAGE", "memory"); storage {
+			case "memory":
+				ethereumRepo = ethereumRepository.NewMemoryStorage()
+
+			case "bolt":
+				storagePath := osx.GetEnvFallback("ETHEREUM_STORAGE_PATH", "data/ethereum.db")
+				boltRepo, err := ethereumRepository.NewBoltStorage(storagePath)
+				if err != nil {
+					logger.Fatal(err)
+				}
+				ethereumRepo = boltRepo
+
+				go func() {
+					<-ctx.Done()
+					boltRepo.(io.Closer).Close()
+				}()
+
+			case "postgres":
+				db, err := sql.Open("postgres", osx.GetEnvFallback("ETHEREUM_STORAGE_DSN", ""))
+				if err != nil {
+					logger.Fatal(err)
+				}
+				ethereumRepo, err = ethereumRepository.NewPostgresStorage(ctx, db)
+				if err != nil {
+					logger.Fatal(err)
+				}
+
+				go func() {
+					<-ctx.Done()
+					db.Close()
+				}()
+
+			default:
+				logger.Fatalf("unknown ETHEREUM_STORAGE %q, expected memory, bolt or postgres", storage)
+			}
+		}
+
+		var ethereumPricer ethereum.Pricer
+		{
+			priceFeeds, err := pricing.ParseFeeds(osx.GetEnvFallback("ETHEREUM_PRICE_FEEDS", pricing.DefaultFeeds))
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			if len(priceFeeds) > 0 {
+				priceCurrency := osx.GetEnvFallback("ETHEREUM_PRICE_CURRENCY", "USD")
+				ethereumPricer = pricing.NewPricer(ethClient, priceCurrency, priceFeeds, logger)
+			}
+		}
+
+		ethereumLedger := ledger.NewLedger(ethClient, ethereumRepo, logger)
+
+		stuckAfter, err := osx.GetEnvDurationFallback("ETHEREUM_STUCK_TX_AFTER", 10*time.Minute)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		nonceTracker := nonces.NewTracker(ethClient, ethereumRepo, stuckAfter, logger)
+
+		confirmations, err := strconv.ParseUint(osx.GetEnvFallback("ETHEREUM_BROADCAST_CONFIRMATIONS", "12"), 10, 64)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		resolveGrace, err := osx.GetEnvDurationFallback("ETHEREUM_BROADCAST_RESOLVE_GRACE", 2*time.Minute)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		broadcaster := broadcast.NewBroadcaster(ethClient, ethereumRepo, confirmations, resolveGrace, logger)
+
+		var retentionPolicy ethereum.RetentionPolicy
+		{
+			retentionPolicy.MaxAge, err = osx.GetEnvDurationFallback("ETHEREUM_RETENTION_MAX_AGE", 0)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			retentionPolicy.MaxTransactionsPerAddress, err = strconv.Atoi(osx.GetEnvFallback("ETHEREUM_RETENTION_MAX_TRANSACTIONS", "0"))
+			if err != nil {
+				logger.Fatal(err)
+			}
+			retentionPolicy.MaxBytes, err = strconv.ParseInt(osx.GetEnvFallback("ETHEREUM_RETENTION_MAX_BYTES", "0"), 10, 64)
+			if err != nil {
+				logger.Fatal(err)
+			}
+		}
+
+		var compactor ethereum.Compactor
+		if retentionPolicy.Enabled() {
+			evicter, ok := ethereumRepo.(ethereum.Evicter)
+			if !ok {
+				logger.Fatal("ETHEREUM_RETENTION_* policies are only supported by the memory storage")
+			}
+
+			var archiver ethereum.Archiver
+			if archivePath := osx.GetEnvFallback("ETHEREUM_RETENTION_ARCHIVE", ""); archivePath != "" {
+				archiver, err = retention.NewFileArchiver(archivePath)
+				if err != nil {
+					logger.Fatal(err)
+				}
+			}
+
+			retentionRate, err := osx.GetEnvDurationFallback("ETHEREUM_RETENTION_INTERVAL", time.Minute)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			retentionRunner := pollers.NewRunner(logger, retentionRate, pollTimeout)
+			compactor = retention.NewCompactor(evicter, ethereumLedger, archiver, retentionPolicy, logger)
+
+			go func() {
+				err := retentionRunner.Run(ctx, retention.NewCompactionPoller(compactor))
+				if err != nil && ctx.Err() == nil {
+					logger.Fatal(err)
+				}
+			}()
+		}
+
+		ethereumParser = ethereum.NewEthereumParser(
+			ethereumRepo,
+			ethereumPricer,
+			ethereumLedger,
+			nonceTracker,
+			broadcaster,
+			compactor,
+			pollers.NewNodeMonitor(ethClient),
+			logger,
+		)
 
 		poller := pollers.NewPoller(ethClient, ethereumRepo, logger)
 
-		runner := pollers.NewRunner(logger, 5*time.Second)
+		pollRate, err := osx.GetEnvDurationFallback("ETHEREUM_POLL_INTERVAL", 5*time.Second)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		runner := pollers.NewRunner(logger, pollRate, pollTimeout)
 
 		go func() {
 			err := runner.Run(ctx, poller)
-			if err != nil {
+			if err != nil && ctx.Err() == nil {
+				logger.Fatal(err)
+			}
+		}()
+
+		reconcileRate, err := osx.GetEnvDurationFallback("ETHEREUM_RECONCILE_INTERVAL", time.Minute)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		reconcileRunner := pollers.NewRunner(logger, reconcileRate, pollTimeout)
+
+		go func() {
+			err := reconcileRunner.Run(ctx, ledger.NewReconciler(ethereumLedger))
+			if err != nil && ctx.Err() == nil {
+				logger.Fatal(err)
+			}
+		}()
+
+		broadcastRunner := pollers.NewRunner(logger, 5*time.Second, pollTimeout)
+
+		go func() {
+			err := broadcastRunner.Run(ctx, broadcast.NewTrackingPoller(broadcaster))
+			if err != nil && ctx.Err() == nil {
+				logger.Fatal(err)
+			}
+		}()
+
+		healthRate, err := osx.GetEnvDurationFallback("ETHEREUM_NODE_HEALTH_INTERVAL", 30*time.Second)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		healthRunner := pollers.NewRunner(logger, healthRate, pollTimeout)
+
+		go func() {
+			err := healthRunner.Run(ctx, pollers.NewHealthPoller(ethClient, logger))
+			if err != nil && ctx.Err() == nil {
+				logger.Fatal(err)
+			}
+		}()
+
+		pendingRunner := pollers.NewRunner(logger, 15*time.Second, pollTimeout)
+
+		go func() {
+			err := pendingRunner.Run(ctx, nonces.NewPendingPoller(nonceTracker))
+			if err != nil && ctx.Err() == nil {
 				logger.Fatal(err)
 			}
 		}()
@@ -55,8 +328,5 @@ func Run(ctx context.Context) {
 		//** Register routes **//
 		parserHandlers.RegisterRoutes(router, parserSvc, logger)
 	}
-
-	httpServerPort := osx.GetEnvFallback("HTTP_SERVER_PORT", ":3000")
-	logger.Printf("Server listening on: %s \n", httpServerPort)
-	http.ListenAndServe(httpServerPort, router)
+	return router
 }
@@ -0,0 +1,192 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+	"github.com/jeronimobarea/transaction_parser/internal/test/nodetest"
+)
+
+const (
+	alice = evm.Address("0x1111111111111111111111111111111111111111")
+	bob   = evm.Address("0x2222222222222222222222222222222222222222")
+	carol = evm.Address("0x3333333333333333333333333333333333333333")
+)
+
+type transaction struct {
+	Hash   string `json:"hash"`
+	From   string `json:"from"`
+	Value  string `json:"value"`
+	Fee    string `json:"fee"`
+	Status string `json:"status"`
+}
+
+func TestRouter_EndToEnd(t *testing.T) {
+	t.Run("ingests the transactions of subscribed addresses", func(t *testing.T) {
+		node := nodetest.NewNode(t)
+		server := newTestServer(t, node)
+
+		subscribe(t, server, alice)
+		block := node.Mine(
+			nodetest.Tx{From: bob, To: alice, Value: evm.QuantityFromUint64(1_000_000)},
+			nodetest.Tx{From: bob, To: carol, Value: evm.QuantityFromUint64(5)},
+		)
+
+		txs := waitForTransactions(t, server, alice, 1)
+		if txs[0].Hash != block.Transactions[0].Hash || txs[0].Value != "1000000" {
+			t.Errorf("unexpected transaction %+v", txs[0])
+		}
+		if txs[0].Fee != "21000000000000" || txs[0].Status != "success" {
+			t.Errorf("expected the receipt details, got %+v", txs[0])
+		}
+
+		var current struct {
+			BlockNumber int64 `json:"block_number"`
+		}
+		get(t, server, "/blocks/current", &current)
+		if current.BlockNumber != int64(block.Number) {
+			t.Errorf("current block: want %d, got %d", block.Number, current.BlockNumber)
+		}
+	})
+
+	t.Run("rides out node failures", func(t *testing.T) {
+		node := nodetest.NewNode(t)
+		server := newTestServer(t, node)
+
+		subscribe(t, server, alice)
+		node.FailNext(3, http.StatusServiceUnavailable)
+		node.SetLatency(5 * time.Millisecond)
+		node.Mine(nodetest.Tx{From: alice, To: bob, Value: evm.QuantityFromUint64(7)})
+
+		txs := waitForTransactions(t, server, alice, 1)
+		if txs[0].From != string(alice) || txs[0].Value != "7" {
+			t.Errorf("unexpected transaction %+v", txs[0])
+		}
+	})
+
+	t.Run("broadcasts a transaction still pending", func(t *testing.T) {
+		node := nodetest.NewNode(t)
+		server := newTestServer(t, node)
+
+		const rawTx = "0x02f8b00184"
+		node.AcceptRawTransaction(rawTx, nodetest.Tx{From: alice, To: bob, Value: evm.QuantityFromUint64(3)})
+
+		resp, err := http.Post(server.URL+"/transactions/broadcast", "application/json", strings.NewReader(`{"rawTransaction":"`+rawTx+`"}`))
+		if err != nil {
+			t.Fatalf("broadcast: unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("broadcast: want status 200, got %d", resp.StatusCode)
+		}
+
+		var broadcast struct {
+			Hash   string `json:"hash"`
+			From   string `json:"from"`
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&broadcast); err != nil {
+			t.Fatalf("broadcast: decoding response: %v", err)
+		}
+		// the node answers the lookup of the mempool transaction with a null block
+		if broadcast.From != string(alice) || broadcast.Status != "pending" {
+			t.Errorf("broadcast: expected a pending transaction from %s, got %+v", alice, broadcast)
+		}
+
+		var tracked struct {
+			Status string `json:"status"`
+		}
+		get(t, server, "/transactions/broadcast?hash="+broadcast.Hash, &tracked)
+		if tracked.Status != "pending" {
+			t.Errorf("GET /transactions/broadcast: want pending, got %+v", tracked)
+		}
+	})
+
+	t.Run("picks up reorganized blocks", func(t *testing.T) {
+		node := nodetest.NewNode(t)
+		server := newTestServer(t, node)
+
+		subscribe(t, server, alice)
+		node.Mine(nodetest.Tx{From: bob, To: alice, Value: evm.QuantityFromUint64(1)})
+		waitForTransactions(t, server, alice, 1)
+
+		replacement := node.Reorg(1, []nodetest.Tx{{From: carol, To: alice, Value: evm.QuantityFromUint64(2)}})
+
+		// both blocks have the same number, their transactions being ordered by hash
+		txs := waitForTransactions(t, server, alice, 2)
+		if !slices.ContainsFunc(txs, func(tx transaction) bool { return tx.Hash == replacement[0].Transactions[0].Hash }) {
+			t.Errorf("expected the transaction of the replacement block, got %+v", txs)
+		}
+	})
+}
+
+// newTestServer serves the application wired against the node, polling it every few milliseconds.
+func newTestServer(t *testing.T, node *nodetest.Node) *httptest.Server {
+	t.Setenv("ETHEREUM_NODE_RPC_URL", node.URL())
+	t.Setenv("ETHEREUM_POLL_INTERVAL", "10ms")
+	t.Setenv("ETHEREUM_PRICE_FEEDS", "")
+	t.Setenv("ETHEREUM_CACHE_SIZE", "0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	server := httptest.NewServer(NewRouter(ctx, log.New(io.Discard, "", 0)))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func subscribe(t *testing.T, server *httptest.Server, address evm.Address) {
+	resp, err := http.Post(server.URL+"/subscribe?address="+string(address), "application/json", nil)
+	if err != nil {
+		t.Fatalf("subscribe: unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("subscribe: want status 200, got %d", resp.StatusCode)
+	}
+}
+
+func get(t *testing.T, server *httptest.Server, path string, v any) {
+	resp, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatalf("GET %s: unexpected error: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: want status 200, got %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("GET %s: decoding response: %v", path, err)
+	}
+}
+
+// waitForTransactions waits for the poller to save count transactions for the address.
+func waitForTransactions(t *testing.T, server *httptest.Server, address evm.Address, count int) []transaction {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var page struct {
+			Transactions []transaction `json:"transactions"`
+		}
+		get(t, server, "/transactions?format=wei&address="+string(address), &page)
+		txs := page.Transactions
+		if len(txs) >= count {
+			return txs
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d transactions for %s, got %+v", count, address, txs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,27 @@
+package ethereumtest
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+)
+
+type FakeBroadcaster struct {
+	BroadcastResp    parser.BroadcastTransaction
+	BroadcastErr     error
+	GetBroadcastResp parser.BroadcastTransaction
+	GetBroadcastErr  error
+	TrackErr         error
+}
+
+func (f *FakeBroadcaster) Broadcast(_ context.Context, _ string) (parser.BroadcastTransaction, error) {
+	return f.BroadcastResp, f.BroadcastErr
+}
+
+func (f *FakeBroadcaster) GetBroadcast(_ context.Context, _ string) (parser.BroadcastTransaction, error) {
+	return f.GetBroadcastResp, f.GetBroadcastErr
+}
+
+func (f *FakeBroadcaster) Track(_ context.Context) error {
+	return f.TrackErr
+}
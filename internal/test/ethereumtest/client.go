@@ -2,15 +2,174 @@ package ethereumtest
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 
 	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
 )
 
 type FakeClient struct {
 	GetBlockResp []client.TransactionResponse
 	GetBlockErr  error
+	CallResp     []byte
+	CallErr      error
+
+	BlockNumberResp evm.BlockNumber
+	BlockNumberErr  error
+	GetBalanceResp  map[evm.Address]evm.Quantity
+	GetBalanceErr   error
+	GetReceiptResp  map[string]*client.ReceiptResponse
+	GetReceiptErr   error
+	// GetReceiptNotFound makes unregistered hashes return client.ErrReceiptNotFound.
+	GetReceiptNotFound bool
+
+	// GetBlockPendingResp is returned instead of GetBlockResp for the pending block.
+	GetBlockPendingResp []client.TransactionResponse
+	GetTxCountResp      map[string]evm.Nonce
+	GetTxCountErr       error
+
+	// GetTxByHashResp returns client.ErrTransactionNotFound for unregistered hashes.
+	GetTxByHashResp map[string]*client.TransactionResponse
+	GetTxByHashErr  error
+	SendRawTxResp   string
+	SendRawTxErr    error
+
+	BatchCallErr error
+
+	TraceTxResp map[string]json.RawMessage
+	TraceTxErr  error
+
+	CheckHealthErr error
+	UsageResp      []client.Usage
 }
 
-func (f *FakeClient) GetBlock(_ context.Context, _ string) ([]client.TransactionResponse, error) {
+func (f *FakeClient) GetBlock(_ context.Context, blockID string) ([]client.TransactionResponse, error) {
+	if blockID == client.PendingBlock {
+		return f.GetBlockPendingResp, f.GetBlockErr
+	}
 	return f.GetBlockResp, f.GetBlockErr
 }
+
+func (f *FakeClient) FilterBlock(ctx context.Context, blockID string, keep func(*client.TransactionResponse) bool) ([]client.TransactionResponse, error) {
+	txs, err := f.GetBlock(ctx, blockID)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []client.TransactionResponse
+	for i := range txs {
+		if keep == nil || keep(&txs[i]) {
+			kept = append(kept, txs[i])
+		}
+	}
+	return kept, nil
+}
+
+func (f *FakeClient) Call(_ context.Context, _ evm.Address, _ []byte, _ evm.BlockNumber) ([]byte, error) {
+	return f.CallResp, f.CallErr
+}
+
+func (f *FakeClient) BlockNumber(_ context.Context) (evm.BlockNumber, error) {
+	return f.BlockNumberResp, f.BlockNumberErr
+}
+
+func (f *FakeClient) GetBalance(_ context.Context, address evm.Address, _ evm.BlockNumber) (evm.Quantity, error) {
+	return f.GetBalanceResp[address], f.GetBalanceErr
+}
+
+// GetTransactionReceipt returns the receipt registered for the hash, or a successful receipt with no fees.
+func (f *FakeClient) GetTransactionReceipt(_ context.Context, hash string) (*client.ReceiptResponse, error) {
+	if f.GetReceiptErr != nil {
+		return nil, f.GetReceiptErr
+	}
+	if receipt, ok := f.GetReceiptResp[hash]; ok {
+		return receipt, nil
+	}
+	if f.GetReceiptNotFound {
+		return nil, client.ErrReceiptNotFound
+	}
+	return &client.ReceiptResponse{TransactionHash: hash, Status: "0x1"}, nil
+}
+
+// GetTransactionCount returns the count registered for the block id, latest or pending.
+func (f *FakeClient) GetTransactionCount(_ context.Context, _ evm.Address, blockID string) (evm.Nonce, error) {
+	return f.GetTxCountResp[blockID], f.GetTxCountErr
+}
+
+func (f *FakeClient) GetTransactionByHash(_ context.Context, hash string) (*client.TransactionResponse, error) {
+	if f.GetTxByHashErr != nil {
+		return nil, f.GetTxByHashErr
+	}
+	if tx, ok := f.GetTxByHashResp[hash]; ok {
+		return tx, nil
+	}
+	return nil, client.ErrTransactionNotFound
+}
+
+func (f *FakeClient) SendRawTransaction(_ context.Context, _ string) (string, error) {
+	return f.SendRawTxResp, f.SendRawTxErr
+}
+
+func (f *FakeClient) BatchCall(_ context.Context, _ []client.BatchElem) error {
+	return f.BatchCallErr
+}
+
+func (f *FakeClient) GetBlocks(ctx context.Context, blockIDs []string) ([][]client.TransactionResponse, error) {
+	blocks := make([][]client.TransactionResponse, len(blockIDs))
+	for i, blockID := range blockIDs {
+		txs, err := f.GetBlock(ctx, blockID)
+		if err != nil {
+			return nil, err
+		}
+		blocks[i] = txs
+	}
+	return blocks, nil
+}
+
+// GetTransactionReceipts answers like GetTransactionReceipt, unknown receipts are nil.
+func (f *FakeClient) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*client.ReceiptResponse, error) {
+	receipts := make([]*client.ReceiptResponse, len(hashes))
+	for i, hash := range hashes {
+		receipt, err := f.GetTransactionReceipt(ctx, hash)
+		if errors.Is(err, client.ErrReceiptNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		receipts[i] = receipt
+	}
+	return receipts, nil
+}
+
+func (f *FakeClient) GetBalances(ctx context.Context, addresses []evm.Address, blockNumber evm.BlockNumber) ([]evm.Quantity, error) {
+	balances := make([]evm.Quantity, len(addresses))
+	for i, address := range addresses {
+		balance, err := f.GetBalance(ctx, address, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		balances[i] = balance
+	}
+	return balances, nil
+}
+
+func (f *FakeClient) CheckHealth(_ context.Context) error {
+	return f.CheckHealthErr
+}
+
+func (f *FakeClient) Usage() []client.Usage {
+	return f.UsageResp
+}
+
+// TraceTransaction returns client.ErrTransactionNotFound for unregistered hashes.
+func (f *FakeClient) TraceTransaction(_ context.Context, hash string) (json.RawMessage, error) {
+	if f.TraceTxErr != nil {
+		return nil, f.TraceTxErr
+	}
+	if trace, ok := f.TraceTxResp[hash]; ok {
+		return trace, nil
+	}
+	return nil, client.ErrTransactionNotFound
+}
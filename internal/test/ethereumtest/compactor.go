@@ -0,0 +1,20 @@
+package ethereumtest
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+)
+
+type FakeCompactor struct {
+	CompactErr  error
+	MetricsResp parser.CompactionMetrics
+}
+
+func (f *FakeCompactor) Compact(_ context.Context) error {
+	return f.CompactErr
+}
+
+func (f *FakeCompactor) Metrics() parser.CompactionMetrics {
+	return f.MetricsResp
+}
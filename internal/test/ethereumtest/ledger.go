@@ -0,0 +1,27 @@
+package ethereumtest
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+type FakeLedger struct {
+	GetBalanceResp parser.Balance
+	GetBalanceErr  error
+	ReconcileErr   error
+	Forgotten      []evm.Address
+}
+
+func (f *FakeLedger) GetBalance(_ context.Context, _ evm.Address) (parser.Balance, error) {
+	return f.GetBalanceResp, f.GetBalanceErr
+}
+
+func (f *FakeLedger) Reconcile(_ context.Context) error {
+	return f.ReconcileErr
+}
+
+func (f *FakeLedger) Forget(address evm.Address) {
+	f.Forgotten = append(f.Forgotten, address)
+}
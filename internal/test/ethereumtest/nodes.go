@@ -0,0 +1,13 @@
+package ethereumtest
+
+import (
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+)
+
+type FakeNodeMonitor struct {
+	UsageResp []parser.NodeUsage
+}
+
+func (f *FakeNodeMonitor) Usage() []parser.NodeUsage {
+	return f.UsageResp
+}
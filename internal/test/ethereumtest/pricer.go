@@ -0,0 +1,20 @@
+package ethereumtest
+
+import (
+	"context"
+
+	"github.com/jeronimobarea/transaction_parser/internal/parser"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+type FakePricer struct {
+	ValueResp *parser.FiatValue
+	ValueErr  error
+	// ValueCalls counts the lookups
+	ValueCalls int
+}
+
+func (f *FakePricer) Value(_ context.Context, _ evm.Address, _ evm.Quantity, _ evm.BlockNumber) (*parser.FiatValue, error) {
+	f.ValueCalls++
+	return f.ValueResp, f.ValueErr
+}
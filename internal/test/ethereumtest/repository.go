@@ -1,31 +1,56 @@
 package ethereumtest
 
 import (
+	"context"
+
 	"github.com/jeronimobarea/transaction_parser/internal/parser"
 	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
 )
 
 type FakeRepo struct {
-	GetLastParsedBlockResp string
+	GetLastParsedBlockResp evm.BlockNumber
+	GetLastParsedBlockErr  error
 	GetTransactionsResp    []parser.Transaction
+	GetTransactionsErr     error
+	QueryTransactionsResp  parser.TransactionPage
+	QueryTransactionsErr   error
+	SaveBlockErr           error
 	HasAddressResp         bool
+	HasAddressErr          error
+	GetAddressesResp       []evm.Address
+	GetAddressesErr        error
 	AddAddressErr          error
+	RemoveAddressErr       error
+}
+
+func (r FakeRepo) GetLastParsedBlock(_ context.Context) (evm.BlockNumber, error) {
+	return r.GetLastParsedBlockResp, r.GetLastParsedBlockErr
+}
+
+func (r FakeRepo) GetTransactions(_ context.Context, _ evm.Address) ([]parser.Transaction, error) {
+	return r.GetTransactionsResp, r.GetTransactionsErr
 }
 
-func (r FakeRepo) GetLastParsedBlock() string {
-	return r.GetLastParsedBlockResp
+func (r FakeRepo) QueryTransactions(_ context.Context, _ evm.Address, _ parser.TransactionQuery) (parser.TransactionPage, error) {
+	return r.QueryTransactionsResp, r.QueryTransactionsErr
 }
 
-func (r FakeRepo) GetTransactions(_ evm.Address) []parser.Transaction {
-	return r.GetTransactionsResp
+func (r FakeRepo) SaveBlock(_ context.Context, _ evm.BlockNumber, _ map[evm.Address][]parser.Transaction) error {
+	return r.SaveBlockErr
 }
 
-func (r FakeRepo) SaveTransaction(_ evm.Address, _ parser.Transaction) {}
+func (r FakeRepo) HasAddress(_ context.Context, _ evm.Address) (bool, error) {
+	return r.HasAddressResp, r.HasAddressErr
+}
 
-func (r FakeRepo) HasAddress(_ evm.Address) bool {
-	return r.HasAddressResp
+func (r FakeRepo) GetAddresses(_ context.Context) ([]evm.Address, error) {
+	return r.GetAddressesResp, r.GetAddressesErr
 }
 
-func (r FakeRepo) AddAddress(_ evm.Address) error {
+func (r FakeRepo) AddAddress(_ context.Context, _ evm.Address) error {
 	return r.AddAddressErr
 }
+
+func (r FakeRepo) RemoveAddress(_ context.Context, _ evm.Address, _ bool) error {
+	return r.RemoveAddressErr
+}
@@ -0,0 +1,645 @@
+package nodetest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+const (
+	// ChainID is the id answered by the node, the mainnet one.
+	ChainID = 1
+
+	defaultGasUsed  = 21000
+	defaultGasPrice = 1_000_000_000
+)
+
+// Tx is a transaction to mine, the zero values are filled with sensible defaults:
+// the next nonce of the sender, 21000 gas at 1 gwei and a hash derived from the content.
+type Tx struct {
+	Hash     string
+	From     evm.Address
+	To       evm.Address
+	Value    evm.Quantity
+	Input    string
+	Nonce    *evm.Nonce
+	GasUsed  uint64
+	GasPrice uint64
+	Failed   bool
+}
+
+// Block is a mined block of the simulated chain.
+type Block struct {
+	Number       evm.BlockNumber
+	Hash         string
+	ParentHash   string
+	Timestamp    uint64
+	Transactions []Tx
+}
+
+// Node is an in-process node speaking JSON-RPC over http, keeping a chain that tests
+// script by mining blocks, reorganizing them and injecting failures.
+type Node struct {
+	server *httptest.Server
+
+	mu sync.Mutex
+	// blocks holds the canonical chain, blocks[i] being block number i.
+	blocks []*Block
+	// balances holds the state after each canonical block.
+	balances []map[evm.Address]*big.Int
+	mempool  []Tx
+	// rawTxs are the transactions accepted by eth_sendRawTransaction.
+	rawTxs   map[string]Tx
+	contract map[string]string
+	// reorgs salts the hashes of replacement blocks so they differ from the ones they replace.
+	reorgs int
+
+	latency      time.Duration
+	failures     []int
+	methodErrors map[string]*client.RPCError
+	requests     map[string]int
+}
+
+// NewNode starts a node holding the genesis block, stopped at the end of the test.
+func NewNode(t testing.TB) *Node {
+	n := &Node{
+		rawTxs:       make(map[string]Tx),
+		contract:     make(map[string]string),
+		methodErrors: make(map[string]*client.RPCError),
+		requests:     make(map[string]int),
+	}
+	n.blocks = []*Block{{Hash: hash("block", 0, 0), ParentHash: hash("genesis")}}
+	n.balances = []map[evm.Address]*big.Int{{}}
+
+	n.server = httptest.NewServer(http.HandlerFunc(n.serveHTTP))
+	t.Cleanup(n.server.Close)
+	return n
+}
+
+// URL is the JSON-RPC endpoint of the node.
+func (n *Node) URL() string {
+	return n.server.URL
+}
+
+// Head returns the number of the latest block.
+func (n *Node) Head() evm.BlockNumber {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.head().Number
+}
+
+// Fund credits the address at the head, as if it had been funded at genesis.
+func (n *Node) Fund(address evm.Address, amount evm.Quantity) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	address = normalize(address)
+	state := n.balances[len(n.balances)-1]
+	state[address] = new(big.Int).Add(balanceOf(state, address), amount.Big())
+}
+
+// Mine appends a block holding the transactions, removing them from the mempool.
+func (n *Node) Mine(txs ...Tx) Block {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return *n.mine(txs)
+}
+
+// Reorg drops the last depth blocks and mines a replacement block for each list of
+// transactions. The dropped transactions missing from the replacements are lost.
+func (n *Node) Reorg(depth int, replacements ...[]Tx) []Block {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	depth = min(depth, len(n.blocks)-1)
+	n.blocks = n.blocks[:len(n.blocks)-depth]
+	n.balances = n.balances[:len(n.balances)-depth]
+	n.reorgs++
+
+	mined := make([]Block, len(replacements))
+	for i, txs := range replacements {
+		mined[i] = *n.mine(txs)
+	}
+	return mined
+}
+
+// AddPending puts the transaction in the mempool, visible in the pending block.
+func (n *Node) AddPending(tx Tx) Tx {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	tx = n.withDefaults(tx, n.head().Number+1, len(n.mempool), n.nonceAt(normalize(tx.From), n.head().Number+1))
+	n.mempool = append(n.mempool, tx)
+	return tx
+}
+
+// AcceptRawTransaction makes eth_sendRawTransaction take the raw transaction as tx,
+// the node being unable to decode and recover signed transactions.
+func (n *Node) AcceptRawTransaction(rawTx string, tx Tx) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.rawTxs[strings.ToLower(rawTx)] = tx
+}
+
+// SetCall sets the result of eth_call for the contract and calldata.
+func (n *Node) SetCall(to evm.Address, data, result []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.contract[callKey(string(to), "0x"+hex.EncodeToString(data))] = "0x" + hex.EncodeToString(result)
+}
+
+// SetLatency delays every answer.
+func (n *Node) SetLatency(latency time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.latency = latency
+}
+
+// FailNext answers the next count requests with the http status.
+func (n *Node) FailNext(count int, status int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for range count {
+		n.failures = append(n.failures, status)
+	}
+}
+
+// FailMethod answers every call of the method with the error, until cleared with nil.
+func (n *Node) FailMethod(method string, err *client.RPCError) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err == nil {
+		delete(n.methodErrors, method)
+		return
+	}
+	n.methodErrors[method] = err
+}
+
+// Requests returns the number of calls received for the method.
+func (n *Node) Requests(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.requests[method]
+}
+
+func (n *Node) head() *Block {
+	return n.blocks[len(n.blocks)-1]
+}
+
+func (n *Node) mine(txs []Tx) *Block {
+	parent := n.head()
+	number := parent.Number + 1
+
+	block := &Block{
+		Number:     number,
+		Hash:       hash("block", uint64(number), uint64(n.reorgs)),
+		ParentHash: parent.Hash,
+		Timestamp:  1_700_000_000 + 12*uint64(number),
+	}
+
+	state := make(map[evm.Address]*big.Int, len(n.balances[len(n.balances)-1]))
+	for address, balance := range n.balances[len(n.balances)-1] {
+		state[address] = balance
+	}
+
+	nonces := make(map[evm.Address]evm.Nonce)
+	for i, tx := range txs {
+		sender := normalize(tx.From)
+		nonce, ok := nonces[sender]
+		if !ok {
+			nonce = n.nonceAt(sender, parent.Number)
+		}
+
+		tx = n.withDefaults(tx, number, i, nonce)
+		nonces[sender] = *tx.Nonce + 1
+		block.Transactions = append(block.Transactions, tx)
+
+		fee := new(big.Int).SetUint64(tx.GasUsed * tx.GasPrice)
+		spent := fee
+		if !tx.Failed {
+			spent = new(big.Int).Add(fee, tx.Value.Big())
+			state[tx.To] = new(big.Int).Add(balanceOf(state, tx.To), tx.Value.Big())
+		}
+		state[tx.From] = new(big.Int).Sub(balanceOf(state, tx.From), spent)
+
+		n.dropPending(tx.Hash)
+	}
+
+	n.blocks = append(n.blocks, block)
+	n.balances = append(n.balances, state)
+	return block
+}
+
+// withDefaults fills the unset fields of a transaction at the index of the block, nonce
+// being the next one of the sender.
+func (n *Node) withDefaults(tx Tx, number evm.BlockNumber, index int, nonce evm.Nonce) Tx {
+	tx.From = normalize(tx.From)
+	tx.To = normalize(tx.To)
+	if tx.Nonce == nil {
+		tx.Nonce = &nonce
+	}
+	if tx.GasUsed == 0 {
+		tx.GasUsed = defaultGasUsed
+	}
+	if tx.GasPrice == 0 {
+		tx.GasPrice = defaultGasPrice
+	}
+	if tx.Input == "" {
+		tx.Input = "0x"
+	}
+	if tx.Hash == "" {
+		tx.Hash = hash(fmt.Sprint("tx", tx.From, *tx.Nonce, index, n.reorgs), uint64(number))
+	}
+	tx.Hash = strings.ToLower(tx.Hash)
+	return tx
+}
+
+func (n *Node) dropPending(txHash string) {
+	for i, tx := range n.mempool {
+		if tx.Hash == txHash {
+			n.mempool = append(n.mempool[:i], n.mempool[i+1:]...)
+			return
+		}
+	}
+}
+
+func (n *Node) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	latency := n.latency
+	var status int
+	if len(n.failures) > 0 {
+		status, n.failures = n.failures[0], n.failures[1:]
+	}
+	n.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	body = []byte(strings.TrimSpace(string(body)))
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(body) > 0 && body[0] == '[' {
+		var calls []request
+		if err := json.Unmarshal(body, &calls); err != nil {
+			json.NewEncoder(w).Encode(errorResponse(nil, client.CodeParseError, err.Error()))
+			return
+		}
+
+		answers := make([]response, len(calls))
+		for i, call := range calls {
+			answers[i] = n.answer(call)
+		}
+		json.NewEncoder(w).Encode(answers)
+		return
+	}
+
+	var call request
+	if err := json.Unmarshal(body, &call); err != nil {
+		json.NewEncoder(w).Encode(errorResponse(nil, client.CodeParseError, err.Error()))
+		return
+	}
+	json.NewEncoder(w).Encode(n.answer(call))
+}
+
+type request struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Result  any              `json:"result"`
+	Error   *client.RPCError `json:"error,omitempty"`
+}
+
+func errorResponse(id json.RawMessage, code int, message string) response {
+	if id == nil {
+		id = json.RawMessage(`null`)
+	}
+	return response{JSONRPC: client.JSONRPCVersion, ID: id, Error: &client.RPCError{Code: code, Message: message}}
+}
+
+func (n *Node) answer(call request) response {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.requests[call.Method]++
+	if err, ok := n.methodErrors[call.Method]; ok {
+		return response{JSONRPC: client.JSONRPCVersion, ID: call.ID, Error: err}
+	}
+
+	result, err := n.dispatch(call.Method, call.Params)
+	if err != nil {
+		return response{JSONRPC: client.JSONRPCVersion, ID: call.ID, Error: err}
+	}
+	return response{JSONRPC: client.JSONRPCVersion, ID: call.ID, Result: result}
+}
+
+func (n *Node) dispatch(method string, params []json.RawMessage) (any, *client.RPCError) {
+	switch method {
+	case "eth_chainId":
+		return evm.BlockNumber(ChainID).Hex(), nil
+
+	case "net_version":
+		return fmt.Sprint(ChainID), nil
+
+	case "eth_syncing":
+		return false, nil
+
+	case client.EthBlockNumber:
+		return n.head().Number.Hex(), nil
+
+	case client.EthGetBlockByNumber:
+		var blockID string
+		if err := param(params, 0, &blockID); err != nil {
+			return nil, err
+		}
+		if blockID == client.PendingBlock {
+			return n.pendingBlockJSON(), nil
+		}
+		block, ok := n.block(blockID)
+		if !ok {
+			return nil, nil
+		}
+		return blockJSON(block), nil
+
+	case client.EthGetReceipt:
+		var txHash string
+		if err := param(params, 0, &txHash); err != nil {
+			return nil, err
+		}
+		block, tx, index, ok := n.mined(txHash)
+		if !ok {
+			return nil, nil
+		}
+		return receiptJSON(block, tx, index), nil
+
+	case client.EthGetTxByHash:
+		var txHash string
+		if err := param(params, 0, &txHash); err != nil {
+			return nil, err
+		}
+		if block, tx, index, ok := n.mined(txHash); ok {
+			return txJSON(block.Number, block.Hash, tx, index), nil
+		}
+		for _, tx := range n.mempool {
+			if tx.Hash == strings.ToLower(txHash) {
+				return pendingTxJSON(tx), nil
+			}
+		}
+		return nil, nil
+
+	case client.EthGetBalance:
+		var (
+			address evm.Address
+			blockID string
+		)
+		if err := param(params, 0, &address); err != nil {
+			return nil, err
+		}
+		if err := param(params, 1, &blockID); err != nil {
+			return nil, err
+		}
+		block, ok := n.block(blockID)
+		if !ok {
+			return nil, &client.RPCError{Code: -32000, Message: "header not found"}
+		}
+		return evm.NewQuantity(balanceOf(n.balances[block.Number], normalize(address))).Hex(), nil
+
+	case client.EthGetTxCount:
+		var (
+			address evm.Address
+			blockID string
+		)
+		if err := param(params, 0, &address); err != nil {
+			return nil, err
+		}
+		if err := param(params, 1, &blockID); err != nil {
+			return nil, err
+		}
+		number := n.head().Number
+		if blockID == client.PendingBlock {
+			number++
+		} else if block, ok := n.block(blockID); ok {
+			number = block.Number
+		}
+		return n.nonceAt(normalize(address), number).Hex(), nil
+
+	case client.EthCall:
+		var call struct {
+			To   string `json:"to"`
+			Data string `json:"data"`
+		}
+		if err := param(params, 0, &call); err != nil {
+			return nil, err
+		}
+		result, ok := n.contract[callKey(call.To, call.Data)]
+		if !ok {
+			return nil, &client.RPCError{Code: 3, Message: "execution reverted"}
+		}
+		return result, nil
+
+	case client.EthSendRawTx:
+		var rawTx string
+		if err := param(params, 0, &rawTx); err != nil {
+			return nil, err
+		}
+		tx, ok := n.rawTxs[strings.ToLower(rawTx)]
+		if !ok {
+			return nil, &client.RPCError{Code: -32000, Message: "invalid transaction"}
+		}
+		tx = n.withDefaults(tx, n.head().Number+1, len(n.mempool), n.nonceAt(normalize(tx.From), n.head().Number+1))
+		n.mempool = append(n.mempool, tx)
+		return tx.Hash, nil
+	}
+
+	return nil, &client.RPCError{Code: client.CodeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", method)}
+}
+
+// nonceAt counts the transactions of the sender up to the block, the mempool ones included
+// past the head.
+func (n *Node) nonceAt(sender evm.Address, number evm.BlockNumber) evm.Nonce {
+	var nonce evm.Nonce
+	for _, block := range n.blocks[:min(int(number), len(n.blocks)-1)+1] {
+		for _, tx := range block.Transactions {
+			if tx.From == sender {
+				nonce++
+			}
+		}
+	}
+	if number > n.head().Number {
+		for _, tx := range n.mempool {
+			if tx.From == sender {
+				nonce++
+			}
+		}
+	}
+	return nonce
+}
+
+func (n *Node) block(blockID string) (*Block, bool) {
+	switch blockID {
+	case client.LatestBlock, "safe", "finalized":
+		return n.head(), true
+	case "earliest":
+		return n.blocks[0], true
+	}
+
+	number, err := evm.ParseBlockNumber(blockID)
+	if err != nil || int(number) >= len(n.blocks) {
+		return nil, false
+	}
+	return n.blocks[number], true
+}
+
+func (n *Node) mined(txHash string) (*Block, Tx, int, bool) {
+	txHash = strings.ToLower(txHash)
+	for _, block := range n.blocks {
+		for i, tx := range block.Transactions {
+			if tx.Hash == txHash {
+				return block, tx, i, true
+			}
+		}
+	}
+	return nil, Tx{}, 0, false
+}
+
+func (n *Node) pendingBlockJSON() map[string]any {
+	head := n.head()
+	txs := make([]map[string]any, len(n.mempool))
+	for i, tx := range n.mempool {
+		txs[i] = txJSON(head.Number+1, "", tx, i)
+	}
+	return map[string]any{
+		"number":       (head.Number + 1).Hex(),
+		"parentHash":   head.Hash,
+		"transactions": txs,
+	}
+}
+
+func blockJSON(block *Block) map[string]any {
+	txs := make([]map[string]any, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		txs[i] = txJSON(block.Number, block.Hash, tx, i)
+	}
+	return map[string]any{
+		"number":       block.Number.Hex(),
+		"hash":         block.Hash,
+		"parentHash":   block.ParentHash,
+		"timestamp":    evm.BlockNumber(block.Timestamp).Hex(),
+		"transactions": txs,
+	}
+}
+
+func txJSON(number evm.BlockNumber, blockHash string, tx Tx, index int) map[string]any {
+	fields := map[string]any{
+		"hash":             tx.Hash,
+		"from":             tx.From,
+		"to":               tx.To,
+		"value":            tx.Value.Hex(),
+		"nonce":            tx.Nonce.Hex(),
+		"input":            tx.Input,
+		"gas":              evm.BlockNumber(tx.GasUsed).Hex(),
+		"gasPrice":         evm.BlockNumber(tx.GasPrice).Hex(),
+		"blockNumber":      number.Hex(),
+		"transactionIndex": evm.BlockNumber(index).Hex(),
+	}
+	if blockHash != "" {
+		fields["blockHash"] = blockHash
+	}
+	return fields
+}
+
+// pendingTxJSON answers a mempool transaction the way nodes do, with no block nor index.
+func pendingTxJSON(tx Tx) map[string]any {
+	fields := txJSON(0, "", tx, 0)
+	fields["blockNumber"], fields["blockHash"], fields["transactionIndex"] = nil, nil, nil
+	return fields
+}
+
+func receiptJSON(block *Block, tx Tx, index int) map[string]any {
+	status := "0x1"
+	if tx.Failed {
+		status = "0x0"
+	}
+	return map[string]any{
+		"transactionHash":   tx.Hash,
+		"transactionIndex":  evm.BlockNumber(index).Hex(),
+		"blockNumber":       block.Number.Hex(),
+		"blockHash":         block.Hash,
+		"from":              tx.From,
+		"to":                tx.To,
+		"gasUsed":           evm.BlockNumber(tx.GasUsed).Hex(),
+		"effectiveGasPrice": evm.BlockNumber(tx.GasPrice).Hex(),
+		"status":            status,
+		"logs":              []any{},
+	}
+}
+
+func param(params []json.RawMessage, i int, v any) *client.RPCError {
+	if i >= len(params) {
+		return &client.RPCError{Code: client.CodeInvalidParams, Message: fmt.Sprintf("missing value for required argument %d", i)}
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return &client.RPCError{Code: client.CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func balanceOf(state map[evm.Address]*big.Int, address evm.Address) *big.Int {
+	if balance, ok := state[address]; ok {
+		return balance
+	}
+	return new(big.Int)
+}
+
+func normalize(address evm.Address) evm.Address {
+	return evm.Address(strings.ToLower(string(address)))
+}
+
+func callKey(to, data string) string {
+	return strings.ToLower(to) + ":" + strings.ToLower(data)
+}
+
+// hash derives a deterministic 32 bytes hash from the values.
+func hash(kind string, values ...uint64) string {
+	h := sha256.New()
+	h.Write([]byte(kind))
+	for _, v := range values {
+		h.Write(binary.BigEndian.AppendUint64(nil, v))
+	}
+	return "0x" + hex.EncodeToString(h.Sum(nil))
+}
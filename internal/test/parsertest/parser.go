@@ -9,16 +9,29 @@ import (
 type FakeParserSvc struct {
 	GetCurrentBlockResp int64
 	GetCurrentBlockErr  error
-	GetTransactionsResp []parser.Transaction
+	GetTransactionsResp parser.TransactionPage
 	GetTransactionsErr  error
 	SubscribeErr        error
+	UnsubscribeErr      error
+	GetBalanceResp      parser.Balance
+	GetBalanceErr       error
+	GetNoncesResp       parser.NonceReport
+	GetNoncesErr        error
+	BroadcastResp       parser.BroadcastTransaction
+	BroadcastErr        error
+	GetBroadcastResp    parser.BroadcastTransaction
+	GetBroadcastErr     error
+	GetCompactionResp   parser.CompactionMetrics
+	GetCompactionErr    error
+	GetNodeUsageResp    []parser.NodeUsage
+	GetNodeUsageErr     error
 }
 
 func (f *FakeParserSvc) GetCurrentBlock(_ context.Context) (int64, error) {
 	return f.GetCurrentBlockResp, f.GetCurrentBlockErr
 }
 
-func (f *FakeParserSvc) GetTransactions(_ context.Context, _ string) ([]parser.Transaction, error) {
+func (f *FakeParserSvc) GetTransactions(_ context.Context, _ string, _ parser.TransactionQuery) (parser.TransactionPage, error) {
 	return f.GetTransactionsResp, f.GetTransactionsErr
 }
 
@@ -26,6 +39,34 @@ func (f *FakeParserSvc) Subscribe(_ context.Context, _ string) error {
 	return f.SubscribeErr
 }
 
+func (f *FakeParserSvc) Unsubscribe(_ context.Context, _ string, _ bool) error {
+	return f.UnsubscribeErr
+}
+
+func (f *FakeParserSvc) GetBalance(_ context.Context, _ string) (parser.Balance, error) {
+	return f.GetBalanceResp, f.GetBalanceErr
+}
+
+func (f *FakeParserSvc) GetNonces(_ context.Context, _ string) (parser.NonceReport, error) {
+	return f.GetNoncesResp, f.GetNoncesErr
+}
+
+func (f *FakeParserSvc) Broadcast(_ context.Context, _ string) (parser.BroadcastTransaction, error) {
+	return f.BroadcastResp, f.BroadcastErr
+}
+
+func (f *FakeParserSvc) GetBroadcast(_ context.Context, _ string) (parser.BroadcastTransaction, error) {
+	return f.GetBroadcastResp, f.GetBroadcastErr
+}
+
+func (f *FakeParserSvc) GetCompactionMetrics(_ context.Context) (parser.CompactionMetrics, error) {
+	return f.GetCompactionResp, f.GetCompactionErr
+}
+
+func (f *FakeParserSvc) GetNodeUsage(_ context.Context) ([]parser.NodeUsage, error) {
+	return f.GetNodeUsageResp, f.GetNodeUsageErr
+}
+
 func (f *FakeParserSvc) Register(_ int, _ parser.Parser) {
 	panic("unimplemented")
 }
@@ -0,0 +1,126 @@
+package rpctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+type message struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// Recorder is a transport capturing the JSON-RPC calls going through it into a cassette.
+type Recorder struct {
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder records the calls sent with next, the default transport when nil.
+func NewRecorder(next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{
+		next: next,
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	if resp.StatusCode == http.StatusOK {
+		r.record(requestBody, responseBody)
+	}
+	return resp, nil
+}
+
+// Cassette returns the calls recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Cassette{
+		Interactions: append([]Interaction(nil), r.cassette.Interactions...),
+	}
+}
+
+// record pairs the calls of a single or batch exchange with their answers by id.
+func (r *Recorder) record(requestBody, responseBody []byte) {
+	calls, ok := decodeMessages(requestBody)
+	if !ok {
+		return
+	}
+	answers, ok := decodeMessages(responseBody)
+	if !ok {
+		return
+	}
+
+	answersByID := make(map[string]message, len(answers))
+	for _, answer := range answers {
+		answersByID[string(answer.ID)] = answer
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, call := range calls {
+		answer, ok := answersByID[string(call.ID)]
+		if !ok {
+			continue
+		}
+		r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+			Method: call.Method,
+			Params: call.Params,
+			Result: answer.Result,
+			Error:  answer.Error,
+		})
+	}
+}
+
+// decodeMessages reads a single message or a batch of them.
+func decodeMessages(body []byte) ([]message, bool) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, false
+	}
+
+	if body[0] == '[' {
+		var messages []message
+		err := json.Unmarshal(body, &messages)
+		return messages, err == nil
+	}
+
+	var msg message
+	err := json.Unmarshal(body, &msg)
+	return []message{msg}, err == nil
+}
@@ -0,0 +1,69 @@
+package rpctest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+	"github.com/jeronimobarea/transaction_parser/internal/pkg/evm"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+
+	var head uint64 = 10
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var calls []message
+		json.NewDecoder(r.Body).Decode(&calls)
+
+		answers := make([]string, len(calls))
+		for i, call := range calls {
+			answers[i] = fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":"0x%x"}`, call.ID, head)
+			head++
+		}
+		fmt.Fprintf(w, "[%s", answers[0])
+		for _, answer := range answers[1:] {
+			fmt.Fprintf(w, ",%s", answer)
+		}
+		fmt.Fprint(w, "]")
+	}))
+	defer node.Close()
+
+	recorder := NewRecorder(nil)
+	recording := client.NewClient([]string{node.URL}, log.Default(), client.WithHTTPClient(&http.Client{Transport: recorder}))
+
+	addresses := []evm.Address{"0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002"}
+	if _, err := recording.GetBalances(ctx, addresses, 1); err != nil {
+		t.Fatalf("GetBalances: unexpected error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := recorder.Cassette().Save(path); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	cassette, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: unexpected error: %v", err)
+	}
+	if len(cassette.Interactions) != 2 {
+		t.Fatalf("expected a recorded interaction per batched call, got %+v", cassette.Interactions)
+	}
+
+	replay := NewReplayServer(t, cassette)
+	replaying := client.NewClient([]string{replay.URL}, log.Default())
+
+	// each call is matched by its params, whatever its position in the batch
+	balances, err := replaying.GetBalances(ctx, []evm.Address{addresses[1], addresses[0]}, 1)
+	if err != nil {
+		t.Fatalf("GetBalances: unexpected error: %v", err)
+	}
+	if balances[0].String() != "11" || balances[1].String() != "10" {
+		t.Errorf("GetBalances = %v; want [11 10]", balances)
+	}
+}
@@ -0,0 +1,122 @@
+package rpctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/jeronimobarea/transaction_parser/internal/chains/ethereum/client"
+)
+
+// RecordURLEnv is the node recorded from instead of replaying the cassettes when set.
+const RecordURLEnv = "RPC_RECORD_URL"
+
+// NewReplayServer serves the cassette back, answering each call with the interactions
+// recorded for the same method and params in order, and repeating the last one once they
+// are exhausted. Calls missing from the cassette fail the test.
+func NewReplayServer(t testing.TB, cassette *Cassette) *httptest.Server {
+	var (
+		mu     sync.Mutex
+		played = make(map[string]int)
+		byKey  = make(map[string][]Interaction)
+	)
+	for _, interaction := range cassette.Interactions {
+		k := key(interaction.Method, interaction.Params)
+		byKey[k] = append(byKey[k], interaction)
+	}
+
+	answer := func(call message) message {
+		mu.Lock()
+		defer mu.Unlock()
+
+		k := key(call.Method, call.Params)
+		recorded := byKey[k]
+		if len(recorded) == 0 {
+			t.Errorf("replay: no recorded interaction for %s", k)
+			return message{
+				ID:    call.ID,
+				Error: json.RawMessage(fmt.Sprintf(`{"code":-32601,"message":%q}`, "no recorded interaction for "+call.Method)),
+			}
+		}
+
+		interaction := recorded[min(played[k], len(recorded)-1)]
+		played[k]++
+		return message{ID: call.ID, Result: interaction.Result, Error: interaction.Error}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		calls, ok := decodeMessages(body)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		answers := make([]response, len(calls))
+		for i, call := range calls {
+			answers[i] = newResponse(answer(call))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if body[0] == '[' {
+			json.NewEncoder(w).Encode(answers)
+			return
+		}
+		json.NewEncoder(w).Encode(answers[0])
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// NewClient returns a client replaying the cassette at path. When RPC_RECORD_URL is set
+// the calls go to that node instead and the cassette is rewritten at the end of the test.
+func NewClient(t testing.TB, path string) client.Client {
+	if url := os.Getenv(RecordURLEnv); url != "" {
+		recorder := NewRecorder(nil)
+		t.Cleanup(func() {
+			if err := recorder.Cassette().Save(path); err != nil {
+				t.Errorf("saving cassette %s: %v", path, err)
+			}
+		})
+		return client.NewClient([]string{url}, log.Default(), client.WithHTTPClient(&http.Client{Transport: recorder}))
+	}
+
+	cassette, err := Load(path)
+	if err != nil {
+		t.Fatalf("loading cassette %s: %v", path, err)
+	}
+	server := NewReplayServer(t, cassette)
+	return client.NewClient([]string{server.URL}, log.Default())
+}
+
+// response always carries the jsonrpc version and either a result or an error.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+func newResponse(msg message) response {
+	resp := response{
+		JSONRPC: client.JSONRPCVersion,
+		ID:      msg.ID,
+		Result:  msg.Result,
+		Error:   msg.Error,
+	}
+	if resp.Result == nil && resp.Error == nil {
+		resp.Result = json.RawMessage(`null`)
+	}
+	return resp
+}
@@ -1,6 +1,10 @@
 package osx
 
-import "os"
+import (
+	"os"
+	"strings"
+	"time"
+)
 
 func GetEnvFallback(key, fallback string) string {
 	if res, ok := os.LookupEnv(key); ok {
@@ -8,3 +12,27 @@ func GetEnvFallback(key, fallback string) string {
 	}
 	return fallback
 }
+
+func GetEnvDurationFallback(key string, fallback time.Duration) (time.Duration, error) {
+	res, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback, nil
+	}
+	return time.ParseDuration(res)
+}
+
+// GetEnvListFallback splits a comma separated variable, ignoring blank items.
+func GetEnvListFallback(key string, fallback []string) []string {
+	res, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	var list []string
+	for _, item := range strings.Split(res, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}